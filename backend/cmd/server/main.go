@@ -3,6 +3,8 @@ package main
 import (
 	"go-gin-backend/internal/database"
 	"go-gin-backend/internal/routes"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/storage"
 	"log"
 	"os"
 	"strings"
@@ -22,6 +24,17 @@ func main() {
 	// Initialize the database connection
 	database.Connect()
 
+	// Set up where uploaded files are kept, local disk or an S3-compatible bucket
+	storage.Setup()
+
+	// Give businesses stored before snapshots were introduced their first one
+	if err := services.BackfillSnapshots(database.DB); err != nil {
+		log.Printf("Failed to backfill business snapshots: %v", err)
+	}
+
+	// Permanently delete trash older than TRASH_RETENTION_DAYS
+	services.StartTrashPurger(database.DB, storage.Files)
+
 	// Create a new Gin router
 	router := gin.Default()
 
@@ -42,17 +55,14 @@ func main() {
 			}
 			return false
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
 	router.Use(cors.New(config))
 
-	// Serve static files from uploads directory
-	router.Static("/uploads", "./uploads")
-
 	// Setup application routes
 	routes.SetupRoutes(router)
 
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminController struct {
+	userService     *services.UserService
+	businessService *services.BusinessService
+	authService     *services.AuthService
+}
+
+func NewAdminController(userService *services.UserService, businessService *services.BusinessService, authService *services.AuthService) *AdminController {
+	return &AdminController{
+		userService:     userService,
+		businessService: businessService,
+		authService:     authService,
+	}
+}
+
+// ModerationRequest carries the reason recorded in the audit log for an admin action
+type ModerationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type AssignRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// adminActor identifies the admin and the reason for the action in the audit log
+func adminActor(c *gin.Context, reason string) services.AuditActor {
+	actor := auditActor(c)
+	actor.Reason = reason
+	return actor
+}
+
+// parsePagination reads page and limit query parameters with the usual defaults
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// GET /admin/users -> search users by username, email or phone, role and status
+func (ac *AdminController) SearchUsers(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	users, total, err := ac.userService.SearchUsers(services.UserSearchFilter{
+		Query:  c.Query("q"),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":      users,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// POST /admin/users/:id/suspend -> block the user from signing in
+func (ac *AdminController) SuspendUser(c *gin.Context) {
+	userID, req, ok := bindUserModeration(c)
+	if !ok {
+		return
+	}
+
+	user, err := ac.userService.WithActor(adminActor(c, req.Reason)).SuspendUser(userID, req.Reason)
+	if err != nil {
+		respondModerationError(c, err, "Failed to suspend user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// POST /admin/users/:id/unsuspend -> let a suspended user sign in again
+func (ac *AdminController) UnsuspendUser(c *gin.Context) {
+	userID, req, ok := bindUserModeration(c)
+	if !ok {
+		return
+	}
+
+	user, err := ac.userService.WithActor(adminActor(c, req.Reason)).UnsuspendUser(userID)
+	if err != nil {
+		respondModerationError(c, err, "Failed to unsuspend user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// POST /admin/users/:id/unlock -> lift the lockout after repeated failed logins
+func (ac *AdminController) UnlockUser(c *gin.Context) {
+	userID, req, ok := bindUserModeration(c)
+	if !ok {
+		return
+	}
+
+	user, err := ac.userService.WithActor(adminActor(c, req.Reason)).UnlockUser(userID)
+	if err != nil {
+		respondModerationError(c, err, "Failed to unlock user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// PUT /admin/users/:id/role -> assign a role to the user
+func (ac *AdminController) AssignRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ac.userService.WithActor(adminActor(c, req.Reason)).SetUserRole(uint(userID), req.Role)
+	if err != nil {
+		respondModerationError(c, err, "Failed to assign role")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// POST /admin/users/:id/force-password-reset -> sign the user out and email them a reset link
+func (ac *AdminController) ForcePasswordReset(c *gin.Context) {
+	userID, req, ok := bindUserModeration(c)
+	if !ok {
+		return
+	}
+
+	user, err := ac.userService.WithActor(adminActor(c, req.Reason)).RequirePasswordReset(userID)
+	if err != nil {
+		respondModerationError(c, err, "Failed to force password reset")
+		return
+	}
+
+	if err := ac.authService.RequestPasswordReset(user.Email); err != nil {
+		// The account stays blocked; the user can still ask for a new link themselves
+		log.Printf("Failed to send forced password reset email to user %d: %v", user.ID, err)
+		c.JSON(http.StatusOK, gin.H{"user": user, "email_sent": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "email_sent": true})
+}
+
+// GET /admin/security-events -> account lockouts and blocked IP addresses, newest first
+func (ac *AdminController) ListSecurityEvents(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	var userID uint64
+	if value := c.Query("user_id"); value != "" {
+		var err error
+		userID, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+	}
+
+	events, total, err := ac.authService.LoginGuard.ListSecurityEvents(services.SecurityEventFilter{
+		Type:      c.Query("type"),
+		UserID:    uint(userID),
+		IPAddress: c.Query("ip_address"),
+		Page:      page,
+		Limit:     limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":     events,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// GET /admin/businesses -> search businesses, including taken down ones
+func (ac *AdminController) SearchBusinesses(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	businesses, total, err := ac.businessService.SearchBusinesses(services.BusinessSearchFilter{
+		Query:  c.Query("q"),
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search businesses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"businesses": businesses,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// POST /admin/businesses/:id/takedown -> hide the business from investors
+func (ac *AdminController) TakeDownBusiness(c *gin.Context) {
+	businessID, req, ok := bindBusinessModeration(c)
+	if !ok {
+		return
+	}
+
+	business, err := ac.businessService.WithActor(adminActor(c, req.Reason)).TakeDownBusiness(businessID, req.Reason)
+	if err != nil {
+		respondModerationError(c, err, "Failed to take down business")
+		return
+	}
+
+	c.JSON(http.StatusOK, business)
+}
+
+// POST /admin/businesses/:id/restore -> list a taken down business again
+func (ac *AdminController) RestoreBusiness(c *gin.Context) {
+	businessID, req, ok := bindBusinessModeration(c)
+	if !ok {
+		return
+	}
+
+	business, err := ac.businessService.WithActor(adminActor(c, req.Reason)).RestoreBusiness(businessID)
+	if err != nil {
+		respondModerationError(c, err, "Failed to restore business")
+		return
+	}
+
+	c.JSON(http.StatusOK, business)
+}
+
+func bindUserModeration(c *gin.Context) (uint, ModerationRequest, bool) {
+	var req ModerationRequest
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, req, false
+	}
+	return uint(userID), req, true
+}
+
+func bindBusinessModeration(c *gin.Context) (uint, ModerationRequest, bool) {
+	var req ModerationRequest
+	businessID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid business ID"})
+		return 0, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, req, false
+	}
+	return uint(businessID), req, true
+}
+
+func respondModerationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+	case errors.Is(err, services.ErrSelfModeration):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadySuspended),
+		errors.Is(err, services.ErrNotSuspended),
+		errors.Is(err, services.ErrNotLocked),
+		errors.Is(err, services.ErrAlreadyTakenDown),
+		errors.Is(err, services.ErrNotTakenDown):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+func NewAuditController(auditService *services.AuditService) *AuditController {
+	return &AuditController{auditService: auditService}
+}
+
+// auditActor identifies the authenticated caller for the audit log
+func auditActor(c *gin.Context) services.AuditActor {
+	userID, _ := utils.GetUserIDFromContext(c)
+	return services.AuditActor{
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// GET /business/:id/audit-logs -> list changes made to the business, newest first.
+// Filters: entity_type, entity_id, action, actor_id, from, to (RFC 3339 or YYYY-MM-DD)
+func (ac *AuditController) ListBusinessAuditLogs(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	filter := services.AuditLogFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     c.Query("action"),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity_id"})
+			return
+		}
+		filter.EntityID = uint(id)
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor_id"})
+			return
+		}
+		filter.ActorID = uint(id)
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := parseAuditTime(v, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseAuditTime(v, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return
+		}
+		filter.To = &to
+	}
+
+	logs, total, err := ac.auditService.ListBusinessAuditLogs(c.GetUint("businessID"), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audit_logs": logs,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// parseAuditTime accepts RFC 3339 timestamps or plain dates. A plain "to" date includes
+// the whole day.
+func parseAuditTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -1,21 +1,27 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go-gin-backend/internal/models"
 	"go-gin-backend/internal/services"
 	"go-gin-backend/internal/utils"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // BusinessResponse represents the business data sent to frontend
 type BusinessResponse struct {
 	models.Business
-	MarketCap        float64 `json:"market_cap"`
-	EBITDAMultiplier float64 `json:"ebitda_multiplier"`
+	MarketCap        float64                      `json:"market_cap"`
+	EBITDAMultiplier float64                      `json:"ebitda_multiplier"`
+	Completeness     *models.BusinessCompleteness `json:"completeness,omitempty"`
 }
 
 // convertToBusinessResponse converts a Business model to BusinessResponse
@@ -49,10 +55,14 @@ func (bc *BusinessController) GetUserBusinesses(c *gin.Context) {
 		return
 	}
 
-	// Convert to response format with calculated market cap
+	// Convert to response format with calculated market cap and profile completeness
+	now := time.Now()
 	var businessResponses []BusinessResponse
 	for _, business := range businesses {
-		businessResponses = append(businessResponses, convertToBusinessResponse(business))
+		response := convertToBusinessResponse(business)
+		completeness := services.ComputeBusinessCompleteness(&business, now)
+		response.Completeness = &completeness
+		businessResponses = append(businessResponses, response)
 	}
 
 	c.JSON(http.StatusOK, businessResponses)
@@ -95,7 +105,9 @@ func (bc *BusinessController) GetAllBusinessesForInvestment(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GET /investment/businesses/:id -> get business details for investment
+// GET /investment/businesses/:id -> get business details for investment. The response is the
+// snapshot the owners pinned, or the live profile naming the latest snapshot, and
+// ?snapshot_id= shows the business as that snapshot captured it.
 func (bc *BusinessController) GetBusinessForInvestment(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	businessID, err := strconv.ParseUint(businessIDStr, 10, 32)
@@ -104,7 +116,21 @@ func (bc *BusinessController) GetBusinessForInvestment(c *gin.Context) {
 		return
 	}
 
-	business, err := bc.businessService.GetBusinessByID(uint(businessID))
+	if snapshotIDStr := c.Query("snapshot_id"); snapshotIDStr != "" {
+		snapshotID, ok := parseSnapshotID(c, snapshotIDStr)
+		if !ok {
+			return
+		}
+		business, err := bc.businessService.GetListedBusinessSnapshot(uint(businessID), snapshotID)
+		if err != nil {
+			respondSnapshotError(c, err, "Failed to fetch business")
+			return
+		}
+		c.JSON(http.StatusOK, business)
+		return
+	}
+
+	business, err := bc.businessService.GetListedBusinessByID(uint(businessID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Business not found"})
 		return
@@ -123,13 +149,19 @@ type CreateBusinessRequestData struct {
 }
 
 type CreateBusinessRequest struct {
-	UserID     uint                            `json:"user_id" binding:"required"`
+	UserID     uint                            `json:"user_id"` // Ignored, the owner is always the authenticated user
 	Business   CreateBusinessRequestData       `json:"business" binding:"required"`
 	Additional []models.BusinessAdditionalInfo `json:"additional_info"`
 	Products   []models.Product                `json:"products"`
 }
 
 func (bc *BusinessController) CreateBusiness(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	var req CreateBusinessRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -138,7 +170,7 @@ func (bc *BusinessController) CreateBusiness(c *gin.Context) {
 
 	// Convert the request data to Business model
 	business := models.Business{
-		UserID:      req.UserID,
+		UserID:      userID,
 		Name:        req.Business.Name,
 		Type:        req.Business.Type,
 		Description: req.Business.Description,
@@ -152,8 +184,13 @@ func (bc *BusinessController) CreateBusiness(c *gin.Context) {
 		}
 	}
 
-	err := bc.businessService.CreateBusiness(&business, req.Additional, req.Products)
+	err = bc.businessService.WithActor(auditActor(c)).CreateBusiness(&business, req.Additional, req.Products)
 	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create business"})
 		return
 	}
@@ -181,10 +218,40 @@ func (bc *BusinessController) GetBusiness(c *gin.Context) {
 
 	// Convert to response format with calculated market cap
 	businessResponse := convertToBusinessResponse(*business)
+	c.Header("ETag", businessETag(business))
 	c.JSON(http.StatusOK, businessResponse)
 }
 
+// GET /business/:id/completeness -> how complete the business profile is, with what is missing
+func (bc *BusinessController) GetBusinessCompleteness(c *gin.Context) {
+	completeness, err := bc.businessService.GetBusinessCompleteness(c.GetUint("businessID"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Business not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute completeness"})
+		return
+	}
+
+	c.JSON(http.StatusOK, completeness)
+}
+
 // ===== Update Business Basic Info =====
+
+// UpdateBusinessRequest only changes the fields present in the body
+type UpdateBusinessRequest struct {
+	Name        *string `json:"name"`
+	Type        *string `json:"type"`
+	Description *string `json:"description"`
+	Industry    *string `json:"industry"`
+	FoundedAt   *string `json:"founded_at"` // YYYY-MM-DD, or "" to clear the date
+	Version     *uint   `json:"version"`    // Alternative to the If-Match header
+}
+
+// PATCH /business/:id -> update the fields present in the body. Requires the business version,
+// from the ETag, in If-Match or the body.
+// PUT /business/:id -> same, with the version check only applied when one is sent.
 func (bc *BusinessController) UpdateBusiness(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	businessID, err := strconv.ParseUint(businessIDStr, 10, 32)
@@ -193,39 +260,91 @@ func (bc *BusinessController) UpdateBusiness(c *gin.Context) {
 		return
 	}
 
-	var req CreateBusinessRequestData
+	var req UpdateBusinessRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Convert the request data to Business model
-	business := models.Business{
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+	if expectedVersion == nil {
+		expectedVersion = req.Version
+	}
+	if expectedVersion == nil && c.Request.Method == http.MethodPatch {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the business ETag is required"})
+		return
+	}
+
+	patch := services.BusinessPatch{
 		Name:        req.Name,
 		Type:        req.Type,
 		Description: req.Description,
 		Industry:    req.Industry,
 	}
-
-	// Parse founded_at if provided
-	if req.FoundedAt != "" {
-		if parsedTime, err := time.Parse("2006-01-02", req.FoundedAt); err == nil {
-			business.FoundedAt = &parsedTime
+	if req.FoundedAt != nil {
+		if *req.FoundedAt == "" {
+			patch.ClearFoundedAt = true
+		} else {
+			foundedAt, err := time.Parse("2006-01-02", *req.FoundedAt)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "founded_at", "message": "founded_at must be a date in YYYY-MM-DD format"})
+				return
+			}
+			patch.FoundedAt = &foundedAt
 		}
 	}
 
-	business.ID = uint(businessID)
-	if err := bc.businessService.UpdateBusiness(&business); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update business"})
+	business, err := bc.businessService.WithActor(auditActor(c)).UpdateBusiness(uint(businessID), patch, expectedVersion)
+	if err != nil {
+		var validationErr *services.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+		case errors.Is(err, services.ErrEmptyPatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Business not found"})
+		case errors.Is(err, services.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "The business was changed by someone else, reload it and try again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update business"})
+		}
 		return
 	}
 
+	c.Header("ETag", businessETag(business))
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Business updated successfully",
 		"business": business,
 	})
 }
 
+// businessETag identifies the version of the business basic info
+func businessETag(business *models.Business) string {
+	return fmt.Sprintf(`"%d"`, business.Version)
+}
+
+// parseIfMatch reads the business version from an If-Match header. It returns nil when the
+// header is absent or "*".
+func parseIfMatch(header string) (*uint, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	v := uint(version)
+	return &v, nil
+}
+
 // ===== Delete Business =====
 func (bc *BusinessController) DeleteBusiness(c *gin.Context) {
 	businessIDStr := c.Param("id")
@@ -235,7 +354,7 @@ func (bc *BusinessController) DeleteBusiness(c *gin.Context) {
 		return
 	}
 
-	if err := bc.businessService.DeleteBusiness(uint(businessID)); err != nil {
+	if err := bc.businessService.WithActor(auditActor(c)).DeleteBusiness(uint(businessID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete business"})
 		return
 	}
@@ -244,6 +363,9 @@ func (bc *BusinessController) DeleteBusiness(c *gin.Context) {
 }
 
 // ===== Product Management =====
+
+// GET /business/:id/products -> the business's products. Query: q, category, min_price,
+// max_price, sort (name, category, price, capacity or created_at) and order (asc or desc).
 func (bc *BusinessController) GetBusinessProducts(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	businessID, err := strconv.ParseUint(businessIDStr, 10, 32)
@@ -252,15 +374,45 @@ func (bc *BusinessController) GetBusinessProducts(c *gin.Context) {
 		return
 	}
 
-	products, err := bc.businessService.GetBusinessProducts(uint(businessID))
+	filter := services.ProductFilter{
+		Query:    c.Query("q"),
+		Category: c.Query("category"),
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+	}
+	for field, target := range map[string]**float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		raw := c.Query(field)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": field, "message": field + " must be a number"})
+			return
+		}
+		*target = &value
+	}
+
+	products, err := bc.businessService.GetBusinessProducts(uint(businessID), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		respondProductError(c, err, "Failed to fetch products")
 		return
 	}
 
 	c.JSON(http.StatusOK, products)
 }
 
+// GET /business/:id/products/categories -> the categories the business's products use
+func (bc *BusinessController) GetProductCategories(c *gin.Context) {
+	categories, err := bc.businessService.ProductCategories(c.GetUint("businessID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
 type AddProductsRequest struct {
 	Products []models.Product `json:"products" binding:"required"`
 }
@@ -279,14 +431,9 @@ func (bc *BusinessController) AddBusinessProducts(c *gin.Context) {
 		return
 	}
 
-	// Set business ID for all products
-	for i := range req.Products {
-		req.Products[i].BusinessID = uint(businessID)
-	}
-
-	addedProducts, err := bc.businessService.AddBusinessProducts(uint(businessID), req.Products)
+	addedProducts, err := bc.businessService.WithActor(auditActor(c)).AddBusinessProducts(uint(businessID), req.Products)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add products"})
+		respondProductError(c, err, "Failed to add products")
 		return
 	}
 
@@ -296,6 +443,33 @@ func (bc *BusinessController) AddBusinessProducts(c *gin.Context) {
 	})
 }
 
+// nullableNumber tells a number left out of a JSON body from one explicitly set to null
+type nullableNumber struct {
+	Set   bool
+	Value *float64
+}
+
+func (n *nullableNumber) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.Value)
+}
+
+// UpdateProductRequest only changes the fields present in the body. unit_price and
+// production_capacity are cleared with null; images and variants replace the whole list.
+type UpdateProductRequest struct {
+	Name               *string                  `json:"name"`
+	Description        *string                  `json:"description"`
+	Category           *string                  `json:"category"`
+	SKU                *string                  `json:"sku"`
+	UnitPrice          nullableNumber           `json:"unit_price"`
+	Currency           *string                  `json:"currency"`
+	ProductionCapacity nullableNumber           `json:"production_capacity"`
+	CapacityUnit       *string                  `json:"capacity_unit"`
+	Images             *[]models.ProductImage   `json:"images"`
+	Variants           *[]models.ProductVariant `json:"variants"`
+}
+
+// PUT /business/:id/products/:productId -> update the fields present in the body
 func (bc *BusinessController) UpdateBusinessProduct(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	productIDStr := c.Param("productId")
@@ -312,19 +486,76 @@ func (bc *BusinessController) UpdateBusinessProduct(c *gin.Context) {
 		return
 	}
 
-	var updateData map[string]interface{}
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	var req UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = bc.businessService.UpdateBusinessProduct(uint(businessID), uint(productID), updateData)
+	patch := services.ProductPatch{
+		Name:                    req.Name,
+		Description:             req.Description,
+		Category:                req.Category,
+		SKU:                     req.SKU,
+		UnitPrice:               req.UnitPrice.Value,
+		ClearUnitPrice:          req.UnitPrice.Set && req.UnitPrice.Value == nil,
+		Currency:                req.Currency,
+		ProductionCapacity:      req.ProductionCapacity.Value,
+		ClearProductionCapacity: req.ProductionCapacity.Set && req.ProductionCapacity.Value == nil,
+		CapacityUnit:            req.CapacityUnit,
+		Images:                  req.Images,
+		Variants:                req.Variants,
+	}
+
+	product, err := bc.businessService.WithActor(auditActor(c)).UpdateBusinessProduct(uint(businessID), uint(productID), patch)
+	if err != nil {
+		respondProductError(c, err, "Failed to update product")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product updated successfully",
+		"product": product,
+	})
+}
+
+// POST /business/:id/products/:productId/images -> upload a picture of the product.
+// Form fields: file and caption (optional).
+func (bc *BusinessController) AddProductImage(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	file, header, ok := formUpload(c, services.MaxProductImageSize)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	product, err := bc.businessService.WithActor(auditActor(c)).
+		AddProductImage(c.GetUint("businessID"), uint(productID), file, header, c.PostForm("caption"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		respondProductError(c, err, "Failed to upload product image")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
+	c.JSON(http.StatusCreated, product)
+}
+
+func respondProductError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+	case errors.Is(err, services.ErrEmptyPatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	default:
+		respondUploadError(c, err, fallback)
+	}
 }
 
 func (bc *BusinessController) DeleteBusinessProduct(c *gin.Context) {
@@ -343,7 +574,7 @@ func (bc *BusinessController) DeleteBusinessProduct(c *gin.Context) {
 		return
 	}
 
-	err = bc.businessService.DeleteBusinessProduct(uint(businessID), uint(productID))
+	err = bc.businessService.WithActor(auditActor(c)).DeleteBusinessProduct(uint(businessID), uint(productID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
@@ -353,6 +584,7 @@ func (bc *BusinessController) DeleteBusinessProduct(c *gin.Context) {
 }
 
 // ===== Legal Document Management =====
+// GET /business/:id/legal -> the active legal documents, or every version with ?include_superseded=true
 func (bc *BusinessController) GetBusinessLegal(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	businessID, err := strconv.ParseUint(businessIDStr, 10, 32)
@@ -361,7 +593,12 @@ func (bc *BusinessController) GetBusinessLegal(c *gin.Context) {
 		return
 	}
 
-	legals, err := bc.businessService.GetBusinessLegal(uint(businessID))
+	includeSuperseded, ok := parseIncludeSuperseded(c)
+	if !ok {
+		return
+	}
+
+	legals, err := bc.businessService.GetBusinessLegal(uint(businessID), includeSuperseded)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch legal documents"})
 		return
@@ -370,6 +607,7 @@ func (bc *BusinessController) GetBusinessLegal(c *gin.Context) {
 	c.JSON(http.StatusOK, legals)
 }
 
+// POST /business/:id/legal -> upload a legal document. supersedes_id renews an earlier one.
 func (bc *BusinessController) AddBusinessLegal(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	businessID, err := strconv.ParseUint(businessIDStr, 10, 32)
@@ -379,9 +617,8 @@ func (bc *BusinessController) AddBusinessLegal(c *gin.Context) {
 	}
 
 	// Handle multipart form data
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+	file, header, ok := formUpload(c, services.MaxUploadSize())
+	if !ok {
 		return
 	}
 	defer file.Close()
@@ -390,16 +627,22 @@ func (bc *BusinessController) AddBusinessLegal(c *gin.Context) {
 	issuedBy := c.PostForm("issued_by")
 	validUntil := c.PostForm("valid_until")
 	notes := c.PostForm("notes")
+	supersedesID, ok := parseSupersedesID(c)
+	if !ok {
+		return
+	}
 
-	legal, err := bc.businessService.AddBusinessLegal(uint(businessID), file, header, legalType, issuedBy, validUntil, notes)
+	legal, err := bc.businessService.WithActor(auditActor(c)).AddBusinessLegal(uint(businessID), file, header, legalType, issuedBy, validUntil, notes, supersedesID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add legal document"})
+		respondLegalError(c, err, "Failed to add legal document")
 		return
 	}
 
 	c.JSON(http.StatusCreated, legal)
 }
 
+// GET /business/:id/products/legal -> the active product legal documents, or every version
+// with ?include_superseded=true
 func (bc *BusinessController) GetProductsLegal(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	businessID, err := strconv.ParseUint(businessIDStr, 10, 32)
@@ -408,7 +651,12 @@ func (bc *BusinessController) GetProductsLegal(c *gin.Context) {
 		return
 	}
 
-	legals, err := bc.businessService.GetProductsLegal(uint(businessID))
+	includeSuperseded, ok := parseIncludeSuperseded(c)
+	if !ok {
+		return
+	}
+
+	legals, err := bc.businessService.GetProductsLegal(uint(businessID), includeSuperseded)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product legal documents"})
 		return
@@ -417,6 +665,8 @@ func (bc *BusinessController) GetProductsLegal(c *gin.Context) {
 	c.JSON(http.StatusOK, legals)
 }
 
+// POST /business/:id/products/:productId/legal -> upload a product legal document.
+// supersedes_id renews an earlier one.
 func (bc *BusinessController) AddProductLegal(c *gin.Context) {
 	businessIDStr := c.Param("id")
 	productIDStr := c.Param("productId")
@@ -434,9 +684,8 @@ func (bc *BusinessController) AddProductLegal(c *gin.Context) {
 	}
 
 	// Handle multipart form data
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+	file, header, ok := formUpload(c, services.MaxUploadSize())
+	if !ok {
 		return
 	}
 	defer file.Close()
@@ -445,10 +694,18 @@ func (bc *BusinessController) AddProductLegal(c *gin.Context) {
 	issuedBy := c.PostForm("issued_by")
 	validUntil := c.PostForm("valid_until")
 	notes := c.PostForm("notes")
+	supersedesID, ok := parseSupersedesID(c)
+	if !ok {
+		return
+	}
 
-	legal, err := bc.businessService.AddProductLegal(uint(businessID), uint(productID), file, header, legalType, issuedBy, validUntil, notes)
+	legal, err := bc.businessService.WithActor(auditActor(c)).AddProductLegal(uint(businessID), uint(productID), file, header, legalType, issuedBy, validUntil, notes, supersedesID)
+	if errors.Is(err, services.ErrAlreadySuperseded) {
+		respondLegalError(c, err, "Failed to add product legal document")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add product legal document"})
+		respondProductError(c, err, "Failed to add product legal document")
 		return
 	}
 
@@ -576,7 +833,7 @@ func (bc *BusinessController) UpdateBusinessFinancial(c *gin.Context) {
 		return
 	}
 
-	financial, err := bc.businessService.UpdateFinancialData(uint(businessID), req.EBITDA, req.Assets, req.Liabilities, req.Equity, req.Notes)
+	financial, err := bc.businessService.WithActor(auditActor(c)).UpdateFinancialData(uint(businessID), req.EBITDA, req.Assets, req.Liabilities, req.Equity, req.Notes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update financial data"})
 		return
@@ -613,7 +870,7 @@ func (bc *BusinessController) CreateBusinessFinancial(c *gin.Context) {
 		return
 	}
 
-	financial, err := bc.businessService.CreateFinancialData(uint(businessID), req.Revenue, req.EBITDA, req.Assets, req.Liabilities, req.Equity, req.Notes)
+	financial, err := bc.businessService.WithActor(auditActor(c)).CreateFinancialData(uint(businessID), req.Revenue, req.EBITDA, req.Assets, req.Liabilities, req.Equity, req.Notes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create financial data"})
 		return
@@ -683,7 +940,7 @@ func (bc *BusinessController) SaveBusinessProjections(c *gin.Context) {
 		return
 	}
 
-	err = bc.businessService.SaveBusinessHistoricalProjections(uint(businessID), request.Projections)
+	err = bc.businessService.WithActor(auditActor(c)).SaveBusinessHistoricalProjections(uint(businessID), request.Projections)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
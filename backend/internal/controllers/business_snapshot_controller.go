@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateSnapshotRequest struct {
+	Label string `json:"label"`
+}
+
+type RestoreSnapshotRequest struct {
+	Version *uint `json:"version"` // Alternative to the If-Match header
+}
+
+type PinSnapshotRequest struct {
+	SnapshotID uint `json:"snapshot_id" binding:"required"`
+}
+
+// GET /business/:id/snapshots -> list the business's snapshots, newest first
+func (bc *BusinessController) ListSnapshots(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	snapshots, total, err := bc.businessService.ListSnapshots(c.GetUint("businessID"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch snapshots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshots": snapshots,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// POST /business/:id/snapshots -> take a snapshot of the profile as it is now
+func (bc *BusinessController) CreateSnapshot(c *gin.Context) {
+	var req CreateSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	snapshot, err := bc.businessService.WithActor(auditActor(c)).CreateSnapshot(c.GetUint("businessID"), req.Label)
+	if err != nil {
+		respondSnapshotError(c, err, "Failed to create snapshot")
+		return
+	}
+
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// GET /business/:id/snapshots/:snapshotId -> one snapshot with the profile it captured
+func (bc *BusinessController) GetSnapshot(c *gin.Context) {
+	snapshotID, ok := parseSnapshotID(c, c.Param("snapshotId"))
+	if !ok {
+		return
+	}
+
+	snapshot, err := bc.businessService.GetSnapshot(c.GetUint("businessID"), snapshotID)
+	if err != nil {
+		respondSnapshotError(c, err, "Failed to fetch snapshot")
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GET /business/:id/snapshots/:snapshotId/diff -> what changed since the snapshot, up to the
+// current profile or to the snapshot in ?against=
+func (bc *BusinessController) DiffSnapshot(c *gin.Context) {
+	snapshotID, ok := parseSnapshotID(c, c.Param("snapshotId"))
+	if !ok {
+		return
+	}
+
+	var againstID *uint
+	if against := c.Query("against"); against != "" {
+		id, ok := parseSnapshotID(c, against)
+		if !ok {
+			return
+		}
+		againstID = &id
+	}
+
+	changes, err := bc.businessService.DiffSnapshot(c.GetUint("businessID"), snapshotID, againstID)
+	if err != nil {
+		respondSnapshotError(c, err, "Failed to compare snapshots")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshot_id": snapshotID,
+		"against_id":  againstID, // null for the current profile
+		"changes":     changes,
+	})
+}
+
+// POST /business/:id/snapshots/:snapshotId/restore -> bring the profile back to the snapshot.
+// Takes the business version in If-Match or the body to guard against concurrent edits.
+func (bc *BusinessController) RestoreSnapshot(c *gin.Context) {
+	snapshotID, ok := parseSnapshotID(c, c.Param("snapshotId"))
+	if !ok {
+		return
+	}
+
+	var req RestoreSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+	if expectedVersion == nil {
+		expectedVersion = req.Version
+	}
+
+	business, err := bc.businessService.WithActor(auditActor(c)).RestoreSnapshot(c.GetUint("businessID"), snapshotID, expectedVersion)
+	if err != nil {
+		respondSnapshotError(c, err, "Failed to restore snapshot")
+		return
+	}
+
+	c.Header("ETag", businessETag(business))
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Business restored successfully",
+		"business": convertToBusinessResponse(*business),
+	})
+}
+
+// PUT /business/:id/pinned-snapshot -> show investors the business as a snapshot captured it
+func (bc *BusinessController) PinSnapshot(c *gin.Context) {
+	var req PinSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	business, err := bc.businessService.WithActor(auditActor(c)).PinSnapshot(c.GetUint("businessID"), req.SnapshotID)
+	if err != nil {
+		respondSnapshotError(c, err, "Failed to pin snapshot")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Snapshot pinned successfully",
+		"business": convertToBusinessResponse(*business),
+	})
+}
+
+// DELETE /business/:id/pinned-snapshot -> show investors the live profile again
+func (bc *BusinessController) UnpinSnapshot(c *gin.Context) {
+	business, err := bc.businessService.WithActor(auditActor(c)).UnpinSnapshot(c.GetUint("businessID"))
+	if err != nil {
+		respondSnapshotError(c, err, "Failed to unpin snapshot")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Snapshot unpinned successfully",
+		"business": convertToBusinessResponse(*business),
+	})
+}
+
+func parseSnapshotID(c *gin.Context, value string) (uint, bool) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondSnapshotError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+	case errors.Is(err, services.ErrSnapshotNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Business not found"})
+	case errors.Is(err, services.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "The business was changed by someone else, reload it and try again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomFieldController struct {
+	businessService *services.BusinessService
+}
+
+func NewCustomFieldController(businessService *services.BusinessService) *CustomFieldController {
+	return &CustomFieldController{businessService: businessService}
+}
+
+type CustomFieldSettingsRequest struct {
+	Label     string   `json:"label" binding:"required"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options"`
+	Currency  string   `json:"currency"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+	MaxLength int      `json:"max_length"`
+	Position  *int     `json:"position"`
+}
+
+type CreateCustomFieldRequest struct {
+	EntityType string `json:"entity_type" binding:"required"`
+	Key        string `json:"key"` // Derived from the label when empty
+	Type       string `json:"type" binding:"required"`
+	CustomFieldSettingsRequest
+}
+
+type SetCustomFieldValuesRequest struct {
+	Values map[string]interface{} `json:"values" binding:"required"` // A null value clears the field
+}
+
+func (r CustomFieldSettingsRequest) settings() services.CustomFieldSettings {
+	return services.CustomFieldSettings{
+		Label:     r.Label,
+		Required:  r.Required,
+		Options:   r.Options,
+		Currency:  r.Currency,
+		Min:       r.Min,
+		Max:       r.Max,
+		MaxLength: r.MaxLength,
+		Position:  r.Position,
+	}
+}
+
+// GET /business/:id/custom-fields -> list the business's custom fields, optionally ?entity_type=
+func (cc *CustomFieldController) ListCustomFields(c *gin.Context) {
+	entityType := c.Query("entity_type")
+	if entityType != "" && !models.IsValidCustomFieldEntity(entityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_type must be business, financial or legal"})
+		return
+	}
+
+	definitions, err := cc.businessService.ListCustomFieldDefinitions(c.GetUint("businessID"), entityType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch custom fields"})
+		return
+	}
+
+	c.JSON(http.StatusOK, definitions)
+}
+
+// POST /business/:id/custom-fields -> define a new custom field
+func (cc *CustomFieldController) CreateCustomField(c *gin.Context) {
+	var req CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := cc.businessService.WithActor(auditActor(c)).
+		CreateCustomFieldDefinition(c.GetUint("businessID"), req.EntityType, req.Key, req.Type, req.settings())
+	if err != nil {
+		respondCustomFieldError(c, err, "Failed to create custom field")
+		return
+	}
+
+	c.JSON(http.StatusCreated, definition)
+}
+
+// PUT /business/:id/custom-fields/:fieldId -> replace a custom field's settings
+func (cc *CustomFieldController) UpdateCustomField(c *gin.Context) {
+	fieldID, err := strconv.ParseUint(c.Param("fieldId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom field ID"})
+		return
+	}
+
+	var req CustomFieldSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := cc.businessService.WithActor(auditActor(c)).
+		UpdateCustomFieldDefinition(c.GetUint("businessID"), uint(fieldID), req.settings())
+	if err != nil {
+		respondCustomFieldError(c, err, "Failed to update custom field")
+		return
+	}
+
+	c.JSON(http.StatusOK, definition)
+}
+
+// DELETE /business/:id/custom-fields/:fieldId -> remove a custom field and its values
+func (cc *CustomFieldController) DeleteCustomField(c *gin.Context) {
+	fieldID, err := strconv.ParseUint(c.Param("fieldId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom field ID"})
+		return
+	}
+
+	if err := cc.businessService.WithActor(auditActor(c)).DeleteCustomFieldDefinition(c.GetUint("businessID"), uint(fieldID)); err != nil {
+		respondCustomFieldError(c, err, "Failed to delete custom field")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Custom field deleted successfully"})
+}
+
+// GET /business/:id/custom-values/:entityType/:entityId -> custom fields of one entity with their values
+func (cc *CustomFieldController) GetCustomFieldValues(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("entityId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+
+	fields, err := cc.businessService.GetCustomFields(c.GetUint("businessID"), c.Param("entityType"), uint(entityID))
+	if err != nil {
+		respondCustomFieldError(c, err, "Failed to fetch custom field values")
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+// PUT /business/:id/custom-values/:entityType/:entityId -> set custom field values of one entity
+func (cc *CustomFieldController) SetCustomFieldValues(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("entityId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+
+	var req SetCustomFieldValuesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := cc.businessService.WithActor(auditActor(c)).
+		SetCustomFieldValues(c.GetUint("businessID"), c.Param("entityType"), uint(entityID), req.Values)
+	if err != nil {
+		respondCustomFieldError(c, err, "Failed to save custom field values")
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+func respondCustomFieldError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+	case errors.Is(err, services.ErrCustomFieldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+	case errors.Is(err, services.ErrCustomFieldEntityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+	case errors.Is(err, services.ErrCustomFieldKeyTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataRoomController struct {
+	businessService *services.BusinessService
+}
+
+func NewDataRoomController(businessService *services.BusinessService) *DataRoomController {
+	return &DataRoomController{businessService: businessService}
+}
+
+type GrantDataRoomRequest struct {
+	InvestorID uint       `json:"investor_id"`
+	Email      string     `json:"email"`      // Alternative to investor_id
+	ExpiresAt  *time.Time `json:"expires_at"` // Access until revoked when omitted
+	Note       string     `json:"note"`
+}
+
+// GET /business/:id/data-room/grants -> investors who can open the business's documents
+func (dc *DataRoomController) ListGrants(c *gin.Context) {
+	grants, err := dc.businessService.ListDataRoomGrants(c.GetUint("businessID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data room grants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// POST /business/:id/data-room/grants -> let an investor open the business's documents
+func (dc *DataRoomController) GrantAccess(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req GrantDataRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grant, err := dc.businessService.WithActor(auditActor(c)).GrantDataRoomAccess(c.GetUint("businessID"), userID, services.DataRoomGrantRequest{
+		InvestorID: req.InvestorID,
+		Email:      req.Email,
+		ExpiresAt:  req.ExpiresAt,
+		Note:       req.Note,
+	})
+	if err != nil {
+		respondDataRoomError(c, err, "Failed to grant data room access")
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// DELETE /business/:id/data-room/grants/:grantId -> revoke an investor's access to the documents
+func (dc *DataRoomController) RevokeGrant(c *gin.Context) {
+	grantID, err := strconv.ParseUint(c.Param("grantId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid grant ID"})
+		return
+	}
+
+	if err := dc.businessService.WithActor(auditActor(c)).RevokeDataRoomGrant(c.GetUint("businessID"), uint(grantID)); err != nil {
+		respondDataRoomError(c, err, "Failed to revoke data room access")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Data room access revoked successfully"})
+}
+
+// GET /investment/data-rooms -> businesses whose documents the investor has been let into
+func (dc *DataRoomController) ListInvestorDataRooms(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	grants, err := dc.businessService.ListInvestorDataRooms(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data rooms"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+func respondDataRoomError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+	case errors.Is(err, services.ErrInvestorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Investor not found"})
+	case errors.Is(err, services.ErrGrantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data room grant not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fmt"
+	"go-gin-backend/internal/middleware"
 	"go-gin-backend/internal/models"
 	"go-gin-backend/internal/services"
 	"log"
@@ -59,6 +60,10 @@ func (gc *GenAIController) AnalyzeBusinessLegals(c *gin.Context) {
 		return
 	}
 
+	if !middleware.CheckBusinessAccess(c, gc.businessService, requestBody.BusinessID, models.BusinessRoleEditor) {
+		return
+	}
+
 	var analysis *models.LegalComparison
 	var err error
 
@@ -83,12 +88,12 @@ func (gc *GenAIController) AnalyzeBusinessLegals(c *gin.Context) {
 	// Store the analysis results
 	if requestBody.IsRefresh {
 		// Clear existing analysis first
-		if err := gc.businessService.ClearStoredLegalAnalysis(requestBody.BusinessID); err != nil {
+		if err := gc.businessService.WithActor(auditActor(c)).ClearStoredLegalAnalysis(requestBody.BusinessID); err != nil {
 			log.Printf("Failed to clear existing analysis: %v", err)
 		}
 	}
 
-	if err := gc.businessService.StoreLegalAnalysisComparison(requestBody.BusinessID, analysis); err != nil {
+	if err := gc.businessService.WithActor(auditActor(c)).StoreLegalAnalysisComparison(requestBody.BusinessID, analysis); err != nil {
 		log.Printf("Failed to store analysis: %v", err)
 	}
 
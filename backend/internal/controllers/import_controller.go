@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/spreadsheet"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Largest spreadsheet accepted for import
+const maxImportFileSize = 10 << 20
+
+// GET /business/:id/import/:kind/columns -> the fields an import fills and the headers recognized for them
+func (bc *BusinessController) GetImportColumns(c *gin.Context) {
+	columns := services.ImportColumns(c.Param("kind"))
+	if columns == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown import kind, use products, projections or financials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"kind": c.Param("kind"), "columns": columns})
+}
+
+// POST /business/:id/import/:kind -> import products, projections or financials from a CSV or
+// XLSX file. Form fields: file, mapping (optional JSON object of field to sheet header) and
+// dry_run (validate only). Nothing is saved unless every row is valid.
+func (bc *BusinessController) ImportBusinessData(c *gin.Context) {
+	kind := c.Param("kind")
+	if services.ImportColumns(kind) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown import kind, use products, projections or financials"})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The file is too large, the limit is 10 MB"})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+		return
+	}
+	if len(data) > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The file is too large, the limit is 10 MB"})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "mapping", "message": "mapping must be a JSON object of field names to sheet headers"})
+			return
+		}
+	}
+
+	dryRun := false
+	if raw := c.PostForm("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "dry_run", "message": "dry_run must be true or false"})
+			return
+		}
+	}
+
+	format, err := spreadsheet.DetectFormat(header.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	}
+	// One extra row for the header
+	rows, err := spreadsheet.Read(format, data, services.MaxImportRows+1)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrTooManyRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "file", "message": "an import can hold at most 5000 rows"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "file", "message": err.Error()})
+		return
+	}
+
+	report, err := bc.businessService.WithActor(auditActor(c)).
+		ImportBusinessData(c.GetUint("businessID"), kind, rows, mapping, dryRun)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import data"})
+		return
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	case report.Committed:
+		c.JSON(http.StatusCreated, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
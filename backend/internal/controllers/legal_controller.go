@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go-gin-backend/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdateLegalRequest only changes the fields present in the body. issued_at and valid_until
+// are YYYY-MM-DD dates and are cleared with an empty string; supersedes_id is cleared with null.
+type UpdateLegalRequest struct {
+	LegalType    *string    `json:"legal_type"`
+	IssuedBy     *string    `json:"issued_by"`
+	Notes        *string    `json:"notes"`
+	IssuedAt     *string    `json:"issued_at"`
+	ValidUntil   *string    `json:"valid_until"`
+	SupersedesID nullableID `json:"supersedes_id"`
+}
+
+// nullableID tells a missing ID field apart from one set to null
+type nullableID struct {
+	Set   bool
+	Value *uint
+}
+
+func (n *nullableID) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		n.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
+
+// PUT|PATCH /business/:id/legal/:legalId -> update a legal document. Takes the fields as
+// JSON, or as multipart form data with a file replacing the current one.
+func (bc *BusinessController) UpdateBusinessLegal(c *gin.Context) {
+	legalID, err := strconv.ParseUint(c.Param("legalId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid legal document ID"})
+		return
+	}
+
+	patch, ok := bindLegalPatch(c)
+	if !ok {
+		return
+	}
+	if patch.File != nil {
+		defer patch.File.Close()
+	}
+
+	legal, err := bc.businessService.WithActor(auditActor(c)).UpdateBusinessLegal(c.GetUint("businessID"), uint(legalID), patch)
+	if err != nil {
+		respondLegalError(c, err, "Failed to update legal document")
+		return
+	}
+
+	c.JSON(http.StatusOK, legal)
+}
+
+// DELETE /business/:id/legal/:legalId -> move a legal document to the trash, or delete it
+// and its files for good with ?permanent=true
+func (bc *BusinessController) DeleteBusinessLegal(c *gin.Context) {
+	legalID, err := strconv.ParseUint(c.Param("legalId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid legal document ID"})
+		return
+	}
+	permanent, ok := parsePermanent(c)
+	if !ok {
+		return
+	}
+
+	if err := bc.businessService.WithActor(auditActor(c)).DeleteBusinessLegal(c.GetUint("businessID"), uint(legalID), permanent); err != nil {
+		respondLegalError(c, err, "Failed to delete legal document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Legal document deleted successfully"})
+}
+
+// GET /business/:id/legal/:legalId/versions -> files the legal document had before, newest first
+func (bc *BusinessController) GetBusinessLegalVersions(c *gin.Context) {
+	legalID, err := strconv.ParseUint(c.Param("legalId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid legal document ID"})
+		return
+	}
+
+	versions, err := bc.businessService.GetBusinessLegalVersions(c.GetUint("businessID"), uint(legalID))
+	if err != nil {
+		respondLegalError(c, err, "Failed to fetch legal document versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// GET /business/:id/legal/:legalId/history -> the documents the legal document renews and is
+// renewed by, oldest first
+func (bc *BusinessController) GetBusinessLegalHistory(c *gin.Context) {
+	legalID, err := strconv.ParseUint(c.Param("legalId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid legal document ID"})
+		return
+	}
+
+	history, err := bc.businessService.GetBusinessLegalHistory(c.GetUint("businessID"), uint(legalID))
+	if err != nil {
+		respondLegalError(c, err, "Failed to fetch legal document history")
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// PUT|PATCH /business/:id/products/:productId/legal/:legalId -> update a product legal
+// document, as JSON or as multipart form data with a file replacing the current one
+func (bc *BusinessController) UpdateProductLegal(c *gin.Context) {
+	productID, legalID, ok := parseProductLegalIDs(c)
+	if !ok {
+		return
+	}
+
+	patch, ok := bindLegalPatch(c)
+	if !ok {
+		return
+	}
+	if patch.File != nil {
+		defer patch.File.Close()
+	}
+
+	legal, err := bc.businessService.WithActor(auditActor(c)).UpdateProductLegal(c.GetUint("businessID"), productID, legalID, patch)
+	if err != nil {
+		respondLegalError(c, err, "Failed to update product legal document")
+		return
+	}
+
+	c.JSON(http.StatusOK, legal)
+}
+
+// DELETE /business/:id/products/:productId/legal/:legalId -> move a product legal document to
+// the trash, or delete it and its files for good with ?permanent=true
+func (bc *BusinessController) DeleteProductLegal(c *gin.Context) {
+	productID, legalID, ok := parseProductLegalIDs(c)
+	if !ok {
+		return
+	}
+	permanent, ok := parsePermanent(c)
+	if !ok {
+		return
+	}
+
+	if err := bc.businessService.WithActor(auditActor(c)).DeleteProductLegal(c.GetUint("businessID"), productID, legalID, permanent); err != nil {
+		respondLegalError(c, err, "Failed to delete product legal document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product legal document deleted successfully"})
+}
+
+// GET /business/:id/products/:productId/legal/:legalId/versions -> files the product legal
+// document had before, newest first
+func (bc *BusinessController) GetProductLegalVersions(c *gin.Context) {
+	productID, legalID, ok := parseProductLegalIDs(c)
+	if !ok {
+		return
+	}
+
+	versions, err := bc.businessService.GetProductLegalVersions(c.GetUint("businessID"), productID, legalID)
+	if err != nil {
+		respondLegalError(c, err, "Failed to fetch product legal document versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// GET /business/:id/products/:productId/legal/:legalId/history -> the documents the product
+// legal document renews and is renewed by, oldest first
+func (bc *BusinessController) GetProductLegalHistory(c *gin.Context) {
+	productID, legalID, ok := parseProductLegalIDs(c)
+	if !ok {
+		return
+	}
+
+	history, err := bc.businessService.GetProductLegalHistory(c.GetUint("businessID"), productID, legalID)
+	if err != nil {
+		respondLegalError(c, err, "Failed to fetch product legal document history")
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func parseProductLegalIDs(c *gin.Context) (uint, uint, bool) {
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+	legalID, err := strconv.ParseUint(c.Param("legalId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid legal document ID"})
+		return 0, 0, false
+	}
+	return uint(productID), uint(legalID), true
+}
+
+func parsePermanent(c *gin.Context) (bool, bool) {
+	value := c.Query("permanent")
+	if value == "" {
+		return false, true
+	}
+	permanent, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "permanent must be true or false"})
+		return false, false
+	}
+	return permanent, true
+}
+
+func parseIncludeSuperseded(c *gin.Context) (bool, bool) {
+	value := c.Query("include_superseded")
+	if value == "" {
+		return false, true
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_superseded must be true or false"})
+		return false, false
+	}
+	return include, true
+}
+
+// parseSupersedesID reads the optional supersedes_id form field of a new legal document
+func parseSupersedesID(c *gin.Context) (uint, bool) {
+	value := c.PostForm("supersedes_id")
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "supersedes_id", "message": "supersedes_id must be a document ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// bindLegalPatch reads a legal document update from a JSON body or, when a new file is sent,
+// from multipart form data where only the fields present are changed
+func bindLegalPatch(c *gin.Context) (services.LegalPatch, bool) {
+	var req UpdateLegalRequest
+	var patch services.LegalPatch
+
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, header, ok := optionalFormUpload(c, services.MaxUploadSize())
+		if !ok {
+			return patch, false
+		}
+		patch.File, patch.FileHeader = file, header
+
+		for field, target := range map[string]**string{
+			"legal_type":  &req.LegalType,
+			"issued_by":   &req.IssuedBy,
+			"notes":       &req.Notes,
+			"issued_at":   &req.IssuedAt,
+			"valid_until": &req.ValidUntil,
+		} {
+			if value, ok := c.GetPostForm(field); ok {
+				*target = &value
+			}
+		}
+		if value, ok := c.GetPostForm("supersedes_id"); ok {
+			req.SupersedesID.Set = true
+			if value != "" {
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil || id == 0 {
+					if file != nil {
+						file.Close()
+					}
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": "supersedes_id", "message": "supersedes_id must be a document ID"})
+					return patch, false
+				}
+				supersedesID := uint(id)
+				req.SupersedesID.Value = &supersedesID
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return patch, false
+	}
+
+	patch.LegalType = req.LegalType
+	patch.IssuedBy = req.IssuedBy
+	patch.Notes = req.Notes
+	if req.SupersedesID.Set {
+		patch.Supersedes = req.SupersedesID.Value
+		patch.ClearSupersedes = req.SupersedesID.Value == nil
+	}
+
+	dates := []struct {
+		field  string
+		value  *string
+		target **time.Time
+		clear  *bool
+	}{
+		{"issued_at", req.IssuedAt, &patch.IssuedAt, &patch.ClearIssuedAt},
+		{"valid_until", req.ValidUntil, &patch.ValidUntil, &patch.ClearValidUntil},
+	}
+	for _, d := range dates {
+		if d.value == nil {
+			continue
+		}
+		if *d.value == "" {
+			*d.clear = true
+			continue
+		}
+		date, err := time.Parse("2006-01-02", *d.value)
+		if err != nil {
+			if patch.File != nil {
+				patch.File.Close()
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": d.field, "message": d.field + " must be a date in YYYY-MM-DD format"})
+			return patch, false
+		}
+		*d.target = &date
+	}
+
+	return patch, true
+}
+
+func respondLegalError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrEmptyPatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Legal document not found"})
+	case errors.Is(err, services.ErrAlreadySuperseded):
+		c.JSON(http.StatusConflict, gin.H{"error": "The document has already been renewed by another one"})
+	default:
+		respondUploadError(c, err, fallback)
+	}
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MembershipController struct {
+	membershipService *services.MembershipService
+}
+
+func NewMembershipController(membershipService *services.MembershipService) *MembershipController {
+	return &MembershipController{membershipService: membershipService}
+}
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type InviteMemberRequest struct {
+	Email       string `json:"email"`
+	PhoneNumber string `json:"phone_number"`
+	Role        string `json:"role" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// GET /business/:id/members -> list the people with access to the business
+func (mc *MembershipController) ListMembers(c *gin.Context) {
+	members, err := mc.membershipService.ListMembers(c.GetUint("businessID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// PATCH /business/:id/members/:memberId -> change a member's role
+func (mc *MembershipController) UpdateMemberRole(c *gin.Context) {
+	memberID, err := strconv.ParseUint(c.Param("memberId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return
+	}
+
+	var req UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := mc.membershipService.WithActor(auditActor(c)).UpdateMemberRole(c.GetUint("businessID"), uint(memberID), req.Role)
+	if err != nil {
+		respondMembershipError(c, err, "Failed to update member")
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// DELETE /business/:id/members/:memberId -> remove a member from the business
+func (mc *MembershipController) RemoveMember(c *gin.Context) {
+	memberID, err := strconv.ParseUint(c.Param("memberId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return
+	}
+
+	if err := mc.membershipService.WithActor(auditActor(c)).RemoveMember(c.GetUint("businessID"), uint(memberID)); err != nil {
+		respondMembershipError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// GET /business/:id/invitations -> list pending invitations
+func (mc *MembershipController) ListInvitations(c *gin.Context) {
+	invitations, err := mc.membershipService.ListInvitations(c.GetUint("businessID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// POST /business/:id/invitations -> invite someone by email or phone number
+func (mc *MembershipController) InviteMember(c *gin.Context) {
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inviterID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	invitation, err := mc.membershipService.WithActor(auditActor(c)).Invite(c.GetUint("businessID"), inviterID, req.Email, req.PhoneNumber, req.Role)
+	if err != nil {
+		respondMembershipError(c, err, "Failed to send invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// DELETE /business/:id/invitations/:invitationId -> revoke a pending invitation
+func (mc *MembershipController) RevokeInvitation(c *gin.Context) {
+	invitationID, err := strconv.ParseUint(c.Param("invitationId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := mc.membershipService.WithActor(auditActor(c)).RevokeInvitation(c.GetUint("businessID"), uint(invitationID)); err != nil {
+		respondMembershipError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+}
+
+// POST /business/invitations/accept -> join a business with an invitation token
+func (mc *MembershipController) AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	member, err := mc.membershipService.WithActor(auditActor(c)).AcceptInvitation(userID, req.Token)
+	if err != nil {
+		respondMembershipError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func respondMembershipError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidBusinessRole),
+		errors.Is(err, services.ErrInvitationRecipientRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMemberNotFound),
+		errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvitationMismatch):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController struct {
+	oidcService *services.OIDCService
+}
+
+func NewOIDCController(oidcService *services.OIDCService) *OIDCController {
+	return &OIDCController{oidcService: oidcService}
+}
+
+// OIDCCallbackRequest is read from the query string when the provider redirects to the API,
+// or from a JSON body when the frontend forwards the redirect
+type OIDCCallbackRequest struct {
+	Code             string `form:"code" json:"code"`
+	State            string `form:"state" json:"state" binding:"required"`
+	Error            string `form:"error" json:"error"`
+	ErrorDescription string `form:"error_description" json:"error_description"`
+}
+
+// GET /oidc/providers -> list the identity providers users can sign in with
+func (oc *OIDCController) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oc.oidcService.ListProviders()})
+}
+
+// GET /oidc/:provider/login -> start signing in with the provider. Returns the provider URL,
+// or redirects to it with ?redirect=true. ?role= sets the role of a newly created account.
+func (oc *OIDCController) StartLogin(c *gin.Context) {
+	authURL, err := oc.oidcService.StartLogin(c.Param("provider"), c.Query("role"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownOIDCProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		case errors.Is(err, services.ErrInvalidRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be either owner or investor"})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to reach the identity provider"})
+		}
+		return
+	}
+
+	if c.Query("redirect") == "true" {
+		c.Redirect(http.StatusFound, authURL)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// GET|POST /oidc/:provider/callback -> finish signing in with the code returned by the provider
+func (oc *OIDCController) Callback(c *gin.Context) {
+	var req OIDCCallbackRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Error != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in was cancelled or denied by the identity provider", "provider_error": req.Error, "provider_error_description": req.ErrorDescription})
+		return
+	}
+	if req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing authorization code"})
+		return
+	}
+
+	tokens, err := oc.oidcService.CompleteLogin(c.Param("provider"), req.Code, req.State, clientInfo(c))
+	if err != nil {
+		if respondAccountBlocked(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrUnknownOIDCProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		case errors.Is(err, services.ErrInvalidOIDCState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login, please start again"})
+		case errors.Is(err, services.ErrOIDCLoginFailed), errors.Is(err, services.ErrOIDCAccountUnavailable):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCEmailRequired), errors.Is(err, services.ErrOIDCEmailNotVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /business/trash -> deleted businesses the user owns, until they are purged
+func (bc *BusinessController) ListTrashedBusinesses(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	items, err := bc.businessService.ListTrashedBusinesses(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// POST /business/trash/:id/restore -> bring back a deleted business with everything deleted with it
+func (bc *BusinessController) RestoreTrashedBusiness(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	businessID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid business ID"})
+		return
+	}
+
+	if err := bc.businessService.AuthorizeTrashedBusiness(userID, uint(businessID)); err != nil {
+		respondTrashError(c, err, "Failed to restore business")
+		return
+	}
+
+	business, err := bc.businessService.WithActor(auditActor(c)).RestoreTrashedBusiness(uint(businessID))
+	if err != nil {
+		respondTrashError(c, err, "Failed to restore business")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Business restored successfully",
+		"business": convertToBusinessResponse(*business),
+	})
+}
+
+// GET /business/:id/trash -> products and documents deleted from the business
+func (bc *BusinessController) ListBusinessTrash(c *gin.Context) {
+	items, err := bc.businessService.ListBusinessTrash(c.GetUint("businessID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// POST /business/:id/trash/:entityType/:entityId/restore -> bring back a deleted product or document
+func (bc *BusinessController) RestoreTrashedItem(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("entityId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+
+	err = bc.businessService.WithActor(auditActor(c)).
+		RestoreTrashedItem(c.GetUint("businessID"), c.Param("entityType"), uint(entityID))
+	if err != nil {
+		respondTrashError(c, err, "Failed to restore item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully"})
+}
+
+func respondTrashError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+	case errors.Is(err, services.ErrBusinessAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can restore a business"})
+	case errors.Is(err, services.ErrNotInTrash):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrParentInTrash):
+		c.JSON(http.StatusConflict, gin.H{"error": "The product this document belongs to is in the trash, restore it first"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"errors"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/storage"
+	"go-gin-backend/internal/utils"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxSignedFileURLs caps how many file URLs can be signed in one request
+const maxSignedFileURLs = 100
+
+// Content types browsers may show inline. Anything else, HTML in particular, is downloaded
+// so an uploaded file can never run as a page of the app.
+var inlineUploadTypes = []string{"image/", "application/pdf", "text/plain"}
+
+type UploadController struct {
+	storage         storage.Storage
+	businessService *services.BusinessService
+}
+
+func NewUploadController(store storage.Storage, businessService *services.BusinessService) *UploadController {
+	return &UploadController{storage: store, businessService: businessService}
+}
+
+// GET /uploads/*key?expires=&signature= -> stream an uploaded file through a signed URL.
+// Only local storage links here; S3 signed URLs point at the bucket.
+func (uc *UploadController) ServeSignedUpload(c *gin.Context) {
+	key, err := storage.CleanKey(c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	local, ok := uc.storage.(*storage.LocalStorage)
+	if !ok || !local.VerifySignedURL(key, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired file link"})
+		return
+	}
+
+	uc.serveUpload(c, key)
+}
+
+// GET /api/files/*key -> stream an uploaded file the user may open
+func (uc *UploadController) DownloadFile(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	key, err := storage.CleanKey(c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	if err := uc.businessService.AuthorizeFileAccess(userID, utils.GetUserRoleFromContext(c), key); err != nil {
+		respondFileAccessError(c, err)
+		return
+	}
+
+	uc.serveUpload(c, key)
+}
+
+type SignFileURLsRequest struct {
+	URLs []string `json:"urls" binding:"required,min=1"`
+}
+
+// POST /api/files/sign -> short-lived links for embedding files the user may open
+func (uc *UploadController) SignFileURLs(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req SignFileURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.URLs) > maxSignedFileURLs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot sign more than 100 URLs at once"})
+		return
+	}
+
+	signed, expiresAt, err := uc.businessService.SignFileURLs(userID, utils.GetUserRoleFromContext(c), req.URLs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign file URLs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"urls":       signed,
+		"expires_at": expiresAt,
+	})
+}
+
+// formUpload reads the "file" form field. The request body is capped a little over maxSize,
+// leaving room for the other form fields, so oversized uploads are refused before they are
+// buffered to disk.
+func formUpload(c *gin.Context, maxSize int64) (multipart.File, *multipart.FileHeader, bool) {
+	file, header, ok := optionalFormUpload(c, maxSize)
+	if ok && file == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, nil, false
+	}
+	return file, header, ok
+}
+
+// optionalFormUpload is formUpload for forms where the file may be left out, in which case
+// it returns a nil file
+func optionalFormUpload(c *gin.Context, maxSize int64) (multipart.File, *multipart.FileHeader, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The file is too large, the limit is " + formatUploadSize(maxSize)})
+			return nil, nil, false
+		case errors.Is(err, http.ErrMissingFile):
+			return nil, nil, true
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, nil, false
+	}
+	if header.Size > maxSize {
+		file.Close()
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The file is too large, the limit is " + formatUploadSize(maxSize)})
+		return nil, nil, false
+	}
+	return file, header, true
+}
+
+func formatUploadSize(size int64) string {
+	return strconv.FormatInt(size>>20, 10) + " MB"
+}
+
+// respondUploadError answers for files the upload pipeline refused
+func respondUploadError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": validationErr.Field, "message": validationErr.Message})
+	case errors.Is(err, services.ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The file is too large"})
+	case errors.Is(err, services.ErrFileInfected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The file was rejected by the malware scan"})
+	case errors.Is(err, services.ErrScanUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The file could not be scanned for malware, please try again later"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func respondFileAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+	case errors.Is(err, services.ErrFileAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this file"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check file access"})
+	}
+}
+
+// serveUpload streams the stored file, downloading anything a browser should not render
+func (uc *UploadController) serveUpload(c *gin.Context, key string) {
+	body, info, err := uc.storage.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer body.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", info.ContentType)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if !inlineUpload(info.ContentType) {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	}
+
+	// Files on disk can be seeked, which gives clients range requests and conditional GETs
+	if content, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", info.ModTime, content)
+		return
+	}
+
+	if !info.ModTime.IsZero() {
+		header.Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if info.Size >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	c.Status(http.StatusOK)
+	if c.Request.Method != http.MethodHead {
+		io.Copy(c.Writer, body)
+	}
+}
+
+func inlineUpload(contentType string) bool {
+	for _, prefix := range inlineUploadTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
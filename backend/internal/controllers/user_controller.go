@@ -1,20 +1,27 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"go-gin-backend/internal/models"
 	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserController struct {
-	userService *services.UserService
+	userService    *services.UserService
+	sessionService *services.SessionService
 }
 
-func NewUserController(userService *services.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService *services.UserService, sessionService *services.SessionService) *UserController {
+	return &UserController{userService: userService, sessionService: sessionService}
 }
 
 func (uc *UserController) GetUser(c *gin.Context) {
@@ -30,9 +37,51 @@ func (uc *UserController) GetUser(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+
+	// List the active sessions on the user's own profile
+	if callerID, err := utils.GetUserIDFromContext(c); err == nil && callerID == user.ID {
+		sessions, err := uc.sessionService.ListActiveSessions(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+			return
+		}
+
+		currentSessionID, _ := utils.GetSessionIDFromContext(c)
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == currentSessionID
+		}
+		user.Sessions = sessions
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
+// DELETE /users/:id/sessions/:sessionId -> revoke one of the user's sessions
+func (uc *UserController) RevokeSession(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := uc.sessionService.RevokeSession(uint(userID), uint(sessionID)); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
 func (uc *UserController) UpdateUser(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
@@ -49,10 +98,85 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 
 	// Set the ID for the update
 	user.ID = uint(userID)
-	err = uc.userService.UpdateUser(&user)
+	err = uc.userService.WithActor(auditActor(c)).UpdateUser(&user)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 	c.JSON(http.StatusOK, user)
 }
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// GET /users/:id/export -> download everything stored about the user as a zip archive
+func (uc *UserController) ExportUserData(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	export, err := uc.userService.ExportUserData(uint(userID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export user data"})
+		return
+	}
+
+	filename := fmt.Sprintf("itbuddy-data-%d-%s.zip", userID, export.ExportedAt.Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := export.WriteZip(c.Writer); err != nil {
+		log.Printf("Failed to write data export for user %d: %v", userID, err)
+	}
+}
+
+// DELETE /users/:id -> permanently delete the account. Users confirm with their password;
+// admins erasing someone else's account do not need one.
+func (uc *UserController) DeleteUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if callerID == uint(userID) {
+		if err := uc.userService.VerifyPassword(uint(userID), req.Password); err != nil {
+			if errors.Is(err, services.ErrInvalidPassword) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify password"})
+			return
+		}
+	}
+
+	if err := uc.userService.WithActor(auditActor(c)).EraseUser(uint(userID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
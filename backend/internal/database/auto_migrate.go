@@ -2,22 +2,36 @@ package database
 
 import (
 	"go-gin-backend/internal/models"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // AutoMigrateAll will migrate all registered models
 func AutoMigrateAll(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&models.User{},
+		&models.Session{},
+		&models.APIKey{},
+		&models.ExternalIdentity{},
+		&models.OIDCLoginState{},
+		&models.UserToken{},
+		&models.PhoneOTP{},
+		&models.RecoveryCode{},
+		&models.LoginAttempt{},
+		&models.SecurityEvent{},
 		&models.Business{},
 		&models.BusinessAdditionalInfo{},
+		&models.BusinessMember{},
+		&models.BusinessInvitation{},
 		&models.Product{},
 		&models.ProductLegal{},
 		&models.Financial{},
 		&models.FinancialAdditionalInfo{},
 		&models.Legal{},
 		&models.LegalAdditionalInfo{},
+		&models.LegalFileVersion{},
 		&models.MissingLegal{},
 		&models.StepToGetLegal{},
 		&models.MissingProductLegal{},
@@ -25,5 +39,107 @@ func AutoMigrateAll(db *gorm.DB) error {
 		&models.BusinessAISuggestion{},
 		&models.BusinessAISuggestionItem{},
 		&models.HistoricalProjection{},
-	)
+		&models.AuditLog{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.BusinessSnapshot{},
+		&models.DataRoomGrant{},
+	); err != nil {
+		return err
+	}
+
+	if err := backfillBusinessOwners(db); err != nil {
+		return err
+	}
+	return migrateAdditionalInfo(db)
+}
+
+// backfillBusinessOwners makes the creator of every business created before
+// memberships existed its owner member
+func backfillBusinessOwners(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO business_members (business_id, user_id, role, created_at, updated_at)
+		SELECT b.id, b.user_id, ?, NOW(), NOW()
+		FROM businesses b
+		WHERE b.deleted_at IS NULL
+		  AND b.user_id <> 0
+		  AND NOT EXISTS (
+		    SELECT 1 FROM business_members m WHERE m.business_id = b.id AND m.user_id = b.user_id
+		  )`, models.BusinessRoleOwner).Error
+}
+
+// migrateAdditionalInfo moves the legacy name/value additional info of businesses,
+// financials and legals into text custom fields. Copied rows are soft-deleted so fields the
+// owners later remove do not come back on the next start.
+func migrateAdditionalInfo(db *gorm.DB) error {
+	sources := []struct {
+		entityType string
+		table      string
+		query      string
+	}{
+		{models.CustomFieldEntityBusiness, "business_additional_infos", `
+			SELECT i.business_id, i.business_id AS entity_id, i.name, i.value
+			FROM business_additional_infos i
+			WHERE i.deleted_at IS NULL`},
+		{models.CustomFieldEntityFinancial, "financial_additional_infos", `
+			SELECT f.business_id, i.financial_id AS entity_id, i.name, i.value
+			FROM financial_additional_infos i
+			JOIN financials f ON f.id = i.financial_id
+			WHERE i.deleted_at IS NULL`},
+		{models.CustomFieldEntityLegal, "legal_additional_infos", `
+			SELECT l.business_id, i.legal_id AS entity_id, i.name, i.value
+			FROM legal_additional_infos i
+			JOIN legals l ON l.id = i.legal_id
+			WHERE i.deleted_at IS NULL`},
+	}
+
+	for _, source := range sources {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var rows []struct {
+				BusinessID uint
+				EntityID   uint
+				Name       string
+				Value      string
+			}
+			if err := tx.Raw(source.query).Scan(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+
+			for _, row := range rows {
+				if row.BusinessID == 0 || strings.TrimSpace(row.Name) == "" {
+					continue
+				}
+
+				definition := models.CustomFieldDefinition{
+					BusinessID: row.BusinessID,
+					EntityType: source.entityType,
+					Key:        models.CustomFieldKey(row.Name),
+				}
+				if err := tx.Where(&definition).
+					Attrs(models.CustomFieldDefinition{Label: strings.TrimSpace(row.Name), Type: models.CustomFieldTypeText}).
+					FirstOrCreate(&definition).Error; err != nil {
+					return err
+				}
+
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CustomFieldValue{
+					DefinitionID: definition.ID,
+					BusinessID:   row.BusinessID,
+					EntityType:   source.entityType,
+					EntityID:     row.EntityID,
+					Value:        row.Value,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Exec("UPDATE " + source.table + " SET deleted_at = NOW() WHERE deleted_at IS NULL").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
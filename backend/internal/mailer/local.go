@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalMailer is used in development and tests. It logs every email and,
+// when Dir is set, also writes it to an .eml file so links can be followed.
+type LocalMailer struct {
+	Dir string
+}
+
+func NewLocalMailer(dir string) *LocalMailer {
+	return &LocalMailer{Dir: dir}
+}
+
+func (m *LocalMailer) Send(msg Message) error {
+	log.Printf("[mailer] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+
+	if m.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	filename := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), recipient)
+	if err := os.WriteFile(filepath.Join(m.Dir, filename), buildMessage("noreply@localhost", msg), 0644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
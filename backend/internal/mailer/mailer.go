@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"os"
+	"strconv"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewFromEnv builds the mailer selected by MAILER_DRIVER ("smtp" or "local").
+// The local mailer is used by default so development never sends real emails.
+func NewFromEnv() Mailer {
+	switch os.Getenv("MAILER_DRIVER") {
+	case "smtp":
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		return NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			port,
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("MAIL_FROM"),
+		)
+	default:
+		return NewLocalMailer(os.Getenv("MAILER_LOCAL_DIR"))
+	}
+}
@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"", "*mailer.LocalMailer"},
+		{"local", "*mailer.LocalMailer"},
+		{"smtp", "*mailer.SMTPMailer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			t.Setenv("MAILER_DRIVER", tt.driver)
+			t.Setenv("SMTP_PORT", "")
+
+			m := NewFromEnv()
+			if got := fmt.Sprintf("%T", m); got != tt.want {
+				t.Errorf("NewFromEnv() = %s, want %s", got, tt.want)
+			}
+			if smtpMailer, ok := m.(*SMTPMailer); ok && smtpMailer.Port != 587 {
+				t.Errorf("default SMTP port = %d, want 587", smtpMailer.Port)
+			}
+		})
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	raw := string(buildMessage("noreply@example.com", Message{
+		To:      "user@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	}))
+
+	headers, body, ok := strings.Cut(raw, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("no blank line between headers and body in %q", raw)
+	}
+	for _, want := range []string{"From: noreply@example.com", "To: user@example.com", "Subject: Hello", "MIME-Version: 1.0"} {
+		if !strings.Contains(headers, want+"\r\n") {
+			t.Errorf("headers do not contain %q:\n%s", want, headers)
+		}
+	}
+	if body != "line one\r\nline two" {
+		t.Errorf("body = %q, want CRLF line endings", body)
+	}
+}
+
+func TestLocalMailerWritesEmail(t *testing.T) {
+	dir := t.TempDir()
+	m := NewLocalMailer(dir)
+
+	if err := m.Send(Message{To: "user@example.com", Subject: "Reset", Body: "link"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*_user_at_example.com.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("found %d email files, want 1", len(files))
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Subject: Reset") {
+		t.Errorf("email file does not contain the subject:\n%s", content)
+	}
+}
@@ -1,37 +1,157 @@
 package middleware
 
 import (
-	"os"
-
+	"errors"
+	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
 	"net/http"
+	"strconv"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates the request with an access token. API keys, sent as a Bearer
+// token or in the X-API-Key header, are accepted too when they hold one of apiKeyScopes;
+// routes that list no scopes are for signed-in users only.
+func AuthMiddleware(apiKeyScopes ...string) gin.HandlerFunc {
+	sessionService := services.NewSessionService(database.DB)
+	apiKeyService := services.NewAPIKeyService(database.DB)
+
 	return func(c *gin.Context) {
 		tokenString := c.Request.Header.Get("Authorization")
+		if apiKey := c.Request.Header.Get("X-API-Key"); apiKey != "" || services.IsAPIKey(tokenString) {
+			if apiKey == "" {
+				apiKey = strings.TrimPrefix(tokenString, "Bearer ")
+			}
+			authenticateAPIKey(c, apiKeyService, apiKey, apiKeyScopes)
+			return
+		}
+
 		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
 			c.Abort()
 			return
 		}
 
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		claims := &jwt.StandardClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil // Use environment variable
-		})
+		claims, err := utils.ParseToken(tokenString)
+		if err != nil || claims.Audience != utils.TokenAudienceAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
 
-		if err != nil || !token.Valid {
+		// Every access token is bound to a session that may have been revoked since
+		sessionID, err := strconv.ParseUint(claims.Id, 10, 32)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
+		active, err := sessionService.IsSessionActive(uint(sessionID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Tokens issued before roles existed belong to SME owners
+		role := claims.Role
+		if role == "" {
+			role = models.RoleOwner
+		}
 
 		c.Set("userID", claims.Subject)
+		c.Set("sessionID", uint(sessionID))
+		c.Set("role", role)
+		c.Set("permissions", models.PermissionsForRole(role))
+		c.Next()
+	}
+}
+
+// authenticateAPIKey lets the request through as the key's owner if the key holds one of
+// the scopes the route accepts
+func authenticateAPIKey(c *gin.Context, apiKeyService *services.APIKeyService, rawKey string, acceptedScopes []string) {
+	key, err := apiKeyService.Authenticate(rawKey, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		case errors.Is(err, services.ErrAccountSuspended), errors.Is(err, services.ErrPasswordResetRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": "The account owning this API key is blocked"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API key"})
+		}
+		c.Abort()
+		return
+	}
+
+	allowed := false
+	for _, scope := range acceptedScopes {
+		if key.Scopes.Has(scope) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not have the scope required for this endpoint"})
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", strconv.FormatUint(uint64(key.UserID), 10))
+	c.Set("apiKeyID", key.ID)
+	c.Set("role", key.User.Role)
+	c.Set("permissions", key.User.Permissions())
+	c.Next()
+}
+
+// RequireRole only lets users with one of the given roles through.
+// It must be used after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := utils.GetUserRoleFromContext(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission only lets users whose role grants the permission through.
+// It must be used after AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !utils.HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOrAdmin only lets the user identified by the path parameter, or an admin, through.
+// It must be used after AuthMiddleware.
+func RequireSelfOrAdmin(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("userID") != c.Param(param) && utils.GetUserRoleFromContext(c) != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only access your own account"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// useTestDB points the database the auth middleware reads at a test database
+func useTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := newTestDB(t)
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+	return db
+}
+
+// createTestUser stores a user with the role
+func createTestUser(t *testing.T, db *gorm.DB, username, role string) *models.User {
+	t.Helper()
+
+	user := &models.User{Username: username, Email: username + "@example.com", PhoneNumber: "+62812" + username, Role: role}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// signIn starts a session for the user and returns the Authorization header of its access token
+func signIn(t *testing.T, db *gorm.DB, user *models.User) http.Header {
+	t.Helper()
+
+	sessionService := services.NewSessionService(db)
+	session, _, err := sessionService.CreateSession(user.ID, services.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := services.NewAuthService(services.NewUserService(db), sessionService, nil, nil, nil, string(utils.GetJWTSecret()))
+	token, err := auth.GenerateToken(user, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestRequireRole(t *testing.T) {
+	db := useTestDB(t)
+	owner := createTestUser(t, db, "alice", models.RoleOwner)
+	investor := createTestUser(t, db, "ivan", models.RoleInvestor)
+	admin := createTestUser(t, db, "adam", models.RoleAdmin)
+
+	tests := []struct {
+		user    *models.User
+		allowed []string
+		want    int
+	}{
+		{admin, []string{models.RoleAdmin}, http.StatusOK},
+		{owner, []string{models.RoleAdmin}, http.StatusForbidden},
+		{investor, []string{models.RoleOwner, models.RoleInvestor}, http.StatusOK},
+		{investor, []string{models.RoleOwner}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		if got := serve(t, "/", "/", signIn(t, db, tt.user), AuthMiddleware(), RequireRole(tt.allowed...)); got != tt.want {
+			t.Errorf("RequireRole(%v) for %s = %d, want %d", tt.allowed, tt.user.Role, got, tt.want)
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	db := useTestDB(t)
+	owner := createTestUser(t, db, "alice", models.RoleOwner)
+	investor := createTestUser(t, db, "ivan", models.RoleInvestor)
+	admin := createTestUser(t, db, "adam", models.RoleAdmin)
+	// Tokens issued before roles existed carry none
+	legacy := createTestUser(t, db, "lucy", models.RoleOwner)
+	legacy.Role = ""
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		permission string
+		want       int
+	}{
+		{"owner manages businesses", owner, models.PermissionManageBusiness, http.StatusOK},
+		{"owner browses listings", owner, models.PermissionBrowseListings, http.StatusForbidden},
+		{"investor browses listings", investor, models.PermissionBrowseListings, http.StatusOK},
+		{"investor manages businesses", investor, models.PermissionManageBusiness, http.StatusForbidden},
+		{"investor asks for advice", investor, models.PermissionInvestmentAdvice, http.StatusOK},
+		{"admin administers", admin, models.PermissionAdministerSystem, http.StatusOK},
+		{"owner administers", owner, models.PermissionAdministerSystem, http.StatusForbidden},
+		{"token without a role", legacy, models.PermissionManageBusiness, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(t, "/", "/", signIn(t, db, tt.user), AuthMiddleware(), RequirePermission(tt.permission))
+			if got != tt.want {
+				t.Errorf("RequirePermission(%s) for %s = %d, want %d", tt.permission, tt.user.Username, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareSessions(t *testing.T) {
+	db := useTestDB(t)
+	user := createTestUser(t, db, "alice", models.RoleOwner)
+	header := signIn(t, db, user)
+
+	if got := serve(t, "/", "/", header, AuthMiddleware()); got != http.StatusOK {
+		t.Fatalf("live session = %d, want %d", got, http.StatusOK)
+	}
+	if err := services.NewSessionService(db).RevokeAllSessions(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(t, "/", "/", header, AuthMiddleware()); got != http.StatusUnauthorized {
+		t.Errorf("revoked session = %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := serve(t, "/", "/", http.Header{"Authorization": {"Bearer not-a-token"}}, AuthMiddleware()); got != http.StatusUnauthorized {
+		t.Errorf("malformed token = %d, want %d", got, http.StatusUnauthorized)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	db := useTestDB(t)
+	investor := createTestUser(t, db, "ivan", models.RoleInvestor)
+	apiKeys := services.NewAPIKeyService(db)
+	createKey := func(scopes []string, expiresAt *time.Time) (*models.APIKey, string) {
+		t.Helper()
+		key, rawKey, err := apiKeys.CreateAPIKey(investor.ID, "Fund CRM", scopes, expiresAt)
+		if err != nil {
+			t.Fatalf("CreateAPIKey: %v", err)
+		}
+		return key, rawKey
+	}
+
+	listingKey, listings := createKey([]string{models.APIScopeReadListings}, nil)
+	revokedKey, revoked := createKey([]string{models.APIScopeReadListings}, nil)
+	if err := apiKeys.RevokeAPIKey(investor.ID, revokedKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	expiredAt := time.Now().Add(-time.Hour)
+	_, expired := createKey([]string{models.APIScopeReadListings}, &expiredAt)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		scopes []string // Accepted by the route
+		want   int
+	}{
+		{"X-API-Key header", http.Header{"X-Api-Key": {listings}}, []string{models.APIScopeReadListings}, http.StatusOK},
+		{"bearer token", http.Header{"Authorization": {"Bearer " + listings}}, []string{models.APIScopeReadListings}, http.StatusOK},
+		{"one of several scopes", http.Header{"X-Api-Key": {listings}}, []string{models.APIScopeReadBusiness, models.APIScopeReadListings}, http.StatusOK},
+		{"missing scope", http.Header{"X-Api-Key": {listings}}, []string{models.APIScopeReadBusiness}, http.StatusForbidden},
+		{"route for signed-in users only", http.Header{"X-Api-Key": {listings}}, nil, http.StatusForbidden},
+		{"revoked key", http.Header{"X-Api-Key": {revoked}}, []string{models.APIScopeReadListings}, http.StatusUnauthorized},
+		{"expired key", http.Header{"X-Api-Key": {expired}}, []string{models.APIScopeReadListings}, http.StatusUnauthorized},
+		{"unknown key", http.Header{"X-Api-Key": {services.APIKeyPrefix + "unknown"}}, []string{models.APIScopeReadListings}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(t, "/", "/", tt.header, AuthMiddleware(tt.scopes...)); got != tt.want {
+				t.Errorf("AuthMiddleware(%v) = %d, want %d", tt.scopes, got, tt.want)
+			}
+		})
+	}
+
+	var used models.APIKey
+	if err := db.First(&used, listingKey.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if used.LastUsedAt == nil || used.LastUsedIP == "" {
+		t.Errorf("API key use was not recorded: last used at %v from %q", used.LastUsedAt, used.LastUsedIP)
+	}
+
+	// The key acts with its owner's role, so investor-only guards still apply after it
+	got := serve(t, "/", "/", http.Header{"X-Api-Key": {listings}},
+		AuthMiddleware(models.APIScopeReadListings), RequirePermission(models.PermissionManageBusiness))
+	if got != http.StatusForbidden {
+		t.Errorf("an investor's key passed the business management guard: %d", got)
+	}
+}
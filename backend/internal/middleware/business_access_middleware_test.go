@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/services"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBusinessAccessMiddleware(t *testing.T) {
+	db := useTestDB(t)
+	create := func(record interface{}) {
+		t.Helper()
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	business := &models.Business{Name: "Kopi Nusantara"}
+	create(business)
+	member := func(username, role string) http.Header {
+		t.Helper()
+		user := createTestUser(t, db, username, models.RoleOwner)
+		create(&models.BusinessMember{BusinessID: business.ID, UserID: user.ID, Role: role})
+		return signIn(t, db, user)
+	}
+	owner := member("alice", models.BusinessRoleOwner)
+	editor := member("erin", models.BusinessRoleEditor)
+	viewer := member("carol", models.BusinessRoleViewer)
+	stranger := signIn(t, db, createTestUser(t, db, "bob", models.RoleOwner))
+	admin := signIn(t, db, createTestUser(t, db, "adam", models.RoleAdmin))
+
+	product := &models.Product{BusinessID: business.ID, Name: "Arabica"}
+	create(product)
+	otherBusiness := &models.Business{Name: "Teh Manis"}
+	create(otherBusiness)
+	otherProduct := &models.Product{BusinessID: otherBusiness.ID, Name: "Jasmine"}
+	create(otherProduct)
+
+	businessPath := fmt.Sprintf("/business/%d", business.ID)
+	tests := []struct {
+		name    string
+		header  http.Header
+		minRole string
+		path    string
+		want    int
+	}{
+		{"owner", owner, models.BusinessRoleOwner, businessPath, http.StatusOK},
+		{"editor edits", editor, models.BusinessRoleEditor, businessPath, http.StatusOK},
+		{"editor manages the business", editor, models.BusinessRoleOwner, businessPath, http.StatusForbidden},
+		{"viewer reads", viewer, models.BusinessRoleViewer, businessPath, http.StatusOK},
+		{"viewer edits", viewer, models.BusinessRoleEditor, businessPath, http.StatusForbidden},
+		{"non-member", stranger, models.BusinessRoleViewer, businessPath, http.StatusForbidden},
+		{"admin", admin, models.BusinessRoleOwner, businessPath, http.StatusOK},
+		{"unauthenticated", nil, models.BusinessRoleViewer, businessPath, http.StatusUnauthorized},
+		{"unknown business", owner, models.BusinessRoleViewer, "/business/999", http.StatusNotFound},
+		{"invalid business ID", owner, models.BusinessRoleViewer, "/business/abc", http.StatusBadRequest},
+		{"product", owner, models.BusinessRoleEditor, fmt.Sprintf("%s/products/%d", businessPath, product.ID), http.StatusOK},
+		{"another business's product", owner, models.BusinessRoleEditor, fmt.Sprintf("%s/products/%d", businessPath, otherProduct.ID), http.StatusNotFound},
+		{"invalid product ID", owner, models.BusinessRoleEditor, businessPath + "/products/abc", http.StatusBadRequest},
+	}
+
+	businessService := services.NewBusinessService(db)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := "/business/:id"
+			if strings.Contains(tt.path, "/products/") {
+				route = "/business/:id/products/:productId"
+			}
+			got := serve(t, route, tt.path, tt.header, AuthMiddleware(), BusinessAccessMiddleware(businessService, tt.minRole))
+			if got != tt.want {
+				t.Errorf("GET %s as %s = %d, want %d", tt.path, tt.name, got, tt.want)
+			}
+		})
+	}
+}
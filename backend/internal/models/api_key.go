@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API key scopes
+const (
+	APIScopeReadListings = "read:listings" // Browse the investment listing
+	APIScopeReadBusiness = "read:business" // Read a listed business in detail
+	APIScopeGenAIChat    = "genai:chat"    // Ask the AI assistant and investment advisor
+)
+
+// APIScopes lists every scope that can be granted to an API key
+var APIScopes = []string{APIScopeReadListings, APIScopeReadBusiness, APIScopeGenAIChat}
+
+// IsValidAPIScope reports whether the scope can be granted to an API key
+func IsValidAPIScope(scope string) bool {
+	for _, s := range APIScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyScopes is stored as a comma-separated list
+type APIKeyScopes []string
+
+func (s APIKeyScopes) GormDataType() string {
+	return "text"
+}
+
+func (s APIKeyScopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *APIKeyScopes) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+		*s = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into APIKeyScopes", value)
+	}
+
+	*s = nil
+	for _, scope := range strings.Split(raw, ",") {
+		if scope != "" {
+			*s = append(*s, scope)
+		}
+	}
+	return nil
+}
+
+// Has reports whether the scope was granted
+func (s APIKeyScopes) Has(scope string) bool {
+	for _, granted := range s {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey lets a user's integrations call the API without a password
+type APIKey struct {
+	gorm.Model
+	UserID     uint         `gorm:"not null;index" json:"user_id"`
+	Name       string       `gorm:"not null" json:"name"`
+	Prefix     string       `gorm:"not null" json:"prefix"` // Start of the key, shown to tell keys apart
+	KeyHash    string       `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     APIKeyScopes `gorm:"not null" json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	LastUsedIP string       `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time   `json:"revoked_at,omitempty"`
+
+	User *User `json:"-"`
+}
+
+// IsActive reports whether the key can still be used
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
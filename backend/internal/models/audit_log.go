@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audit actions
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore" // A deleted record brought back
+)
+
+// Audited entity types
+const (
+	AuditEntityUser          = "user"
+	AuditEntityBusiness      = "business"
+	AuditEntityProduct       = "product"
+	AuditEntityLegal         = "legal"
+	AuditEntityProductLegal  = "product_legal"
+	AuditEntityFinancial     = "financial"
+	AuditEntityProjections   = "projections"
+	AuditEntityLegalAnalysis = "legal_analysis"
+	AuditEntityCustomField   = "custom_field"
+	AuditEntityDataRoomGrant = "data_room_grant"
+	AuditEntityMember        = "business_member"
+	AuditEntityInvitation    = "business_invitation"
+)
+
+// ErrAuditLogImmutable is returned when something tries to change a written audit entry
+var ErrAuditLogImmutable = errors.New("audit log entries cannot be modified")
+
+// AuditLog is an append-only record of a change made to an entity
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    *uint           `gorm:"index" json:"actor_id,omitempty"` // Nil for system changes
+	BusinessID *uint           `gorm:"index" json:"business_id,omitempty"`
+	EntityType string          `gorm:"not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint            `gorm:"index:idx_audit_entity" json:"entity_id"`
+	Action     string          `gorm:"not null;index" json:"action"`
+	Before     json.RawMessage `gorm:"type:jsonb" json:"before,omitempty"`
+	After      json.RawMessage `gorm:"type:jsonb" json:"after,omitempty"`
+	Diff       json.RawMessage `gorm:"type:jsonb" json:"diff,omitempty"` // Changed fields as {"field": {"before": ..., "after": ...}}
+	IPAddress  string          `json:"ip_address,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
+}
+
+// BeforeUpdate keeps audit entries append-only
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete keeps audit entries append-only
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -17,11 +17,32 @@ type Business struct {
 	Industry    string     `json:"industry,omitempty"`
 	FoundedAt   *time.Time `json:"founded_at,omitempty"`
 
+	// Bumped on every basic info update for optimistic concurrency, exposed as the ETag
+	Version uint `gorm:"not null;default:1" json:"version"`
+
+	// Snapshot an owner pinned for investors to see instead of the live profile
+	PinnedSnapshotID *uint `json:"pinned_snapshot_id,omitempty"`
+
+	// Set while an admin has taken the business down from investor listings
+	TakenDownAt    *time.Time `json:"taken_down_at,omitempty"`
+	TakedownReason string     `json:"takedown_reason,omitempty"`
+
 	// Relations
-	Legals     []Legal     `gorm:"foreignKey:BusinessID" json:"legals,omitempty"`
-	Products   []Product   `gorm:"foreignKey:BusinessID" json:"products,omitempty"`
-	Financials []Financial `gorm:"foreignKey:BusinessID" json:"financials,omitempty"`
-	Financial  *Financial  `gorm:"foreignKey:BusinessID" json:"financial,omitempty"` // Latest financial record for compatibility
+	Legals     []Legal          `gorm:"foreignKey:BusinessID" json:"legals,omitempty"`
+	Products   []Product        `gorm:"foreignKey:BusinessID" json:"products,omitempty"`
+	Financials []Financial      `gorm:"foreignKey:BusinessID" json:"financials,omitempty"`
+	Financial  *Financial       `gorm:"foreignKey:BusinessID" json:"financial,omitempty"` // Latest financial record for compatibility
+	Members    []BusinessMember `gorm:"foreignKey:BusinessID" json:"members,omitempty"`
+
+	CustomFields []CustomField `gorm:"-" json:"custom_fields,omitempty"`
+
+	// Set on investor views to the snapshot the data comes from
+	Snapshot *BusinessSnapshotRef `gorm:"-" json:"snapshot,omitempty"`
+}
+
+// ListedBusinesses is a query scope that hides businesses taken down by an admin
+func ListedBusinesses(db *gorm.DB) *gorm.DB {
+	return db.Where("businesses.taken_down_at IS NULL")
 }
 
 // GetMarketCap returns the calculated market cap based on financial data
@@ -40,6 +61,15 @@ func (b *Business) GetEBITDAMultiplier() float64 {
 	return b.Financial.GetEBITDAMultiplier()
 }
 
+// Completeness sections, in the order owners are asked to fill them in
+const (
+	CompletenessSectionBasicInfo     = "basic_info"
+	CompletenessSectionTrademark     = "trademark"
+	CompletenessSectionProducts      = "products"
+	CompletenessSectionProductLegals = "product_legals"
+	CompletenessSectionFinancials    = "financials"
+)
+
 // BusinessCompleteness represents the completeness status of a business profile
 type BusinessCompleteness struct {
 	BasicInfo         bool    `json:"basic_info"`
@@ -47,14 +77,36 @@ type BusinessCompleteness struct {
 	HasLegalDocs      bool    `json:"has_legal_docs"`
 	HasProductLegals  bool    `json:"has_product_legals"`
 	HasFinancialData  bool    `json:"has_financial_data"`
-	OverallProgress   float64 `json:"overall_progress"`
+	OverallProgress   float64 `json:"overall_progress"` // Weighted percentage, 0-100
 	CompletedSections int     `json:"completed_sections"`
 	TotalSections     int     `json:"total_sections"`
+
+	Sections []CompletenessSection `json:"sections"`
+	NextStep *CompletenessItem     `json:"next_step,omitempty"` // First missing item in onboarding order
+}
+
+// CompletenessSection is one weighted part of the business profile
+type CompletenessSection struct {
+	Key      string             `json:"key"`
+	Title    string             `json:"title"`
+	Weight   float64            `json:"weight"`   // Share of the overall progress, in percent
+	Progress float64            `json:"progress"` // Percentage of the section's items that are complete
+	Complete bool               `json:"complete"`
+	Items    []CompletenessItem `json:"items"`
+}
+
+// CompletenessItem is a single requirement, with the reason when it is missing
+type CompletenessItem struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Complete bool   `json:"complete"`
+	Missing  string `json:"missing,omitempty"`
 }
 
+// BusinessAdditionalInfo is the legacy name/value field, superseded by custom fields
 type BusinessAdditionalInfo struct {
 	gorm.Model
-	BusinessID uint   `gorm:"unique" json:"business_id"`
+	BusinessID uint   `gorm:"index" json:"business_id"`
 	Name       string `json:"name"`
 	Value      string `json:"value"`
 }
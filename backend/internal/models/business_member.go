@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Business member roles, from most to least privileged
+const (
+	BusinessRoleOwner  = "owner"  // Full control, including members and deletion
+	BusinessRoleEditor = "editor" // Can edit products, legals, financials and projections
+	BusinessRoleViewer = "viewer" // Read-only access
+)
+
+var businessRoleRanks = map[string]int{
+	BusinessRoleViewer: 1,
+	BusinessRoleEditor: 2,
+	BusinessRoleOwner:  3,
+}
+
+// IsValidBusinessRole reports whether the role is a known business member role
+func IsValidBusinessRole(role string) bool {
+	_, ok := businessRoleRanks[role]
+	return ok
+}
+
+// BusinessRoleAtLeast reports whether role grants at least the access of minRole
+func BusinessRoleAtLeast(role, minRole string) bool {
+	return businessRoleRanks[role] >= businessRoleRanks[minRole] && businessRoleRanks[role] > 0
+}
+
+// BusinessMember gives a user access to a business with a role
+type BusinessMember struct {
+	gorm.Model
+	BusinessID uint   `gorm:"not null;uniqueIndex:idx_business_member" json:"business_id"`
+	UserID     uint   `gorm:"not null;uniqueIndex:idx_business_member" json:"user_id"`
+	Role       string `gorm:"not null" json:"role"`
+
+	User *User `json:"user,omitempty"`
+}
+
+// BusinessInvitation invites someone by email or phone number to join a business
+type BusinessInvitation struct {
+	gorm.Model
+	BusinessID   uint       `gorm:"not null;index" json:"business_id"`
+	Email        string     `json:"email,omitempty"`
+	PhoneNumber  string     `json:"phone_number,omitempty"`
+	Role         string     `gorm:"not null" json:"role"`
+	TokenHash    string     `gorm:"not null;uniqueIndex" json:"-"`
+	InvitedByID  uint       `gorm:"not null" json:"invited_by_id"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+	AcceptedByID *uint      `json:"accepted_by_id,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+
+	Business *Business `json:"business,omitempty"`
+}
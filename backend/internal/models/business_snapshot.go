@@ -0,0 +1,220 @@
+package models
+
+import "time"
+
+// What caused a business snapshot to be taken
+const (
+	SnapshotTriggerChange  = "change"  // Taken after a change to the profile
+	SnapshotTriggerManual  = "manual"  // Requested by a member
+	SnapshotTriggerRestore = "restore" // Taken after the profile was restored from an older snapshot
+)
+
+// BusinessSnapshot is a point-in-time copy of a business profile. Snapshots are numbered per
+// business and never change once written.
+type BusinessSnapshot struct {
+	ID              uint                 `gorm:"primaryKey" json:"id"`
+	BusinessID      uint                 `gorm:"not null;uniqueIndex:idx_business_snapshot_number" json:"business_id"`
+	Number          uint                 `gorm:"not null;uniqueIndex:idx_business_snapshot_number" json:"number"`
+	BusinessVersion uint                 `json:"business_version"` // Basic info version at the time
+	Trigger         string               `gorm:"not null" json:"trigger"`
+	Label           string               `json:"label,omitempty"`
+	CreatedByID     *uint                `json:"created_by_id,omitempty"`
+	RestoredFromID  *uint                `json:"restored_from_id,omitempty"`
+	Checksum        string               `gorm:"not null" json:"checksum"` // SHA-256 of the data, to skip unchanged snapshots
+	Data            BusinessSnapshotData `gorm:"type:jsonb;serializer:json" json:"data"`
+	CreatedAt       time.Time            `gorm:"index" json:"created_at"`
+}
+
+// BusinessSnapshotData is the business aggregate captured by a snapshot
+type BusinessSnapshotData struct {
+	Name         string               `json:"name"`
+	Type         string               `json:"type,omitempty"`
+	Description  string               `json:"description,omitempty"`
+	Industry     string               `json:"industry,omitempty"`
+	FoundedAt    *time.Time           `json:"founded_at,omitempty"`
+	CustomFields []CustomField        `json:"custom_fields,omitempty"`
+	Products     []SnapshotProduct    `json:"products"`
+	Legals       []SnapshotDocument   `json:"legals"`
+	Financial    *SnapshotFinancial   `json:"financial,omitempty"` // Latest financial record
+	Projections  []SnapshotProjection `json:"projections,omitempty"`
+}
+
+type SnapshotProduct struct {
+	ID                 uint               `json:"id"`
+	Name               string             `json:"name"`
+	Description        string             `json:"description,omitempty"`
+	Category           string             `json:"category,omitempty"`
+	SKU                string             `json:"sku,omitempty"`
+	UnitPrice          *float64           `json:"unit_price,omitempty"`
+	Currency           string             `json:"currency,omitempty"`
+	ProductionCapacity *float64           `json:"production_capacity,omitempty"`
+	CapacityUnit       string             `json:"capacity_unit,omitempty"`
+	Images             []ProductImage     `json:"images,omitempty"`
+	Variants           []ProductVariant   `json:"variants,omitempty"`
+	Legals             []SnapshotDocument `json:"legals,omitempty"`
+}
+
+// Product returns the catalog fields of the snapshot as a product of the business
+func (p SnapshotProduct) Product(businessID uint) Product {
+	return Product{
+		BusinessID:         businessID,
+		Name:               p.Name,
+		Description:        p.Description,
+		Category:           p.Category,
+		SKU:                p.SKU,
+		UnitPrice:          p.UnitPrice,
+		Currency:           p.Currency,
+		ProductionCapacity: p.ProductionCapacity,
+		CapacityUnit:       p.CapacityUnit,
+		Images:             p.Images,
+		Variants:           p.Variants,
+	}
+}
+
+// SnapshotDocument is a business or product legal document
+type SnapshotDocument struct {
+	ID           uint          `json:"id"`
+	FileName     string        `json:"file_name,omitempty"`
+	FileURL      string        `json:"file_url,omitempty"`
+	LegalType    string        `json:"legal_type,omitempty"`
+	IssuedBy     string        `json:"issued_by,omitempty"`
+	IssuedAt     *time.Time    `json:"issued_at,omitempty"`
+	ValidUntil   *time.Time    `json:"valid_until,omitempty"`
+	Notes        string        `json:"notes,omitempty"`
+	SupersedesID *uint         `json:"supersedes_id,omitempty"`
+	CustomFields []CustomField `json:"custom_fields,omitempty"`
+}
+
+type SnapshotFinancial struct {
+	ID            uint          `json:"id"`
+	Revenue       float64       `json:"revenue"`
+	EBITDA        float64       `json:"ebitda"`
+	Assets        float64       `json:"assets"`
+	Liabilities   float64       `json:"liabilities"`
+	Equity        float64       `json:"equity"`
+	ReportFileURL string        `json:"report_file_url,omitempty"`
+	Notes         string        `json:"notes,omitempty"`
+	CustomFields  []CustomField `json:"custom_fields,omitempty"`
+}
+
+type SnapshotProjection struct {
+	Year      int     `json:"year"`
+	Revenue   float64 `json:"revenue"`
+	Expenses  float64 `json:"expenses"`
+	NetIncome float64 `json:"net_income"`
+	CashFlow  float64 `json:"cash_flow"`
+}
+
+// BusinessSnapshotRef tells investors which snapshot of a business they are looking at
+type BusinessSnapshotRef struct {
+	ID        uint      `json:"id"`
+	Number    uint      `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+	Pinned    bool      `json:"pinned,omitempty"` // Chosen by the owners rather than the latest
+}
+
+// SnapshotChange is one difference between two snapshots. Path addresses the value, e.g.
+// "products[id=3].name" or "projections[year=2023].revenue".
+type SnapshotChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Ref returns the reference investors see for the snapshot
+func (s *BusinessSnapshot) Ref() *BusinessSnapshotRef {
+	return &BusinessSnapshotRef{ID: s.ID, Number: s.Number, CreatedAt: s.CreatedAt}
+}
+
+// Business renders the snapshot as the business it captured, for read-only views
+func (s *BusinessSnapshot) Business() *Business {
+	data := s.Data
+	business := &Business{
+		Name:         data.Name,
+		Type:         data.Type,
+		Description:  data.Description,
+		Industry:     data.Industry,
+		FoundedAt:    data.FoundedAt,
+		Version:      s.BusinessVersion,
+		CustomFields: data.CustomFields,
+		Snapshot:     s.Ref(),
+	}
+	business.ID = s.BusinessID
+
+	for _, p := range data.Products {
+		product := p.Product(s.BusinessID)
+		product.ID = p.ID
+		renewed := supersededDocuments(p.Legals)
+		for _, d := range p.Legals {
+			if renewed[d.ID] {
+				continue
+			}
+			legal := ProductLegal{
+				ProductID:    p.ID,
+				FileName:     d.FileName,
+				FileURL:      d.FileURL,
+				LegalType:    d.LegalType,
+				IssuedBy:     d.IssuedBy,
+				IssuedAt:     d.IssuedAt,
+				ValidUntil:   d.ValidUntil,
+				Notes:        d.Notes,
+				SupersedesID: d.SupersedesID,
+			}
+			legal.ID = d.ID
+			product.ProductLegals = append(product.ProductLegals, legal)
+		}
+		business.Products = append(business.Products, product)
+	}
+
+	renewed := supersededDocuments(data.Legals)
+	for _, d := range data.Legals {
+		if renewed[d.ID] {
+			continue
+		}
+		legal := Legal{
+			BusinessID:   s.BusinessID,
+			FileName:     d.FileName,
+			FileURL:      d.FileURL,
+			LegalType:    d.LegalType,
+			IssuedBy:     d.IssuedBy,
+			IssuedAt:     d.IssuedAt,
+			ValidUntil:   d.ValidUntil,
+			Notes:        d.Notes,
+			SupersedesID: d.SupersedesID,
+			CustomFields: d.CustomFields,
+		}
+		legal.ID = d.ID
+		business.Legals = append(business.Legals, legal)
+	}
+
+	if f := data.Financial; f != nil {
+		financial := Financial{
+			BusinessID:    s.BusinessID,
+			Revenue:       f.Revenue,
+			EBITDA:        f.EBITDA,
+			Assets:        f.Assets,
+			Liabilities:   f.Liabilities,
+			Equity:        f.Equity,
+			ReportFileURL: f.ReportFileURL,
+			Notes:         f.Notes,
+			CustomFields:  f.CustomFields,
+		}
+		financial.ID = f.ID
+		business.Financial = &financial
+		business.Financials = []Financial{financial}
+	}
+
+	return business
+}
+
+// supersededDocuments returns the IDs of documents renewed by another one in the list,
+// which read-only views leave out like the live ones do
+func supersededDocuments(documents []SnapshotDocument) map[uint]bool {
+	renewed := map[uint]bool{}
+	for _, d := range documents {
+		if d.SupersedesID != nil {
+			renewed[*d.SupersedesID] = true
+		}
+	}
+	return renewed
+}
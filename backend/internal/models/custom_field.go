@@ -0,0 +1,127 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entities custom fields can be defined for
+const (
+	CustomFieldEntityBusiness  = "business"
+	CustomFieldEntityFinancial = "financial"
+	CustomFieldEntityLegal     = "legal"
+)
+
+// Custom field types
+const (
+	CustomFieldTypeText     = "text"
+	CustomFieldTypeNumber   = "number"
+	CustomFieldTypeDate     = "date"     // Stored as YYYY-MM-DD
+	CustomFieldTypeEnum     = "enum"     // One of the definition's options
+	CustomFieldTypeCurrency = "currency" // Amount in the definition's currency
+)
+
+// IsValidCustomFieldEntity reports whether custom fields can be defined for the entity type
+func IsValidCustomFieldEntity(entityType string) bool {
+	switch entityType {
+	case CustomFieldEntityBusiness, CustomFieldEntityFinancial, CustomFieldEntityLegal:
+		return true
+	}
+	return false
+}
+
+// IsValidCustomFieldType reports whether the custom field type is supported
+func IsValidCustomFieldType(fieldType string) bool {
+	switch fieldType {
+	case CustomFieldTypeText, CustomFieldTypeNumber, CustomFieldTypeDate, CustomFieldTypeEnum, CustomFieldTypeCurrency:
+		return true
+	}
+	return false
+}
+
+// CustomFieldDefinition describes an extra field a business tracks on its profile, its
+// financials or its legal documents
+type CustomFieldDefinition struct {
+	ID         uint     `gorm:"primaryKey" json:"id"`
+	BusinessID uint     `gorm:"not null;uniqueIndex:idx_custom_field_key" json:"business_id"`
+	EntityType string   `gorm:"not null;uniqueIndex:idx_custom_field_key" json:"entity_type"`
+	Key        string   `gorm:"not null;uniqueIndex:idx_custom_field_key" json:"key"`
+	Label      string   `gorm:"not null" json:"label"`
+	Type       string   `gorm:"not null" json:"type"`
+	Required   bool     `gorm:"not null;default:false" json:"required"`
+	Options    []string `gorm:"serializer:json" json:"options,omitempty"` // Allowed values of enum fields
+	Currency   string   `json:"currency,omitempty"`                       // ISO 4217 code of currency fields
+	Min        *float64 `json:"min,omitempty"`                            // Bounds of number and currency fields
+	Max        *float64 `json:"max,omitempty"`
+	MaxLength  int      `json:"max_length,omitempty"` // Longest text value, 0 for the default limit
+	Position   int      `gorm:"not null;default:0" json:"position"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomFieldValue is the value of a custom field for one entity, stored in a canonical
+// text form: numbers and amounts as decimals, dates as YYYY-MM-DD
+type CustomFieldValue struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	DefinitionID uint   `gorm:"not null;uniqueIndex:idx_custom_field_value" json:"definition_id"`
+	BusinessID   uint   `gorm:"not null;index" json:"business_id"`
+	EntityType   string `gorm:"not null;index:idx_custom_field_entity" json:"entity_type"`
+	EntityID     uint   `gorm:"not null;uniqueIndex:idx_custom_field_value;index:idx_custom_field_entity" json:"entity_id"`
+	Value        string `gorm:"not null" json:"value"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomField is a custom field with its value, as returned with the entity it belongs to
+type CustomField struct {
+	Key      string      `json:"key"`
+	Label    string      `json:"label"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required,omitempty"`
+	Currency string      `json:"currency,omitempty"`
+	Value    interface{} `json:"value"` // nil when not filled in
+}
+
+// Field pairs the definition with a stored value, which may be nil
+func (d *CustomFieldDefinition) Field(value *CustomFieldValue) CustomField {
+	field := CustomField{
+		Key:      d.Key,
+		Label:    d.Label,
+		Type:     d.Type,
+		Required: d.Required,
+		Currency: d.Currency,
+	}
+	if value != nil {
+		field.Value = d.TypedValue(value.Value)
+	}
+	return field
+}
+
+// TypedValue converts a stored value back to its JSON type
+func (d *CustomFieldDefinition) TypedValue(stored string) interface{} {
+	switch d.Type {
+	case CustomFieldTypeNumber, CustomFieldTypeCurrency:
+		if f, err := strconv.ParseFloat(stored, 64); err == nil {
+			return f
+		}
+	}
+	return stored
+}
+
+var customFieldKeyUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CustomFieldKey derives a field key from a label, e.g. "Tax ID (NPWP)" becomes "tax_id_npwp"
+func CustomFieldKey(label string) string {
+	key := strings.Trim(customFieldKeyUnsafe.ReplaceAllString(strings.ToLower(label), "_"), "_")
+	if len(key) > 50 {
+		key = strings.TrimRight(key[:50], "_")
+	}
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		key = "field_" + key
+	}
+	return key
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// DataRoomGrant lets an investor open the documents of a business they are not a member of:
+// its legal documents, product permits and financial reports
+type DataRoomGrant struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	BusinessID  uint       `gorm:"not null;index:idx_data_room_grant" json:"business_id"`
+	InvestorID  uint       `gorm:"not null;index:idx_data_room_grant" json:"investor_id"`
+	GrantedByID uint       `gorm:"not null" json:"granted_by_id"`
+	Note        string     `json:"note,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Nil for access until revoked
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Investor *User     `gorm:"foreignKey:InvestorID" json:"investor,omitempty"`
+	Business *Business `json:"business,omitempty"`
+}
+
+// Active reports whether the grant still gives access at the given time
+func (g *DataRoomGrant) Active(now time.Time) bool {
+	return g.RevokedAt == nil && (g.ExpiresAt == nil || g.ExpiresAt.After(now))
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ExternalIdentity links a user to their account at an OIDC identity provider
+type ExternalIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Provider    string     `gorm:"not null;uniqueIndex:idx_external_identity_subject" json:"provider"`
+	Subject     string     `gorm:"not null;uniqueIndex:idx_external_identity_subject" json:"subject"`
+	Email       string     `json:"email,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// OIDCLoginState is a pending OIDC login, looked up by the hash of the state parameter
+// when the provider redirects back. It is deleted once used.
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"not null;uniqueIndex"`
+	Provider     string    `gorm:"not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	Role         string    // Role for accounts created by this login
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
@@ -32,6 +32,8 @@ type Financial struct {
 
 	ReportFileURL string `json:"report_file_url,omitempty"` // raw financial file (pdf/txt)
 	Notes         string `json:"notes,omitempty"`
+
+	CustomFields []CustomField `gorm:"-" json:"custom_fields,omitempty"`
 }
 
 // GetEBITDAMultiplier returns the EBITDA multiplier based on revenue
@@ -56,9 +58,10 @@ func (f *Financial) CalculateMarketCap() float64 {
 	return f.EBITDA * f.GetEBITDAMultiplier()
 }
 
+// FinancialAdditionalInfo is the legacy name/value field, superseded by custom fields
 type FinancialAdditionalInfo struct {
 	gorm.Model
-	FinancialID uint   `gorm:"unique" json:"financial_id"`
+	FinancialID uint   `gorm:"index" json:"financial_id"`
 	Name        string `json:"name"`
 	Value       string `json:"value"`
 }
@@ -18,11 +18,23 @@ type Legal struct {
 	IssuedAt   *time.Time `json:"issued_at,omitempty"`
 	ValidUntil *time.Time `json:"valid_until,omitempty"`
 	Notes      string     `json:"notes,omitempty"`
+
+	// Renewals point at the document they replace; only the newest in a chain is active
+	SupersedesID   *uint `gorm:"index" json:"supersedes_id,omitempty"`
+	SupersededByID *uint `gorm:"-" json:"superseded_by_id,omitempty"`
+
+	CustomFields []CustomField `gorm:"-" json:"custom_fields,omitempty"`
+}
+
+// ActiveLegals is a query scope that hides documents renewed by a newer one still in use
+func ActiveLegals(db *gorm.DB) *gorm.DB {
+	return db.Where("NOT EXISTS (SELECT 1 FROM legals AS successor WHERE successor.supersedes_id = legals.id AND successor.deleted_at IS NULL)")
 }
 
+// LegalAdditionalInfo is the legacy name/value field, superseded by custom fields
 type LegalAdditionalInfo struct {
 	gorm.Model
-	LegalID uint   `gorm:"unique" json:"legal_id"`
+	LegalID uint   `gorm:"index" json:"legal_id"`
 	Name    string `json:"name"`
 	Value   string `json:"value"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LegalFileVersion is a file that was replaced on a business or product legal document.
+// The document always points at its current file; earlier ones are kept here.
+type LegalFileVersion struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	BusinessID   uint      `gorm:"not null;index" json:"business_id"`
+	EntityType   string    `gorm:"not null;index:idx_legal_file_version" json:"entity_type"` // AuditEntityLegal or AuditEntityProductLegal
+	EntityID     uint      `gorm:"not null;index:idx_legal_file_version" json:"entity_id"`
+	FileName     string    `json:"file_name"`
+	FileURL      string    `json:"file_url"`
+	ReplacedByID uint      `json:"replaced_by_id,omitempty"` // Zero for changes made by the system
+	ReplacedAt   time.Time `gorm:"autoCreateTime" json:"replaced_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt records a single sign-in attempt. Unknown usernames are recorded
+// too, so throttling behaves the same whether or not an account exists.
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Username  string    `gorm:"index" json:"username"`
+	UserID    *uint     `gorm:"index" json:"user_id,omitempty"`
+	IPAddress string    `gorm:"index" json:"ip_address"`
+	Success   bool      `gorm:"not null" json:"success"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// Security event types
+const (
+	SecurityEventAccountLocked = "account_locked"
+	SecurityEventIPBlocked     = "ip_blocked"
+)
+
+// SecurityEvent is a notable authentication event kept for admins to review
+type SecurityEvent struct {
+	gorm.Model
+	Type      string     `gorm:"not null;index" json:"type"`
+	UserID    *uint      `gorm:"index" json:"user_id,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	IPAddress string     `json:"ip_address,omitempty"`
+	Details   string     `json:"details,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // When the lock or block ends
+}
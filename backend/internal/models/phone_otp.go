@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OTP purposes
+const (
+	OTPPurposeLogin             = "login"
+	OTPPurposePhoneVerification = "phone_verification"
+)
+
+// PhoneOTP is a one-time code sent by SMS to a phone number
+type PhoneOTP struct {
+	gorm.Model
+	PhoneNumber string    `gorm:"not null;index"`
+	Purpose     string    `gorm:"not null;index"`
+	CodeHash    string    `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	Attempts    int       `gorm:"not null;default:0"`
+	ConsumedAt  *time.Time
+}
@@ -9,13 +9,40 @@ import (
 // ================== PRODUCT ==================
 type Product struct {
 	gorm.Model
-	BusinessID uint   `json:"business_id"`
-	Name       string `gorm:"not null" json:"name"`
+	BusinessID  uint   `gorm:"index" json:"business_id"`
+	Name        string `gorm:"not null" json:"name"`
+	Description string `gorm:"type:text" json:"description,omitempty"`
+	Category    string `gorm:"index" json:"category,omitempty"` // e.g. Food & Beverage, Apparel
+	SKU         string `json:"sku,omitempty"`                   // Unique within the business
+
+	UnitPrice          *float64 `json:"unit_price,omitempty"`
+	Currency           string   `gorm:"default:IDR" json:"currency,omitempty"` // ISO 4217 code
+	ProductionCapacity *float64 `json:"production_capacity,omitempty"`
+	CapacityUnit       string   `json:"capacity_unit,omitempty"` // e.g. pcs/month, kg/day
+
+	Images   []ProductImage   `gorm:"type:jsonb;serializer:json" json:"images,omitempty"`
+	Variants []ProductVariant `gorm:"type:jsonb;serializer:json" json:"variants,omitempty"`
 
 	// Relations
 	ProductLegals []ProductLegal `gorm:"foreignKey:ProductID" json:"product_legals,omitempty"`
 }
 
+// ProductImage is a picture of the product, uploaded or hosted elsewhere
+type ProductImage struct {
+	URL     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// ProductVariant is a sellable version of the product such as a size or flavour. Variants
+// without their own price or capacity use the product's.
+type ProductVariant struct {
+	Name               string            `json:"name"`
+	SKU                string            `json:"sku,omitempty"`
+	UnitPrice          *float64          `json:"unit_price,omitempty"`
+	ProductionCapacity *float64          `json:"production_capacity,omitempty"`
+	Attributes         map[string]string `json:"attributes,omitempty"` // e.g. size: 250ml
+}
+
 // ================== PRODUCT LEGAL ==================
 type ProductLegal struct {
 	gorm.Model
@@ -29,6 +56,15 @@ type ProductLegal struct {
 	ValidUntil *time.Time `json:"valid_until,omitempty"`
 	Notes      string     `json:"notes,omitempty"`
 
+	// Renewals point at the permit they replace; only the newest in a chain is active
+	SupersedesID   *uint `gorm:"index" json:"supersedes_id,omitempty"`
+	SupersededByID *uint `gorm:"-" json:"superseded_by_id,omitempty"`
+
 	// Relations
 	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
 }
+
+// ActiveProductLegals is a query scope that hides permits renewed by a newer one still in use
+func ActiveProductLegals(db *gorm.DB) *gorm.DB {
+	return db.Where("NOT EXISTS (SELECT 1 FROM product_legals AS successor WHERE successor.supersedes_id = product_legals.id AND successor.deleted_at IS NULL)")
+}
@@ -0,0 +1,64 @@
+package models
+
+// User roles
+const (
+	RoleOwner    = "owner"    // SME owner managing their own businesses
+	RoleInvestor = "investor" // Investor browsing businesses on the platform
+	RoleAdmin    = "admin"    // Platform administrator
+)
+
+// Permissions granted through roles
+const (
+	PermissionManageBusiness   = "business:manage"
+	PermissionBrowseListings   = "investment:browse"
+	PermissionUseGenAI         = "genai:use"
+	PermissionInvestmentAdvice = "genai:investment_advice"
+	PermissionAdministerSystem = "admin:system"
+)
+
+var rolePermissions = map[string][]string{
+	RoleOwner: {
+		PermissionManageBusiness,
+		PermissionUseGenAI,
+	},
+	RoleInvestor: {
+		PermissionBrowseListings,
+		PermissionUseGenAI,
+		PermissionInvestmentAdvice,
+	},
+	RoleAdmin: {
+		PermissionManageBusiness,
+		PermissionBrowseListings,
+		PermissionUseGenAI,
+		PermissionInvestmentAdvice,
+		PermissionAdministerSystem,
+	},
+}
+
+// IsValidRole reports whether the role is one of the known roles
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsSelfAssignableRole reports whether a user may pick the role when registering
+func IsSelfAssignableRole(role string) bool {
+	return role == RoleOwner || role == RoleInvestor
+}
+
+// PermissionsForRole returns the permissions granted to a role
+func PermissionsForRole(role string) []string {
+	permissions := make([]string, len(rolePermissions[role]))
+	copy(permissions, rolePermissions[role])
+	return permissions
+}
+
+// RoleHasPermission reports whether the role grants the permission
+func RoleHasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -7,15 +7,38 @@ import (
 )
 
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Username  string         `gorm:"unique;not null" json:"username"`
-	Password  string         `gorm:"not null" json:"-"`
-	Email     string         `gorm:"unique;not null" json:"email"`
-	PhoneNumber     string         `gorm:"unique;not null" json:"phone_number"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Username        string     `gorm:"unique;not null" json:"username"`
+	Password        string     `gorm:"not null" json:"-"`
+	Email           string     `gorm:"unique;not null" json:"email"`
+	PhoneNumber     string     `gorm:"unique;not null" json:"phone_number"`
+	Role            string     `gorm:"not null;default:owner" json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+
+	// Two-factor authentication
+	TwoFactorEnabled bool   `gorm:"not null;default:false" json:"two_factor_enabled"`
+	TOTPSecret       string `json:"-"`
+	TOTPLastUsedStep int64  `json:"-"` // Rejects replay of an already accepted code
+
+	// Set while the account is temporarily locked after repeated failed logins
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+
+	// Moderation by admins
+	SuspendedAt           *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason      string     `json:"suspension_reason,omitempty"`
+	PasswordResetRequired bool       `gorm:"not null;default:false" json:"password_reset_required"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relation
 	Businesses []Business `gorm:"foreignKey:UserID" json:"businesses,omitempty"`
+	Sessions   []Session  `gorm:"foreignKey:UserID" json:"sessions,omitempty"` // Only filled for the user's own profile
+}
+
+// Permissions returns the permissions granted by the user's role
+func (u *User) Permissions() []string {
+	return PermissionsForRole(u.Role)
 }
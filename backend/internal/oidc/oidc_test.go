@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"go-gin-backend/internal/oidc/oidctest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const testRedirectURL = "http://app.test/auth/oidc/test/callback"
+
+func newTestProvider(idp *oidctest.Server) *Provider {
+	return NewProvider("test", "Test IdP", idp.URL, oidctest.ClientID, oidctest.ClientSecret, testRedirectURL, nil)
+}
+
+// login goes through the authorization code flow and returns the verified ID token claims
+func login(t *testing.T, idp *oidctest.Server, p *Provider, identity oidctest.Identity) (*IDTokenClaims, error) {
+	t.Helper()
+
+	authURL, err := p.AuthCodeURL("state", "nonce", "code-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code := idp.Authorize(t, authURL, identity)
+	return p.Exchange(code, "code-verifier", "nonce")
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := oidctest.NewServer(t)
+
+	authURL, err := newTestProvider(idp).AuthCodeURL("state", "nonce", "code-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             oidctest.ClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        CodeChallenge("code-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr error
+	}{
+		{name: "valid ID token"},
+		{name: "another audience", claims: jwt.MapClaims{"aud": "another-client"}, wantErr: ErrInvalidIDToken},
+		{name: "audience list with this client", claims: jwt.MapClaims{"aud": []string{"another-client", oidctest.ClientID}}},
+		{name: "another issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}, wantErr: ErrInvalidIDToken},
+		{name: "expired", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, wantErr: ErrInvalidIDToken},
+		{name: "another nonce", claims: jwt.MapClaims{"nonce": "replayed"}, wantErr: ErrInvalidIDToken},
+		{name: "no subject", claims: jwt.MapClaims{"sub": ""}, wantErr: ErrInvalidIDToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.NewServer(t)
+
+			claims, err := login(t, idp, newTestProvider(idp), oidctest.Identity{
+				Subject:       "user-1",
+				Email:         "alice@example.com",
+				EmailVerified: true,
+				Claims:        tt.claims,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Exchange() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (claims.Subject != "user-1" || claims.Email != "alice@example.com" || !claims.IsEmailVerified()) {
+				t.Errorf("Exchange() = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsCode(t *testing.T) {
+	idp := oidctest.NewServer(t)
+	p := newTestProvider(idp)
+	authURL, err := p.AuthCodeURL("state", "nonce", "code-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := idp.Authorize(t, authURL, oidctest.Identity{Subject: "user-1"})
+	if _, err := p.Exchange(code, "another-verifier", "nonce"); !errors.Is(err, ErrCodeExchange) {
+		t.Errorf("wrong PKCE verifier: error = %v, want %v", err, ErrCodeExchange)
+	}
+
+	code = idp.Authorize(t, authURL, oidctest.Identity{Subject: "user-1"})
+	if _, err := p.Exchange(code, "code-verifier", "nonce"); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if _, err := p.Exchange(code, "code-verifier", "nonce"); !errors.Is(err, ErrCodeExchange) {
+		t.Errorf("reused code: error = %v, want %v", err, ErrCodeExchange)
+	}
+}
+
+func TestVerifyIDTokenChecksSignature(t *testing.T) {
+	idp := oidctest.NewServer(t)
+	p := newTestProvider(idp)
+	claims := jwt.MapClaims{
+		"iss": idp.URL,
+		"sub": "user-1",
+		"aud": oidctest.ClientID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	signed, err := idp.SignIDToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.VerifyIDToken(signed, ""); err != nil {
+		t.Fatalf("token signed by the provider: %v", err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	forged.Header["kid"] = "test-key"
+	forgedToken, err := forged.SignedString(otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.VerifyIDToken(forgedToken, ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("token signed by another key: error = %v, want %v", err, ErrInvalidIDToken)
+	}
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(oidctest.ClientSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.VerifyIDToken(unsigned, ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("token signed with the client secret: error = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"go-gin-backend/internal/controllers"
+	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/mailer"
+	"go-gin-backend/internal/middleware"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/sms"
+	"go-gin-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAdminRoutes(router *gin.RouterGroup) {
+	// Initialize services
+	userService := services.NewUserService(database.DB)
+	businessService := services.NewBusinessService(database.DB)
+	sessionService := services.NewSessionService(database.DB)
+	otpService := services.NewOTPService(database.DB, sms.NewFromEnv())
+	loginGuard := services.NewLoginGuardService(database.DB)
+	authService := services.NewAuthService(userService, sessionService, otpService, loginGuard, mailer.NewFromEnv(), string(utils.GetJWTSecret()))
+
+	// Initialize controllers
+	adminController := controllers.NewAdminController(userService, businessService, authService)
+
+	// Admin routes
+	adminGroup := router.Group("/admin", middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAdmin))
+	{
+		// User moderation
+		adminGroup.GET("/users", adminController.SearchUsers)
+		adminGroup.POST("/users/:id/suspend", adminController.SuspendUser)
+		adminGroup.POST("/users/:id/unsuspend", adminController.UnsuspendUser)
+		adminGroup.POST("/users/:id/unlock", adminController.UnlockUser)
+		adminGroup.PUT("/users/:id/role", adminController.AssignRole)
+		adminGroup.POST("/users/:id/force-password-reset", adminController.ForcePasswordReset)
+
+		// Sign-in security
+		adminGroup.GET("/security-events", adminController.ListSecurityEvents)
+
+		// Business moderation
+		adminGroup.GET("/businesses", adminController.SearchBusinesses)
+		adminGroup.POST("/businesses/:id/takedown", adminController.TakeDownBusiness)
+		adminGroup.POST("/businesses/:id/restore", adminController.RestoreBusiness)
+	}
+}
@@ -3,8 +3,12 @@ package auth
 import (
 	"go-gin-backend/internal/controllers"
 	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/mailer"
+	"go-gin-backend/internal/middleware"
+	"go-gin-backend/internal/oidc"
 	"go-gin-backend/internal/services"
-	"os"
+	"go-gin-backend/internal/sms"
+	"go-gin-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,16 +16,53 @@ import (
 func SetupAuthRoutes(router *gin.RouterGroup) {
 	// Initialize services
 	userService := services.NewUserService(database.DB)
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key" // Default for development
-	}
-	authService := services.NewAuthService(userService, jwtSecret)
+	sessionService := services.NewSessionService(database.DB)
+	otpService := services.NewOTPService(database.DB, sms.NewFromEnv())
+	loginGuard := services.NewLoginGuardService(database.DB)
+	authService := services.NewAuthService(userService, sessionService, otpService, loginGuard, mailer.NewFromEnv(), string(utils.GetJWTSecret()))
+	oidcService := services.NewOIDCService(database.DB, authService, oidc.ProvidersFromEnv())
 
 	// Initialize controllers
 	authController := controllers.NewAuthController(authService)
+	oidcController := controllers.NewOIDCController(oidcService)
 
 	// Authentication routes
 	router.POST("/register", authController.Register)
 	router.POST("/login", authController.Login)
+	router.POST("/refresh", authController.Refresh)
+
+	// Session routes
+	router.POST("/logout", middleware.AuthMiddleware(), authController.Logout)
+	router.POST("/logout-all", middleware.AuthMiddleware(), authController.LogoutAll)
+
+	// Account recovery and verification routes
+	router.POST("/password/forgot", authController.ForgotPassword)
+	router.POST("/password/reset", authController.ResetPassword)
+	router.POST("/email/verify", authController.VerifyEmail)
+	router.POST("/email/verify/resend", middleware.AuthMiddleware(), authController.ResendEmailVerification)
+
+	// Phone OTP routes
+	router.POST("/otp/request", authController.RequestLoginOTP)
+	router.POST("/login/otp", authController.LoginWithOTP)
+	router.POST("/phone/verify/request", middleware.AuthMiddleware(), authController.RequestPhoneVerification)
+	router.POST("/phone/verify", middleware.AuthMiddleware(), authController.VerifyPhone)
+
+	// Single sign-on with OIDC identity providers
+	oidcGroup := router.Group("/oidc")
+	{
+		oidcGroup.GET("/providers", oidcController.ListProviders)
+		oidcGroup.GET("/:provider/login", oidcController.StartLogin)
+		oidcGroup.GET("/:provider/callback", oidcController.Callback)
+		oidcGroup.POST("/:provider/callback", oidcController.Callback)
+	}
+
+	// Two-factor authentication routes
+	router.POST("/login/2fa", authController.LoginWithTwoFactor)
+	twoFactorGroup := router.Group("/2fa", middleware.AuthMiddleware())
+	{
+		twoFactorGroup.POST("/enroll", authController.EnrollTwoFactor)
+		twoFactorGroup.POST("/verify", authController.ConfirmTwoFactor)
+		twoFactorGroup.POST("/disable", authController.DisableTwoFactor)
+		twoFactorGroup.POST("/recovery-codes", authController.RegenerateRecoveryCodes)
+	}
 }
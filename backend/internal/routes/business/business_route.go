@@ -3,7 +3,11 @@ package business
 import (
 	"go-gin-backend/internal/controllers"
 	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/mailer"
+	"go-gin-backend/internal/middleware"
+	"go-gin-backend/internal/models"
 	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/sms"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,39 +15,114 @@ import (
 func SetupBusinessRoutes(router *gin.RouterGroup) {
 	// Init service
 	businessService := services.NewBusinessService(database.DB)
+	membershipService := services.NewMembershipService(database.DB, mailer.NewFromEnv(), sms.NewFromEnv())
+	auditService := services.NewAuditService(database.DB)
 
 	// Init controller
 	businessController := controllers.NewBusinessController(businessService)
+	membershipController := controllers.NewMembershipController(membershipService)
+	auditController := controllers.NewAuditController(auditService)
+	customFieldController := controllers.NewCustomFieldController(businessService)
+	dataRoomController := controllers.NewDataRoomController(businessService)
 
 	// Business routes
-	businessGroup := router.Group("/business")
+	businessGroup := router.Group("/business", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermissionManageBusiness))
 	{
 		businessGroup.POST("", businessController.CreateBusiness)        // Create
-		businessGroup.GET("/:id", businessController.GetBusiness)        // Fetch one
-		businessGroup.PUT("/:id", businessController.UpdateBusiness)     // Update
-		businessGroup.DELETE("/:id", businessController.DeleteBusiness)  // Delete
 		businessGroup.GET("/user", businessController.GetUserBusinesses) // Fetch User's business
 
-		// Product management routes
-		businessGroup.GET("/:id/products", businessController.GetBusinessProducts)
-		businessGroup.POST("/:id/products", businessController.AddBusinessProducts)
-		businessGroup.PUT("/:id/products/:productId", businessController.UpdateBusinessProduct)
-		businessGroup.DELETE("/:id/products/:productId", businessController.DeleteBusinessProduct)
-
-		// Legal document routes
-		businessGroup.GET("/:id/legal", businessController.GetBusinessLegal)
-		businessGroup.POST("/:id/legal", businessController.AddBusinessLegal)
-		businessGroup.GET("/:id/products/legal", businessController.GetProductsLegal)
-		businessGroup.POST("/:id/products/:productId/legal", businessController.AddProductLegal)
-
-		// Financial data routes
-		businessGroup.GET("/:id/financial", businessController.GetBusinessFinancial)
-		businessGroup.GET("/:id/financial/history", businessController.GetBusinessFinancialHistory)
-		businessGroup.POST("/:id/financial", businessController.CreateBusinessFinancial)
-		businessGroup.PUT("/:id/financial", businessController.UpdateBusinessFinancial)
-
-		// Historical projections routes
-		businessGroup.GET("/:id/projections", businessController.GetBusinessProjections)
-		businessGroup.POST("/:id/projections", businessController.SaveBusinessProjections)
+		// Join a business the user was invited to
+		businessGroup.POST("/invitations/accept", membershipController.AcceptInvitation)
+
+		// Deleted businesses stay restorable by their owners until the trash is purged
+		businessGroup.GET("/trash", businessController.ListTrashedBusinesses)
+		businessGroup.POST("/trash/:id/restore", businessController.RestoreTrashedBusiness)
+
+		// Everything below requires the caller to be a member of the business in the path
+		viewer := middleware.BusinessAccessMiddleware(businessService, models.BusinessRoleViewer)
+		editor := middleware.BusinessAccessMiddleware(businessService, models.BusinessRoleEditor)
+		owner := middleware.BusinessAccessMiddleware(businessService, models.BusinessRoleOwner)
+
+		memberGroup := businessGroup.Group("/:id")
+		{
+			memberGroup.GET("", viewer, businessController.GetBusiness)      // Fetch one
+			memberGroup.PUT("", owner, businessController.UpdateBusiness)    // Update
+			memberGroup.PATCH("", owner, businessController.UpdateBusiness)  // Partial update with If-Match
+			memberGroup.DELETE("", owner, businessController.DeleteBusiness) // Delete
+			memberGroup.GET("/completeness", viewer, businessController.GetBusinessCompleteness)
+
+			// Product management routes
+			memberGroup.GET("/products", viewer, businessController.GetBusinessProducts)
+			memberGroup.GET("/products/categories", viewer, businessController.GetProductCategories)
+			memberGroup.POST("/products", editor, businessController.AddBusinessProducts)
+			memberGroup.PUT("/products/:productId", editor, businessController.UpdateBusinessProduct)
+			memberGroup.DELETE("/products/:productId", editor, businessController.DeleteBusinessProduct)
+			memberGroup.POST("/products/:productId/images", editor, businessController.AddProductImage)
+
+			// Legal document routes
+			memberGroup.GET("/legal", viewer, businessController.GetBusinessLegal)
+			memberGroup.POST("/legal", editor, businessController.AddBusinessLegal)
+			memberGroup.PUT("/legal/:legalId", editor, businessController.UpdateBusinessLegal)
+			memberGroup.PATCH("/legal/:legalId", editor, businessController.UpdateBusinessLegal)
+			memberGroup.DELETE("/legal/:legalId", editor, businessController.DeleteBusinessLegal)
+			memberGroup.GET("/legal/:legalId/versions", viewer, businessController.GetBusinessLegalVersions)
+			memberGroup.GET("/legal/:legalId/history", viewer, businessController.GetBusinessLegalHistory)
+			memberGroup.GET("/products/legal", viewer, businessController.GetProductsLegal)
+			memberGroup.POST("/products/:productId/legal", editor, businessController.AddProductLegal)
+			memberGroup.PUT("/products/:productId/legal/:legalId", editor, businessController.UpdateProductLegal)
+			memberGroup.PATCH("/products/:productId/legal/:legalId", editor, businessController.UpdateProductLegal)
+			memberGroup.DELETE("/products/:productId/legal/:legalId", editor, businessController.DeleteProductLegal)
+			memberGroup.GET("/products/:productId/legal/:legalId/versions", viewer, businessController.GetProductLegalVersions)
+			memberGroup.GET("/products/:productId/legal/:legalId/history", viewer, businessController.GetProductLegalHistory)
+
+			// Financial data routes
+			memberGroup.GET("/financial", viewer, businessController.GetBusinessFinancial)
+			memberGroup.GET("/financial/history", viewer, businessController.GetBusinessFinancialHistory)
+			memberGroup.POST("/financial", editor, businessController.CreateBusinessFinancial)
+			memberGroup.PUT("/financial", editor, businessController.UpdateBusinessFinancial)
+
+			// Custom field routes
+			memberGroup.GET("/custom-fields", viewer, customFieldController.ListCustomFields)
+			memberGroup.POST("/custom-fields", owner, customFieldController.CreateCustomField)
+			memberGroup.PUT("/custom-fields/:fieldId", owner, customFieldController.UpdateCustomField)
+			memberGroup.DELETE("/custom-fields/:fieldId", owner, customFieldController.DeleteCustomField)
+			memberGroup.GET("/custom-values/:entityType/:entityId", viewer, customFieldController.GetCustomFieldValues)
+			memberGroup.PUT("/custom-values/:entityType/:entityId", editor, customFieldController.SetCustomFieldValues)
+
+			// Bulk import from CSV or XLSX
+			memberGroup.GET("/import/:kind/columns", viewer, businessController.GetImportColumns)
+			memberGroup.POST("/import/:kind", editor, businessController.ImportBusinessData)
+
+			// Historical projections routes
+			memberGroup.GET("/projections", viewer, businessController.GetBusinessProjections)
+			memberGroup.POST("/projections", editor, businessController.SaveBusinessProjections)
+
+			// Team management routes
+			memberGroup.GET("/members", viewer, membershipController.ListMembers)
+			memberGroup.PATCH("/members/:memberId", owner, membershipController.UpdateMemberRole)
+			memberGroup.DELETE("/members/:memberId", owner, membershipController.RemoveMember)
+			memberGroup.GET("/invitations", owner, membershipController.ListInvitations)
+			memberGroup.POST("/invitations", owner, membershipController.InviteMember)
+			memberGroup.DELETE("/invitations/:invitationId", owner, membershipController.RevokeInvitation)
+
+			// Investors let into the business's documents
+			memberGroup.GET("/data-room/grants", owner, dataRoomController.ListGrants)
+			memberGroup.POST("/data-room/grants", owner, dataRoomController.GrantAccess)
+			memberGroup.DELETE("/data-room/grants/:grantId", owner, dataRoomController.RevokeGrant)
+
+			// Deleted products and documents
+			memberGroup.GET("/trash", viewer, businessController.ListBusinessTrash)
+			memberGroup.POST("/trash/:entityType/:entityId/restore", editor, businessController.RestoreTrashedItem)
+
+			// Change history. Audit entries carry the IP addresses and user agents of members.
+			memberGroup.GET("/audit-logs", owner, auditController.ListBusinessAuditLogs)
+			memberGroup.GET("/snapshots", viewer, businessController.ListSnapshots)
+			memberGroup.POST("/snapshots", editor, businessController.CreateSnapshot)
+			memberGroup.GET("/snapshots/:snapshotId", viewer, businessController.GetSnapshot)
+			memberGroup.GET("/snapshots/:snapshotId/diff", viewer, businessController.DiffSnapshot)
+			memberGroup.POST("/snapshots/:snapshotId/restore", owner, businessController.RestoreSnapshot)
+			memberGroup.PUT("/pinned-snapshot", owner, businessController.PinSnapshot)
+			memberGroup.DELETE("/pinned-snapshot", owner, businessController.UnpinSnapshot)
+		}
 	}
 }
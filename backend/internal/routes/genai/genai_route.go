@@ -3,6 +3,8 @@ package genai
 import (
 	"go-gin-backend/internal/controllers"
 	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/middleware"
+	"go-gin-backend/internal/models"
 	"go-gin-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -19,12 +21,21 @@ func SetupGenAIRoutes(router *gin.RouterGroup) {
 	genAIController := controllers.NewGenAIController(genAIService, businessService)
 
 	// User routes
+	// Chat endpoints also accept API keys with the genai:chat scope; the rest need a signed-in user
+	useGenAI := middleware.RequirePermission(models.PermissionUseGenAI)
+	chatAuth := middleware.AuthMiddleware(models.APIScopeGenAIChat)
+	userAuth := middleware.AuthMiddleware()
 	genAIGroup := router.Group("/genai")
 	{
-		genAIGroup.GET("/response", genAIController.GetAIResponse)
-		genAIGroup.POST("/infer-products", genAIController.GetProductsFromFile)
-		genAIGroup.POST("/analyze-business-legals", genAIController.AnalyzeBusinessLegals)
-		genAIGroup.GET("/business-suggestions/:id", genAIController.GenerateBusinessSuggestions)
-		genAIGroup.POST("/investment-advice", genAIController.GetInvestmentAdvice)
+		genAIGroup.GET("/response", chatAuth, useGenAI, genAIController.GetAIResponse)
+
+		// Business owner tools
+		manageBusiness := middleware.RequirePermission(models.PermissionManageBusiness)
+		genAIGroup.POST("/infer-products", userAuth, useGenAI, manageBusiness, genAIController.GetProductsFromFile)
+		genAIGroup.POST("/analyze-business-legals", userAuth, useGenAI, manageBusiness, genAIController.AnalyzeBusinessLegals)
+		genAIGroup.GET("/business-suggestions/:id", userAuth, useGenAI, manageBusiness, middleware.BusinessAccessMiddleware(businessService, models.BusinessRoleViewer), genAIController.GenerateBusinessSuggestions)
+
+		// Investor tools
+		genAIGroup.POST("/investment-advice", chatAuth, useGenAI, middleware.RequirePermission(models.PermissionInvestmentAdvice), genAIController.GetInvestmentAdvice)
 	}
 }
@@ -3,6 +3,8 @@ package investment
 import (
 	"go-gin-backend/internal/controllers"
 	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/middleware"
+	"go-gin-backend/internal/models"
 	"go-gin-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -14,12 +16,18 @@ func SetupInvestmentRoutes(router *gin.RouterGroup) {
 
 	// Initialize controllers
 	businessController := controllers.NewBusinessController(businessService)
+	dataRoomController := controllers.NewDataRoomController(businessService)
 
 	// Investment routes
+	// Partner systems can also read listings with an API key holding the matching scope
+	browseListings := middleware.RequirePermission(models.PermissionBrowseListings)
 	investmentGroup := router.Group("/investment")
 	{
-		// Public routes (for browsing businesses)
-		investmentGroup.GET("/businesses", businessController.GetAllBusinessesForInvestment)
-		investmentGroup.GET("/businesses/:id", businessController.GetBusinessForInvestment)
+		// Investor routes (for browsing businesses)
+		investmentGroup.GET("/businesses", middleware.AuthMiddleware(models.APIScopeReadListings), browseListings, businessController.GetAllBusinessesForInvestment)
+		investmentGroup.GET("/businesses/:id", middleware.AuthMiddleware(models.APIScopeReadBusiness), browseListings, businessController.GetBusinessForInvestment)
+
+		// Businesses whose documents the investor has been let into
+		investmentGroup.GET("/data-rooms", middleware.AuthMiddleware(), browseListings, dataRoomController.ListInvestorDataRooms)
 	}
 }
@@ -1,10 +1,12 @@
 package routes
 
 import (
+	"go-gin-backend/internal/routes/admin"
 	"go-gin-backend/internal/routes/auth"
 	"go-gin-backend/internal/routes/business"
 	"go-gin-backend/internal/routes/genai"
 	"go-gin-backend/internal/routes/investment"
+	"go-gin-backend/internal/routes/upload"
 	"go-gin-backend/internal/routes/user"
 
 	"github.com/gin-gonic/gin"
@@ -20,4 +22,9 @@ func SetupRoutes(router *gin.Engine) {
 	investment.SetupInvestmentRoutes(api)
 	genai.SetupGenAIRoutes(api)
 	business.SetupBusinessRoutes(api)
+	admin.SetupAdminRoutes(api)
+	upload.SetupFileRoutes(api)
+
+	// Uploaded files are served outside /api
+	upload.SetupUploadRoutes(router)
 }
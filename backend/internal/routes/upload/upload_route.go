@@ -0,0 +1,40 @@
+package upload
+
+import (
+	"go-gin-backend/internal/controllers"
+	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/middleware"
+	"go-gin-backend/internal/services"
+	"go-gin-backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupUploadRoutes(router gin.IRoutes) {
+	// Initialize services
+	businessService := services.NewBusinessService(database.DB)
+
+	// Initialize controllers
+	uploadController := controllers.NewUploadController(storage.Files, businessService)
+
+	// Uploaded files, kept at the root where their stored URLs point. Only signed links are served.
+	router.GET("/uploads/*key", uploadController.ServeSignedUpload)
+	router.HEAD("/uploads/*key", uploadController.ServeSignedUpload)
+}
+
+func SetupFileRoutes(router *gin.RouterGroup) {
+	// Initialize services
+	businessService := services.NewBusinessService(database.DB)
+
+	// Initialize controllers
+	uploadController := controllers.NewUploadController(storage.Files, businessService)
+
+	// Authenticated downloads, checked against business membership and data room grants
+	fileGroup := router.Group("/files")
+	fileGroup.Use(middleware.AuthMiddleware())
+	{
+		fileGroup.POST("/sign", uploadController.SignFileURLs)
+		fileGroup.GET("/*key", uploadController.DownloadFile)
+		fileGroup.HEAD("/*key", uploadController.DownloadFile)
+	}
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"go-gin-backend/internal/controllers"
 	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/middleware"
 	"go-gin-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -11,14 +12,25 @@ import (
 func SetupUserRoutes(router *gin.RouterGroup) {
 	// Initialize services
 	userService := services.NewUserService(database.DB)
+	sessionService := services.NewSessionService(database.DB)
+	apiKeyService := services.NewAPIKeyService(database.DB)
 
 	// Initialize controllers
-	userController := controllers.NewUserController(userService)
+	userController := controllers.NewUserController(userService, sessionService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
 
 	// User routes
-	userGroup := router.Group("/users")
+	userGroup := router.Group("/users", middleware.AuthMiddleware())
 	{
-		userGroup.GET("/:id", userController.GetUser)
-		userGroup.PUT("/:id", userController.UpdateUser)
+		userGroup.GET("/:id", middleware.RequireSelfOrAdmin("id"), userController.GetUser)
+		userGroup.PUT("/:id", middleware.RequireSelfOrAdmin("id"), userController.UpdateUser)
+		userGroup.DELETE("/:id", middleware.RequireSelfOrAdmin("id"), userController.DeleteUser)
+		userGroup.GET("/:id/export", middleware.RequireSelfOrAdmin("id"), userController.ExportUserData)
+		userGroup.DELETE("/:id/sessions/:sessionId", middleware.RequireSelfOrAdmin("id"), userController.RevokeSession)
+
+		// API keys for partner integrations
+		userGroup.GET("/:id/api-keys", middleware.RequireSelfOrAdmin("id"), apiKeyController.ListAPIKeys)
+		userGroup.POST("/:id/api-keys", middleware.RequireSelfOrAdmin("id"), apiKeyController.CreateAPIKey)
+		userGroup.DELETE("/:id/api-keys/:keyId", middleware.RequireSelfOrAdmin("id"), apiKeyController.RevokeAPIKey)
 	}
 }
@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd answers one INSTREAM scan like clamd, with the reply for the streamed data.
+// It returns the address it listens on and the data it receives.
+func fakeClamd(t *testing.T, reply func(data []byte) string) (string, <-chan []byte) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data := readInstream(t, conn)
+		received <- data
+		conn.Write([]byte(reply(data) + "\x00"))
+	}()
+	return ln.Addr().String(), received
+}
+
+func readInstream(t *testing.T, conn net.Conn) []byte {
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		t.Errorf("clamd received command %q, %v", command, err)
+		return nil
+	}
+
+	var data []byte
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			t.Errorf("reading chunk size: %v", err)
+			return nil
+		}
+		if size == 0 {
+			return data
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			t.Errorf("reading chunk: %v", err)
+			return nil
+		}
+		data = append(data, chunk...)
+	}
+}
+
+// clamdVerdict reports streams that contain "virus" as infected
+func clamdVerdict(data []byte) string {
+	if bytes.Contains(data, []byte("virus")) {
+		return "stream: Win.Test.Virus FOUND"
+	}
+	return "stream: OK"
+}
+
+func TestClamdScanner(t *testing.T) {
+	large := bytes.Repeat([]byte("clean "), clamdChunkSize) // Sent in several chunks
+
+	tests := []struct {
+		name          string
+		data          []byte
+		wantSignature string // Empty for clean files
+	}{
+		{"clean file", []byte("%PDF-1.4 quarterly report"), ""},
+		{"empty file", nil, ""},
+		{"file over several chunks", large, ""},
+		{"infected file", []byte("%PDF-1.4 virus"), "Win.Test.Virus"},
+		{"infected in the last chunk", append(append([]byte{}, large...), "virus"...), "Win.Test.Virus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, received := fakeClamd(t, clamdVerdict)
+			s := NewClamdScanner(address, 5*time.Second)
+
+			err := s.Scan("upload.pdf", bytes.NewReader(tt.data))
+			var infected *InfectedError
+			switch {
+			case tt.wantSignature == "" && err != nil:
+				t.Errorf("Scan() error = %v, want nil", err)
+			case tt.wantSignature != "" && (!errors.As(err, &infected) || infected.Signature != tt.wantSignature):
+				t.Errorf("Scan() error = %v, want infected with %s", err, tt.wantSignature)
+			}
+			if data := <-received; !bytes.Equal(data, tt.data) {
+				t.Errorf("clamd received %d bytes, want the %d bytes of the file", len(data), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestClamdScannerErrors(t *testing.T) {
+	t.Run("scan error", func(t *testing.T) {
+		address, _ := fakeClamd(t, func([]byte) string { return "INSTREAM size limit exceeded. ERROR" })
+
+		err := NewClamdScanner(address, 5*time.Second).Scan("upload.pdf", strings.NewReader("data"))
+		var infected *InfectedError
+		if err == nil || errors.As(err, &infected) {
+			t.Errorf("Scan() error = %v, want a scan failure", err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := ln.Addr().String()
+		ln.Close()
+
+		err = NewClamdScanner(address, time.Second).Scan("upload.pdf", strings.NewReader("data"))
+		var infected *InfectedError
+		if err == nil || errors.As(err, &infected) {
+			t.Errorf("Scan() error = %v, want a connection failure", err)
+		}
+	})
+}
+
+func TestNewClamdScanner(t *testing.T) {
+	tests := []struct {
+		address     string
+		wantNetwork string
+		wantAddress string
+	}{
+		{"", "tcp", "localhost:3310"},
+		{"clamav:3310", "tcp", "clamav:3310"},
+		{"tcp://clamav:3310", "tcp", "clamav:3310"},
+		{"unix:/run/clamav/clamd.sock", "unix", "/run/clamav/clamd.sock"},
+		{"unix:///run/clamav/clamd.sock", "unix", "/run/clamav/clamd.sock"},
+	}
+
+	for _, tt := range tests {
+		s := NewClamdScanner(tt.address, time.Second)
+		if s.Network != tt.wantNetwork || s.Address != tt.wantAddress {
+			t.Errorf("NewClamdScanner(%q) = %s %s, want %s %s", tt.address, s.Network, s.Address, tt.wantNetwork, tt.wantAddress)
+		}
+	}
+}
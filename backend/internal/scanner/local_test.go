@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLocalScanner(t *testing.T) {
+	padding := strings.Repeat("a", 32*1024-10) // Splits the signature across two reads
+
+	tests := []struct {
+		name         string
+		data         string
+		wantInfected bool
+	}{
+		{"clean file", "%PDF-1.4 quarterly report", false},
+		{"empty file", "", false},
+		{"EICAR test file", string(eicarSignature), true},
+		{"EICAR inside a document", "%PDF-1.4\n" + string(eicarSignature) + "\n%%EOF", true},
+		{"EICAR split across reads", padding + string(eicarSignature), true},
+		{"partial signature", string(eicarSignature[:len(eicarSignature)-1]), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewLocalScanner().Scan("upload.pdf", strings.NewReader(tt.data))
+			assertInfected(t, err, tt.wantInfected)
+
+			err = NewLocalScanner().Scan("upload.pdf", iotest.OneByteReader(strings.NewReader(tt.data)))
+			assertInfected(t, err, tt.wantInfected)
+		})
+	}
+}
+
+func assertInfected(t *testing.T, err error, want bool) {
+	t.Helper()
+
+	var infected *InfectedError
+	if got := errors.As(err, &infected); got != want || (!want && err != nil) {
+		t.Errorf("Scan() error = %v, want infected %v", err, want)
+	}
+}
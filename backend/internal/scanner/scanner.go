@@ -0,0 +1,40 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Scanner checks uploaded files for malware before they are stored
+type Scanner interface {
+	// Scan reads the whole file and returns an *InfectedError when malware is found.
+	// Any other error means the file could not be scanned.
+	Scan(name string, r io.Reader) error
+}
+
+// InfectedError reports the signature a scanner found in a file
+type InfectedError struct {
+	Signature string
+}
+
+func (e *InfectedError) Error() string {
+	return fmt.Sprintf("malware detected: %s", e.Signature)
+}
+
+// NewFromEnv builds the scanner selected by SCANNER_DRIVER ("clamd" or "local").
+// The local scanner only recognises the EICAR test file, so development needs no antivirus.
+func NewFromEnv() Scanner {
+	switch os.Getenv("SCANNER_DRIVER") {
+	case "clamd":
+		timeout, err := strconv.Atoi(os.Getenv("CLAMD_TIMEOUT_SECONDS"))
+		if err != nil || timeout < 1 {
+			timeout = 30
+		}
+		return NewClamdScanner(os.Getenv("CLAMD_ADDRESS"), time.Duration(timeout)*time.Second)
+	default:
+		return NewLocalScanner()
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/utils"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// APIKeyPrefix marks API keys so they can be told apart from JWTs
+	APIKeyPrefix = "itb_"
+
+	apiKeyDisplayLength = 12
+	maxAPIKeysPerUser   = 20
+	// Last-used details are only written once per interval to keep hot keys cheap
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, revoked or expired API keys
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrInvalidAPIScope is returned when creating a key with an unknown or no scope
+	ErrInvalidAPIScope = errors.New("invalid API key scope")
+	// ErrAPIKeyNotFound is returned when revoking a key the user does not have
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrTooManyAPIKeys is returned when the user already has the maximum number of active keys
+	ErrTooManyAPIKeys = errors.New("too many active API keys")
+)
+
+type APIKeyService struct {
+	DB *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{DB: db}
+}
+
+// CreateAPIKey issues a new key for the user. The plain key is only returned here.
+func (s *APIKeyService) CreateAPIKey(userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidAPIScope
+	}
+	var granted models.APIKeyScopes
+	for _, scope := range scopes {
+		if !models.IsValidAPIScope(scope) {
+			return nil, "", ErrInvalidAPIScope
+		}
+		if !granted.Has(scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	var active int64
+	if err := s.DB.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&active).Error; err != nil {
+		return nil, "", err
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, "", ErrTooManyAPIKeys
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    granted,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.DB.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+
+	return key, rawKey, nil
+}
+
+// ListAPIKeys returns the user's keys, including revoked ones, newest first
+func (s *APIKeyService) ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops one of the user's keys from working
+func (s *APIKeyService) RevokeAPIKey(userID, keyID uint) error {
+	result := s.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a plain API key to its key record with the owning user loaded,
+// and records when and from where it was used
+func (s *APIKeyService) Authenticate(rawKey, ipAddress string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := s.DB.Preload("User").Where("key_hash = ?", utils.HashToken(rawKey)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if !key.IsActive() || key.User == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := checkAccountStatus(key.User); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval || key.LastUsedIP != ipAddress {
+		if err := s.DB.Model(&key).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ipAddress,
+		}).Error; err != nil {
+			log.Printf("Failed to record API key use: %v", err)
+		}
+	}
+
+	return &key, nil
+}
+
+// IsAPIKey reports whether a credential looks like an API key rather than a JWT
+func IsAPIKey(credential string) bool {
+	credential, _ = strings.CutPrefix(credential, "Bearer ")
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"encoding/json"
+	"go-gin-backend/internal/models"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fields left out of audit diffs because they change on every write
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// Fields whose values are replaced in audit entries so erasing a user leaves nothing behind
+var auditRedactedFields = map[string]bool{
+	"username":     true,
+	"email":        true,
+	"phone_number": true,
+}
+
+const auditRedacted = "[redacted]"
+
+// AuditActor identifies who made a change and from where
+type AuditActor struct {
+	UserID    uint
+	IPAddress string
+	UserAgent string
+	Reason    string // Optional explanation, required for admin actions
+}
+
+// AuditLogFilter narrows down the audit entries returned for a business
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   uint
+	Action     string
+	ActorID    uint
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
+type AuditService struct {
+	DB *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{DB: db}
+}
+
+// ListBusinessAuditLogs returns the business's audit entries, newest first, with the total count
+func (s *AuditService) ListBusinessAuditLogs(businessID uint, filter AuditLogFilter) ([]models.AuditLog, int64, error) {
+	query := s.DB.Model(&models.AuditLog{}).Where("business_id = ?", businessID)
+
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AuditLog
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(filter.Limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// recordAudit appends an audit entry in the same transaction as the change it describes.
+// before is nil for creates and after is nil for deletes.
+func recordAudit(tx *gorm.DB, actor AuditActor, businessID *uint, entityType string, entityID uint, action string, before, after interface{}) error {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return err
+	}
+
+	diff := map[string]map[string]interface{}{}
+	for field := range mergeKeys(beforeFields, afterFields) {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		b, a := beforeFields[field], afterFields[field]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		if auditRedactedFields[field] {
+			b, a = redactAuditValue(b), redactAuditValue(a)
+		}
+		diff[field] = map[string]interface{}{"before": b, "after": a}
+	}
+
+	entry := &models.AuditLog{
+		BusinessID: businessID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		Reason:     actor.Reason,
+	}
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		entry.ActorID = &actorID
+	}
+	if entry.Before, err = marshalAuditFields(beforeFields); err != nil {
+		return err
+	}
+	if entry.After, err = marshalAuditFields(afterFields); err != nil {
+		return err
+	}
+	if len(diff) > 0 {
+		if entry.Diff, err = json.Marshal(diff); err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(entry).Error
+}
+
+// auditFields flattens a value into its top-level JSON fields
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func marshalAuditFields(fields map[string]interface{}) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	redacted := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		if auditRedactedFields[k] {
+			v = redactAuditValue(v)
+		}
+		redacted[k] = v
+	}
+	return json.Marshal(redacted)
+}
+
+func redactAuditValue(v interface{}) interface{} {
+	if v == nil || v == "" {
+		return v
+	}
+	return auditRedacted
+}
+
+func mergeKeys(a, b map[string]interface{}) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
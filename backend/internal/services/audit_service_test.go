@@ -0,0 +1,90 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"go-gin-backend/internal/models"
+	"testing"
+)
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	s := NewBusinessService(db).WithActor(AuditActor{UserID: owner.ID, IPAddress: "203.0.113.1", UserAgent: "test"})
+	business := &models.Business{UserID: owner.ID, Name: "Kopi Nusantara"}
+	if err := s.CreateBusiness(business, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry models.AuditLog
+	if err := db.Where("business_id = ? AND action = ?", business.ID, models.AuditActionCreate).First(&entry).Error; err != nil {
+		t.Fatalf("the business creation was not audited: %v", err)
+	}
+	if entry.ActorID == nil || *entry.ActorID != owner.ID || entry.IPAddress != "203.0.113.1" {
+		t.Errorf("audit entry = %+v, want the actor recorded", entry)
+	}
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"update the entry", func() error { return db.Model(&entry).Update("action", models.AuditActionDelete).Error }},
+		{"save the entry", func() error { entry.Reason = "edited"; return db.Save(&entry).Error }},
+		{"update matching entries", func() error {
+			return db.Model(&models.AuditLog{}).Where("business_id = ?", business.ID).Update("reason", "edited").Error
+		}},
+		{"delete the entry", func() error { return db.Delete(&entry).Error }},
+		{"delete matching entries", func() error {
+			return db.Where("business_id = ?", business.ID).Delete(&models.AuditLog{}).Error
+		}},
+	}
+	for _, c := range changes {
+		if err := c.change(); !errors.Is(err, models.ErrAuditLogImmutable) {
+			t.Errorf("%s: error = %v, want %v", c.name, err, models.ErrAuditLogImmutable)
+		}
+	}
+
+	var stored models.AuditLog
+	if err := db.First(&stored, entry.ID).Error; err != nil {
+		t.Fatalf("the entry is gone: %v", err)
+	}
+	if stored.Action != models.AuditActionCreate || stored.Reason != "" {
+		t.Errorf("stored entry = %+v, want it unchanged", stored)
+	}
+}
+
+func TestRecordAuditDiff(t *testing.T) {
+	db := newTestDB(t)
+	before := &models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleOwner}
+	after := &models.User{Username: "alice", Email: "alice@new.example.com", Role: models.RoleInvestor}
+
+	if err := recordAudit(db, AuditActor{Reason: "test"}, nil, models.AuditEntityUser, 1, models.AuditActionUpdate, before, after); err != nil {
+		t.Fatalf("recordAudit: %v", err)
+	}
+
+	var entry models.AuditLog
+	if err := db.First(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	var diff map[string]map[string]interface{}
+	if err := json.Unmarshal(entry.Diff, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 2 {
+		t.Errorf("diff = %v, want the email and role", diff)
+	}
+	if role := diff["role"]; role["before"] != models.RoleOwner || role["after"] != models.RoleInvestor {
+		t.Errorf("role diff = %v", role)
+	}
+	// Personal data is kept out of the log
+	if email := diff["email"]; email["before"] != auditRedacted || email["after"] != auditRedacted {
+		t.Errorf("email diff = %v, want it redacted", email)
+	}
+	var afterFields map[string]interface{}
+	if err := json.Unmarshal(entry.After, &afterFields); err != nil {
+		t.Fatal(err)
+	}
+	if afterFields["username"] != auditRedacted {
+		t.Errorf("after username = %v, want it redacted", afterFields["username"])
+	}
+}
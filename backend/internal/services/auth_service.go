@@ -2,37 +2,101 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"go-gin-backend/internal/mailer"
 	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/utils"
+	"log"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+// dummyPasswordHash is compared against when the username does not exist
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+var (
+	// ErrInvalidCredentials is returned for any failed username/password login
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidRole is returned when registering with a role that cannot be self-assigned
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrInvalidUserToken is returned for unknown, used or expired emailed tokens
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified is returned when asking to verify a verified email again
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	// ErrPhoneAlreadyVerified is returned when asking to verify a verified phone number again
+	ErrPhoneAlreadyVerified = errors.New("phone number already verified")
+	// ErrAccountSuspended is returned when a suspended user tries to sign in
+	ErrAccountSuspended = errors.New("account suspended")
+	// ErrPasswordResetRequired is returned when an admin requires the user to reset their password
+	ErrPasswordResetRequired = errors.New("password reset required")
+)
+
+// TokenPair is returned to clients after a successful login or refresh
+type TokenPair struct {
+	Token        string `json:"token"` // Short-lived access token
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// LoginResult holds either the issued tokens or a two-factor challenge to complete
+type LoginResult struct {
+	*TokenPair
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
 type AuthService struct {
-	UserService *UserService
-	JWTSecret   []byte
+	UserService    *UserService
+	SessionService *SessionService
+	OTPService     *OTPService
+	LoginGuard     *LoginGuardService
+	Mailer         mailer.Mailer
+	JWTSecret      []byte
 }
 
-func NewAuthService(userService *UserService, jwtSecret string) *AuthService {
+func NewAuthService(userService *UserService, sessionService *SessionService, otpService *OTPService, loginGuard *LoginGuardService, mailSender mailer.Mailer, jwtSecret string) *AuthService {
 	return &AuthService{
-		UserService: userService,
-		JWTSecret:   []byte(jwtSecret),
+		UserService:    userService,
+		SessionService: sessionService,
+		OTPService:     otpService,
+		LoginGuard:     loginGuard,
+		Mailer:         mailSender,
+		JWTSecret:      []byte(jwtSecret),
 	}
 }
 
-func (s *AuthService) Register(username, password, email string, phone_number string) (*models.User, error) {
+func (s *AuthService) Register(username, password, email string, phone_number string, role string) (*models.User, error) {
+	if role == "" {
+		role = models.RoleOwner
+	}
+	if !models.IsSelfAssignableRole(role) {
+		return nil, ErrInvalidRole
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &models.User{
-		Username: username,
-		Password: string(hashedPassword),
-		Email:    email,
-		PhoneNumber:    phone_number,
+		Username:    username,
+		Password:    string(hashedPassword),
+		Email:       email,
+		PhoneNumber: utils.NormalizePhoneNumber(phone_number),
+		Role:        role,
 	}
 
 	err = s.UserService.CreateUser(user)
@@ -40,34 +104,358 @@ func (s *AuthService) Register(username, password, email string, phone_number st
 		return nil, err
 	}
 
+	// Registration succeeds even if the mail server is down, the user can ask for a resend
+	if err := s.SendEmailVerification(user.ID); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	return user, nil
 }
 
-func (s *AuthService) Login(username, password string) (string, error) {
+// Login checks the username and password. Unknown usernames and wrong passwords fail the
+// same way, and repeated failures are throttled per account and per IP address.
+func (s *AuthService) Login(username, password string, client ClientInfo) (*LoginResult, error) {
+	if err := s.LoginGuard.Check(username, client.IPAddress); err != nil {
+		return nil, err
+	}
+
 	user, err := s.UserService.GetUserByUsername(username)
 	if err != nil {
-		return "", err
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// Compare anyway so response times don't reveal whether the account exists
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		s.LoginGuard.RecordFailure(username, nil, client.IPAddress)
+		return nil, ErrInvalidCredentials
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid credentials")
+		s.LoginGuard.RecordFailure(username, &user.ID, client.IPAddress)
+		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.GenerateToken(user.ID)
+	s.LoginGuard.RecordSuccess(username, user.ID, client.IPAddress)
+	return s.completeLogin(user, client)
+}
+
+// Refresh rotates the refresh token and issues a new access token for the same session
+func (s *AuthService) Refresh(refreshToken string, client ClientInfo) (*TokenPair, error) {
+	session, newRefreshToken, err := s.SessionService.RotateRefreshToken(refreshToken, client)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return token, nil
+	user, err := s.UserService.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, session, newRefreshToken)
+}
+
+// Logout revokes the session the access token was issued for
+func (s *AuthService) Logout(userID, sessionID uint) error {
+	return s.SessionService.RevokeSession(userID, sessionID)
+}
+
+// LogoutAll revokes every session of the user
+func (s *AuthService) LogoutAll(userID uint) error {
+	return s.SessionService.RevokeAllSessions(userID)
+}
+
+// completeLogin starts a session once the first factor is verified, or returns a
+// two-factor challenge when the user has 2FA enabled
+func (s *AuthService) completeLogin(user *models.User, client ClientInfo) (*LoginResult, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		challengeToken, err := s.generateChallengeToken(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{TwoFactorRequired: true, ChallengeToken: challengeToken}, nil
+	}
+
+	tokens, err := s.startSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: tokens}, nil
+}
+
+// checkAccountStatus keeps suspended users and users who must reset their password out
+func checkAccountStatus(user *models.User) error {
+	if user.SuspendedAt != nil {
+		return ErrAccountSuspended
+	}
+	if user.PasswordResetRequired {
+		return ErrPasswordResetRequired
+	}
+	return nil
 }
 
-func (s *AuthService) GenerateToken(userID uint) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &jwt.StandardClaims{
-		Subject:   strconv.FormatUint(uint64(userID), 10),
-		ExpiresAt: expirationTime.Unix(),
+func (s *AuthService) startSession(user *models.User, client ClientInfo) (*TokenPair, error) {
+	session, refreshToken, err := s.SessionService.CreateSession(user.ID, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, session, refreshToken)
+}
+
+func (s *AuthService) issueTokens(user *models.User, session *models.Session, refreshToken string) (*TokenPair, error) {
+	token, err := s.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		Token:        token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// GenerateToken signs an access token bound to the given session
+func (s *AuthService) GenerateToken(user *models.User, sessionID uint) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
+	claims := &utils.Claims{
+		Role:        user.Role,
+		Permissions: user.Permissions(),
+		StandardClaims: jwt.StandardClaims{
+			Audience:  utils.TokenAudienceAccess,
+			Id:        strconv.FormatUint(uint64(sessionID), 10),
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expirationTime.Unix(),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.JWTSecret)
 }
+
+// ===== Phone OTP =====
+
+// RequestLoginOTP texts a login code to the phone number if it belongs to an account.
+// Unknown numbers are ignored so the endpoint cannot be used to discover accounts.
+func (s *AuthService) RequestLoginOTP(phoneNumber string) error {
+	if _, err := s.UserService.GetUserByPhoneNumber(phoneNumber); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.OTPService.SendOTP(phoneNumber, models.OTPPurposeLogin)
+}
+
+// LoginWithOTP signs the user in with a phone number and the code texted to it
+func (s *AuthService) LoginWithOTP(phoneNumber, code string, client ClientInfo) (*LoginResult, error) {
+	if err := s.LoginGuard.Check("", client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	if err := s.OTPService.VerifyOTP(phoneNumber, models.OTPPurposeLogin, code); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			s.LoginGuard.RecordFailure("", nil, client.IPAddress)
+		}
+		return nil, err
+	}
+
+	user, err := s.UserService.GetUserByPhoneNumber(phoneNumber)
+	if err != nil {
+		return nil, ErrInvalidOTP
+	}
+
+	// Receiving the code proves the user owns the number
+	if user.PhoneVerifiedAt == nil {
+		if err := s.UserService.DB.Model(user).Update("phone_verified_at", time.Now()).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.completeLogin(user, client)
+}
+
+// SendPhoneVerification texts a verification code to the user's phone number
+func (s *AuthService) SendPhoneVerification(userID uint) error {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.PhoneVerifiedAt != nil {
+		return ErrPhoneAlreadyVerified
+	}
+
+	return s.OTPService.SendOTP(user.PhoneNumber, models.OTPPurposePhoneVerification)
+}
+
+// VerifyPhone marks the user's phone number as verified using the texted code
+func (s *AuthService) VerifyPhone(userID uint, code string) error {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.OTPService.VerifyOTP(user.PhoneNumber, models.OTPPurposePhoneVerification, code); err != nil {
+		return err
+	}
+
+	return s.UserService.DB.Model(user).Update("phone_verified_at", time.Now()).Error
+}
+
+// ===== Password Reset & Email Verification =====
+
+// RequestPasswordReset emails a reset link to the account with the given email.
+// Unknown emails are ignored so the endpoint cannot be used to discover accounts.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	user, err := s.UserService.GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.issueUserToken(user.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset kata sandi akun Anda",
+		Body: fmt.Sprintf("Halo %s,\n\nKami menerima permintaan untuk mengatur ulang kata sandi Anda. "+
+			"Buka tautan berikut dalam %d menit:\n\n%s\n\nAbaikan email ini jika Anda tidak memintanya.",
+			user.Username, int(passwordResetTTL.Minutes()), appURL("/reset-password", token)),
+	})
+}
+
+// ResetPassword sets a new password using a reset token and signs the user out everywhere
+func (s *AuthService) ResetPassword(token, newPassword string) error {
+	return s.UserService.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeUserToken(tx, token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", userToken.UserID).Updates(map[string]interface{}{
+			"password":                string(hashedPassword),
+			"password_reset_required": false,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Any outstanding reset links are no longer needed
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userToken.UserID, models.TokenPurposePasswordReset).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return NewSessionService(tx).RevokeAllSessions(userToken.UserID)
+	})
+}
+
+// SendEmailVerification emails a verification link to the user's address
+func (s *AuthService) SendEmailVerification(userID uint) error {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueUserToken(user.ID, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verifikasi email Anda",
+		Body: fmt.Sprintf("Halo %s,\n\nSilakan verifikasi alamat email Anda melalui tautan berikut:\n\n%s",
+			user.Username, appURL("/verify-email", token)),
+	})
+}
+
+// VerifyEmail marks the user's email as verified using a verification token
+func (s *AuthService) VerifyEmail(token string) error {
+	return s.UserService.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeUserToken(tx, token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userToken.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+}
+
+// issueUserToken stores the hash of a new single-use token and returns the raw token
+func (s *AuthService) issueUserToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	userToken := &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.UserService.DB.Create(userToken).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeUserToken marks a valid token as used so it cannot be replayed
+func (s *AuthService) consumeUserToken(tx *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	var userToken models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).
+		First(&userToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	result := tx.Model(&userToken).Where("used_at IS NULL").Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidUserToken
+	}
+
+	return &userToken, nil
+}
+
+// appURL builds a link into the frontend carrying a token
+func appURL(path, token string) string {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:5173" // Default for development
+	}
+	return strings.TrimSuffix(baseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/mailer"
+	"go-gin-backend/internal/models"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// captureMailer keeps sent emails instead of delivering them
+type captureMailer struct {
+	messages []mailer.Message
+}
+
+func (m *captureMailer) Send(msg mailer.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var emailTokenPattern = regexp.MustCompile(`\?token=(\S+)`)
+
+// lastEmailToken returns the token in the link of the last email sent
+func (m *captureMailer) lastEmailToken(t *testing.T) string {
+	t.Helper()
+
+	if len(m.messages) == 0 {
+		t.Fatal("no email was sent")
+	}
+	match := emailTokenPattern.FindStringSubmatch(m.messages[len(m.messages)-1].Body)
+	if match == nil {
+		t.Fatal("email does not contain a token link")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *captureMailer) {
+	t.Helper()
+
+	db := newTestDB(t)
+	mail := &captureMailer{}
+	userService := NewUserService(db)
+	return NewAuthService(userService, NewSessionService(db), nil, nil, mail, "test-secret"), mail
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	auth, mail := newTestAuthService(t)
+	user := createTestUser(t, auth.UserService.DB, "alice")
+
+	if err := auth.RequestPasswordReset("nobody@example.com"); err != nil {
+		t.Fatalf("unknown email: got error %v, want nil", err)
+	}
+	if len(mail.messages) != 0 {
+		t.Fatalf("unknown email: sent %d emails, want none", len(mail.messages))
+	}
+
+	if err := auth.RequestPasswordReset(user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	if len(mail.messages) != 1 || mail.messages[0].To != user.Email {
+		t.Fatalf("sent %+v, want one email to %s", mail.messages, user.Email)
+	}
+
+	token := mail.lastEmailToken(t)
+	var stored models.UserToken
+	if err := auth.UserService.DB.Where("user_id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.TokenHash == token {
+		t.Error("the raw token is stored instead of its hash")
+	}
+	if stored.Purpose != models.TokenPurposePasswordReset {
+		t.Errorf("purpose = %q, want %q", stored.Purpose, models.TokenPurposePasswordReset)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   func(t *testing.T, auth *AuthService, mail *captureMailer, user *models.User) string
+		wantErr error
+	}{
+		{
+			name: "valid token",
+			token: func(t *testing.T, auth *AuthService, mail *captureMailer, user *models.User) string {
+				if err := auth.RequestPasswordReset(user.Email); err != nil {
+					t.Fatal(err)
+				}
+				return mail.lastEmailToken(t)
+			},
+		},
+		{
+			name: "unknown token",
+			token: func(*testing.T, *AuthService, *captureMailer, *models.User) string {
+				return "not-a-token"
+			},
+			wantErr: ErrInvalidUserToken,
+		},
+		{
+			name: "used token",
+			token: func(t *testing.T, auth *AuthService, mail *captureMailer, user *models.User) string {
+				if err := auth.RequestPasswordReset(user.Email); err != nil {
+					t.Fatal(err)
+				}
+				token := mail.lastEmailToken(t)
+				if err := auth.ResetPassword(token, "first-new-password"); err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidUserToken,
+		},
+		{
+			name: "expired token",
+			token: func(t *testing.T, auth *AuthService, _ *captureMailer, user *models.User) string {
+				token, err := auth.issueUserToken(user.ID, models.TokenPurposePasswordReset, -time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidUserToken,
+		},
+		{
+			name: "email verification token",
+			token: func(t *testing.T, auth *AuthService, _ *captureMailer, user *models.User) string {
+				token, err := auth.issueUserToken(user.ID, models.TokenPurposeEmailVerification, time.Hour)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidUserToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, mail := newTestAuthService(t)
+			user := createTestUser(t, auth.UserService.DB, "alice")
+			token := tt.token(t, auth, mail, user)
+
+			err := auth.ResetPassword(token, "new-password")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResetPassword() error = %v, want %v", err, tt.wantErr)
+			}
+
+			updated, err := auth.UserService.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changed := bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("new-password")) == nil
+			if changed != (tt.wantErr == nil) {
+				t.Errorf("password changed = %v, want %v", changed, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	auth, mail := newTestAuthService(t)
+	user := createTestUser(t, auth.UserService.DB, "alice")
+
+	if err := auth.SendEmailVerification(user.ID); err != nil {
+		t.Fatalf("SendEmailVerification: %v", err)
+	}
+	token := mail.lastEmailToken(t)
+
+	if err := auth.VerifyEmail(token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	updated, err := auth.UserService.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.EmailVerifiedAt == nil {
+		t.Error("email is not marked as verified")
+	}
+
+	if err := auth.VerifyEmail(token); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("reusing the token: error = %v, want %v", err, ErrInvalidUserToken)
+	}
+	if err := auth.SendEmailVerification(user.ID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("verifying again: error = %v, want %v", err, ErrEmailAlreadyVerified)
+	}
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodeCount     = 10
+	totpIssuer            = "ITBuddy"
+)
+
+var (
+	// ErrInvalidTwoFactorCode is returned for wrong TOTP or recovery codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge is returned for unknown or expired login challenges
+	ErrInvalidChallenge = errors.New("invalid or expired challenge")
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling while 2FA is active
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTwoFactorNotEnabled is returned when 2FA operations need an active enrollment
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication not enabled")
+	// ErrTwoFactorNotEnrolled is returned when confirming 2FA before enrolling
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment not started")
+	// ErrInvalidPassword is returned when a sensitive action is confirmed with a wrong password
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+// TwoFactorEnrollment is returned when a user starts enrolling an authenticator app
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// CompleteTwoFactorLogin finishes a login started by a password or OTP login using a
+// TOTP code or one of the user's recovery codes
+func (s *AuthService) CompleteTwoFactorLogin(challengeToken, code, recoveryCode string, client ClientInfo) (*TokenPair, error) {
+	userID, err := s.parseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil || !user.TwoFactorEnabled {
+		return nil, ErrInvalidChallenge
+	}
+
+	// Second factor guesses count against the same limits as passwords
+	if err := s.LoginGuard.Check(user.Username, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(user, code, recoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.LoginGuard.RecordFailure(user.Username, &user.ID, client.IPAddress)
+		}
+		return nil, err
+	}
+
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(user, client)
+}
+
+// EnrollTwoFactor generates a new TOTP secret for the user. It only takes effect
+// once confirmed with ConfirmTwoFactor.
+func (s *AuthService) EnrollTwoFactor(userID uint) (*TwoFactorEnrollment, error) {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UserService.DB.Model(user).Update("totp_secret", secret).Error; err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables 2FA after the user proves the authenticator app works,
+// and returns a fresh set of recovery codes
+func (s *AuthService) ConfirmTwoFactor(userID uint, code string) ([]string, error) {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.UserService.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"two_factor_enabled":  true,
+			"totp_last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking the password and a second factor
+func (s *AuthService) DisableTwoFactor(userID uint, password, code, recoveryCode string) error {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return ErrInvalidPassword
+	}
+
+	if err := s.verifySecondFactor(user, code, recoveryCode); err != nil {
+		return err
+	}
+
+	return s.UserService.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"two_factor_enabled":  false,
+			"totp_secret":         "",
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a TOTP code
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.UserService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if err := s.verifySecondFactor(user, code, ""); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.UserService.DB.Transaction(func(tx *gorm.DB) error {
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// verifySecondFactor accepts either a fresh TOTP code or an unused recovery code
+func (s *AuthService) verifySecondFactor(user *models.User, code, recoveryCode string) error {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		// Only accept each time step once
+		result := s.UserService.DB.Model(&models.User{}).
+			Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+			Update("totp_last_used_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	if recoveryCode != "" {
+		return s.useRecoveryCode(user.ID, recoveryCode)
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+func (s *AuthService) useRecoveryCode(userID uint, recoveryCode string) error {
+	codeHash := utils.HashToken(normalizeRecoveryCode(recoveryCode))
+
+	result := s.UserService.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *AuthService) generateChallengeToken(userID uint) (string, error) {
+	claims := &jwt.StandardClaims{
+		Audience:  utils.TokenAudienceTwoFactorChallenge,
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.JWTSecret)
+}
+
+func (s *AuthService) parseChallengeToken(challengeToken string) (uint, error) {
+	claims, err := utils.ParseToken(challengeToken)
+	if err != nil || claims.Audience != utils.TokenAudienceTwoFactorChallenge {
+		return 0, ErrInvalidChallenge
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidChallenge
+	}
+	return uint(userID), nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores a new set
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// totpCode computes the current code an authenticator app would show for the secret
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	auth, _ := newTestAuthService(t)
+	user := createTestUser(t, auth.UserService.DB, "alice")
+
+	if _, err := auth.ConfirmTwoFactor(user.ID, "123456"); !errors.Is(err, ErrTwoFactorNotEnrolled) {
+		t.Fatalf("confirming before enrolling: error = %v, want %v", err, ErrTwoFactorNotEnrolled)
+	}
+
+	enrollment, err := auth.EnrollTwoFactor(user.ID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+	if !strings.Contains(enrollment.OTPAuthURL, "secret="+enrollment.Secret) {
+		t.Errorf("otpauth URL %q does not carry the secret", enrollment.OTPAuthURL)
+	}
+
+	code := totpCode(t, enrollment.Secret, time.Now())
+	if _, err := auth.ConfirmTwoFactor(user.ID, wrongCode(code)); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("confirming with a wrong code: error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	recoveryCodes, err := auth.ConfirmTwoFactor(user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+
+	enabled, err := auth.UserService.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled.TwoFactorEnabled {
+		t.Fatal("two-factor authentication is not enabled")
+	}
+
+	// The code used to confirm cannot be replayed
+	if err := auth.verifySecondFactor(enabled, code, ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("replaying the confirmation code: error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	if _, err := auth.EnrollTwoFactor(user.ID); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Errorf("enrolling again: error = %v, want %v", err, ErrTwoFactorAlreadyEnabled)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	auth, _ := newTestAuthService(t)
+	user := createTestUser(t, auth.UserService.DB, "alice")
+
+	enrollment, err := auth.EnrollTwoFactor(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes, err := auth.ConfirmTwoFactor(user.ID, totpCode(t, enrollment.Secret, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"unused code", recoveryCodes[0], nil},
+		{"used code", recoveryCodes[0], ErrInvalidTwoFactorCode},
+		{"uppercase without dash", strings.ToUpper(strings.ReplaceAll(recoveryCodes[1], "-", "")), nil},
+		{"unknown code", "00000-00000", ErrInvalidTwoFactorCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := auth.verifySecondFactor(user, "", tt.code); !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifySecondFactor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Regenerating the codes takes a valid TOTP code
+	if _, err := auth.RegenerateRecoveryCodes(user.ID, wrongCode(totpCode(t, enrollment.Secret, time.Now()))); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("regenerating with a wrong code: error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Business statuses admins can filter by
+const (
+	BusinessStatusListed    = "listed"
+	BusinessStatusTakenDown = "taken_down"
+)
+
+var (
+	// ErrAlreadyTakenDown is returned when taking down a business that is already down
+	ErrAlreadyTakenDown = errors.New("business already taken down")
+	// ErrNotTakenDown is returned when restoring a business that is listed
+	ErrNotTakenDown = errors.New("business is not taken down")
+)
+
+// BusinessSearchFilter narrows down the businesses returned to admins
+type BusinessSearchFilter struct {
+	Query  string // Matches name or description
+	Status string
+	Page   int
+	Limit  int
+}
+
+// SearchBusinesses returns businesses for admins, including taken down ones, with the total count
+func (s *BusinessService) SearchBusinesses(filter BusinessSearchFilter) ([]models.Business, int64, error) {
+	query := s.DB.Model(&models.Business{})
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("name ILIKE ? OR description ILIKE ?", like, like)
+	}
+	switch filter.Status {
+	case BusinessStatusListed:
+		query = query.Where("taken_down_at IS NULL")
+	case BusinessStatusTakenDown:
+		query = query.Where("taken_down_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var businesses []models.Business
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(filter.Limit).Find(&businesses).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return businesses, total, nil
+}
+
+// TakeDownBusiness hides the business from investors. Its members keep access to it.
+func (s *BusinessService) TakeDownBusiness(businessID uint, reason string) (*models.Business, error) {
+	return s.moderateBusiness(businessID, func(business *models.Business) (map[string]interface{}, error) {
+		if business.TakenDownAt != nil {
+			return nil, ErrAlreadyTakenDown
+		}
+		return map[string]interface{}{
+			"taken_down_at":   time.Now(),
+			"takedown_reason": reason,
+		}, nil
+	})
+}
+
+// RestoreBusiness lists a taken down business again
+func (s *BusinessService) RestoreBusiness(businessID uint) (*models.Business, error) {
+	return s.moderateBusiness(businessID, func(business *models.Business) (map[string]interface{}, error) {
+		if business.TakenDownAt == nil {
+			return nil, ErrNotTakenDown
+		}
+		return map[string]interface{}{
+			"taken_down_at":   nil,
+			"takedown_reason": "",
+		}, nil
+	})
+}
+
+func (s *BusinessService) moderateBusiness(businessID uint, change func(business *models.Business) (map[string]interface{}, error)) (*models.Business, error) {
+	var business models.Business
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&business, businessID).Error; err != nil {
+			return err
+		}
+		before := business
+
+		updates, err := change(&business)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&business).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&business, businessID).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, businessID, models.AuditEntityBusiness, businessID, models.AuditActionUpdate, &before, &business)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &business, nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"fmt"
+	"go-gin-backend/internal/models"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// completenessSections lists the profile sections in onboarding order with their weights,
+// which add up to 100
+var completenessSections = []struct {
+	key    string
+	title  string
+	weight float64
+}{
+	{models.CompletenessSectionBasicInfo, "Basic information", 15},
+	{models.CompletenessSectionTrademark, "Trademark and business legal documents", 25},
+	{models.CompletenessSectionProducts, "Products", 15},
+	{models.CompletenessSectionProductLegals, "Product permits", 20},
+	{models.CompletenessSectionFinancials, "Financial data from tax reports", 25},
+}
+
+// Legal types recognized as a trademark registration
+var trademarkLegalTypes = []string{"trademark", "merek", "merk", "haki", "hki"}
+
+// GetBusinessCompleteness loads the business and computes how complete its profile is
+func (s *BusinessService) GetBusinessCompleteness(businessID uint) (*models.BusinessCompleteness, error) {
+	businesses := make([]models.Business, 1)
+	business := &businesses[0]
+	if err := s.DB.
+		Preload("Legals", models.ActiveLegals).
+		Preload("Products.ProductLegals", models.ActiveProductLegals).
+		Preload("Financials", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		First(business, businessID).Error; err != nil {
+		return nil, err
+	}
+	if err := attachCustomFields(s.DB, businesses); err != nil {
+		return nil, err
+	}
+
+	completeness := ComputeBusinessCompleteness(business, time.Now())
+	return &completeness, nil
+}
+
+// ComputeBusinessCompleteness scores a business loaded with its legals, products with their
+// legals, and financials (latest first). Documents that expired before now do not count.
+func ComputeBusinessCompleteness(business *models.Business, now time.Time) models.BusinessCompleteness {
+	builders := map[string]func(*models.Business, time.Time) []models.CompletenessItem{
+		models.CompletenessSectionBasicInfo:     basicInfoItems,
+		models.CompletenessSectionTrademark:     trademarkItems,
+		models.CompletenessSectionProducts:      productItems,
+		models.CompletenessSectionProductLegals: productLegalItems,
+		models.CompletenessSectionFinancials:    financialItems,
+	}
+
+	result := models.BusinessCompleteness{TotalSections: len(completenessSections)}
+	sectionComplete := make(map[string]bool)
+	for _, def := range completenessSections {
+		items := builders[def.key](business, now)
+
+		done := 0
+		for i := range items {
+			if items[i].Complete {
+				done++
+			} else if result.NextStep == nil {
+				next := items[i]
+				result.NextStep = &next
+			}
+		}
+
+		section := models.CompletenessSection{
+			Key:      def.key,
+			Title:    def.title,
+			Weight:   def.weight,
+			Progress: roundPercent(float64(done) / float64(len(items)) * 100),
+			Complete: done == len(items),
+			Items:    items,
+		}
+		if section.Complete {
+			result.CompletedSections++
+		}
+		sectionComplete[def.key] = section.Complete
+		result.OverallProgress += def.weight * float64(done) / float64(len(items))
+		result.Sections = append(result.Sections, section)
+	}
+	result.OverallProgress = roundPercent(result.OverallProgress)
+
+	result.BasicInfo = sectionComplete[models.CompletenessSectionBasicInfo]
+	result.HasLegalDocs = countValidLegals(business.Legals, now) > 0
+	result.HasProducts = len(business.Products) > 0
+	result.HasProductLegals = sectionComplete[models.CompletenessSectionProductLegals]
+	result.HasFinancialData = len(business.Financials) > 0 || business.Financial != nil
+
+	return result
+}
+
+func basicInfoItems(business *models.Business, _ time.Time) []models.CompletenessItem {
+	items := []models.CompletenessItem{
+		requiredItem("name", "Business name", strings.TrimSpace(business.Name) != "", "The business has no name"),
+		requiredItem("type", "Business type", strings.TrimSpace(business.Type) != "", "The business type is not set"),
+		requiredItem("industry", "Industry", strings.TrimSpace(business.Industry) != "", "The industry is not set"),
+		requiredItem("description", "Description", strings.TrimSpace(business.Description) != "", "The business has no description"),
+		requiredItem("founded_at", "Founding date", business.FoundedAt != nil, "The founding date is not set"),
+	}
+
+	// Custom fields the owners marked as required are part of the basic information
+	for _, field := range business.CustomFields {
+		if !field.Required {
+			continue
+		}
+		items = append(items, requiredItem(
+			"custom_fields."+field.Key,
+			field.Label,
+			field.Value != nil,
+			fmt.Sprintf("%s is not filled in", field.Label),
+		))
+	}
+	return items
+}
+
+func trademarkItems(business *models.Business, now time.Time) []models.CompletenessItem {
+	var trademark *models.Legal
+	var expiredTrademark *models.Legal
+	for i := range business.Legals {
+		legal := &business.Legals[i]
+		if !isTrademark(legal.LegalType) {
+			continue
+		}
+		if isExpired(legal.ValidUntil, now) {
+			expiredTrademark = legal
+			continue
+		}
+		trademark = legal
+		break
+	}
+
+	missing := "No trademark registration has been uploaded"
+	if expiredTrademark != nil {
+		missing = fmt.Sprintf("The trademark registration expired on %s", expiredTrademark.ValidUntil.Format("2006-01-02"))
+	}
+	items := []models.CompletenessItem{
+		requiredItem("trademark", "Trademark registration", trademark != nil, missing),
+	}
+
+	// Other expired documents have to be renewed
+	for _, legal := range business.Legals {
+		if isTrademark(legal.LegalType) || !isExpired(legal.ValidUntil, now) {
+			continue
+		}
+		items = append(items, requiredItem(
+			fmt.Sprintf("legal_%d", legal.ID),
+			fmt.Sprintf("Renew %s", documentName(legal.LegalType, legal.FileName)),
+			false,
+			fmt.Sprintf("Expired on %s", legal.ValidUntil.Format("2006-01-02")),
+		))
+	}
+	return items
+}
+
+func productItems(business *models.Business, _ time.Time) []models.CompletenessItem {
+	return []models.CompletenessItem{
+		requiredItem("products", "At least one product", len(business.Products) > 0, "No products have been added"),
+	}
+}
+
+func productLegalItems(business *models.Business, now time.Time) []models.CompletenessItem {
+	if len(business.Products) == 0 {
+		return []models.CompletenessItem{
+			requiredItem("product_permits", "Product permits", false, "Add products before uploading their permits"),
+		}
+	}
+
+	items := make([]models.CompletenessItem, 0, len(business.Products))
+	for _, product := range business.Products {
+		valid := 0
+		for _, legal := range product.ProductLegals {
+			if !isExpired(legal.ValidUntil, now) {
+				valid++
+			}
+		}
+
+		missing := fmt.Sprintf("No permit has been uploaded for %s", product.Name)
+		if len(product.ProductLegals) > 0 {
+			missing = fmt.Sprintf("All permits for %s have expired", product.Name)
+		}
+		items = append(items, requiredItem(
+			fmt.Sprintf("product_%d", product.ID),
+			fmt.Sprintf("Permit for %s", product.Name),
+			valid > 0,
+			missing,
+		))
+	}
+	return items
+}
+
+func financialItems(business *models.Business, _ time.Time) []models.CompletenessItem {
+	latest := business.Financial
+	if len(business.Financials) > 0 {
+		latest = &business.Financials[0]
+	}
+	if latest == nil {
+		return []models.CompletenessItem{
+			requiredItem("financial_record", "Financial record", false, "No financial data has been entered"),
+		}
+	}
+
+	return []models.CompletenessItem{
+		requiredItem("financial_record", "Financial record", true, ""),
+		requiredItem("revenue", "Revenue", latest.Revenue > 0, "The latest financial record has no revenue"),
+		requiredItem("ebitda", "EBITDA", latest.EBITDA != 0, "The latest financial record has no EBITDA"),
+		requiredItem("assets", "Assets", latest.Assets > 0, "The latest financial record has no assets"),
+		requiredItem("tax_report", "Tax report", latest.ReportFileURL != "", "The tax report the figures are based on has not been uploaded"),
+	}
+}
+
+func requiredItem(key, label string, complete bool, missing string) models.CompletenessItem {
+	item := models.CompletenessItem{Key: key, Label: label, Complete: complete}
+	if !complete {
+		item.Missing = missing
+	}
+	return item
+}
+
+func isTrademark(legalType string) bool {
+	legalType = strings.ToLower(legalType)
+	for _, t := range trademarkLegalTypes {
+		if strings.Contains(legalType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isExpired(validUntil *time.Time, now time.Time) bool {
+	return validUntil != nil && validUntil.Before(now)
+}
+
+func countValidLegals(legals []models.Legal, now time.Time) int {
+	count := 0
+	for _, legal := range legals {
+		if !isExpired(legal.ValidUntil, now) {
+			count++
+		}
+	}
+	return count
+}
+
+func documentName(legalType, fileName string) string {
+	if legalType != "" {
+		return legalType
+	}
+	if fileName != "" {
+		return fileName
+	}
+	return "legal document"
+}
+
+func roundPercent(value float64) float64 {
+	return math.Round(value*10) / 10
+}
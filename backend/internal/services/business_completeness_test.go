@@ -0,0 +1,104 @@
+package services
+
+import (
+	"go-gin-backend/internal/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestComputeBusinessCompleteness(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	founded := now.AddDate(-3, 0, 0)
+	nextYear := now.AddDate(1, 0, 0)
+	lastYear := now.AddDate(-1, 0, 0)
+
+	basicInfo := func(b *models.Business) {
+		b.Name, b.Type, b.Industry, b.Description, b.FoundedAt = "Kopi Nusantara", "PT", "Food", "Coffee roastery", &founded
+	}
+	product := func(id uint, permits ...models.ProductLegal) models.Product {
+		return models.Product{Model: gorm.Model{ID: id}, Name: "Arabica", ProductLegals: permits}
+	}
+	permit := models.ProductLegal{LegalType: "BPOM", ValidUntil: &nextYear}
+	trademark := models.Legal{Model: gorm.Model{ID: 1}, LegalType: "Trademark", ValidUntil: &nextYear}
+	financial := models.Financial{Revenue: 1e9, EBITDA: 2e8, Assets: 5e8, ReportFileURL: "/uploads/financials/1/spt.pdf"}
+	complete := func(b *models.Business) {
+		basicInfo(b)
+		b.Legals = []models.Legal{trademark}
+		b.Products = []models.Product{product(1, permit)}
+		b.Financials = []models.Financial{financial}
+	}
+
+	tests := []struct {
+		name      string
+		build     func(*models.Business)
+		want      float64
+		completed int
+		nextStep  string // Key of the first missing item, empty when the profile is complete
+	}{
+		{name: "empty", build: func(*models.Business) {}, want: 0, nextStep: "name"},
+		{name: "part of the basic information", build: func(b *models.Business) {
+			b.Name, b.Type, b.Industry = "Kopi Nusantara", "PT", "Food"
+		}, want: 9, nextStep: "description"},
+		{name: "basic information", build: basicInfo, want: 15, completed: 1, nextStep: "trademark"},
+		{name: "required custom field", build: func(b *models.Business) {
+			basicInfo(b)
+			b.CustomFields = []models.CustomField{
+				{Key: "nib", Label: "NIB", Required: true},
+				{Key: "instagram", Label: "Instagram"},
+			}
+		}, want: 12.5, nextStep: "custom_fields.nib"},
+		{name: "products without permits", build: func(b *models.Business) {
+			basicInfo(b)
+			b.Products = []models.Product{product(1, permit), product(2), product(3)}
+		}, want: 36.7, completed: 2, nextStep: "trademark"},
+		{name: "financial record without figures", build: func(b *models.Business) {
+			b.Financials = []models.Financial{{Revenue: 1e9}}
+		}, want: 10, nextStep: "name"},
+		{name: "complete", build: complete, want: 100, completed: 5},
+		{name: "expired trademark", build: func(b *models.Business) {
+			complete(b)
+			b.Legals[0].ValidUntil = &lastYear
+		}, want: 75, completed: 4, nextStep: "trademark"},
+		{name: "other expired document", build: func(b *models.Business) {
+			complete(b)
+			b.Legals = append(b.Legals, models.Legal{Model: gorm.Model{ID: 2}, LegalType: "NIB", ValidUntil: &lastYear})
+		}, want: 87.5, completed: 4, nextStep: "legal_2"},
+		{name: "expired product permit", build: func(b *models.Business) {
+			complete(b)
+			b.Products[0].ProductLegals[0].ValidUntil = &lastYear
+		}, want: 80, completed: 4, nextStep: "product_1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			business := &models.Business{}
+			tt.build(business)
+			got := ComputeBusinessCompleteness(business, now)
+
+			if got.OverallProgress != tt.want || got.CompletedSections != tt.completed || got.TotalSections != 5 {
+				t.Errorf("progress = %v with %d/%d sections complete, want %v with %d/5",
+					got.OverallProgress, got.CompletedSections, got.TotalSections, tt.want, tt.completed)
+			}
+			switch {
+			case tt.nextStep == "" && got.NextStep != nil:
+				t.Errorf("next step = %+v, want none", got.NextStep)
+			case tt.nextStep != "" && (got.NextStep == nil || got.NextStep.Key != tt.nextStep):
+				t.Errorf("next step = %+v, want %s", got.NextStep, tt.nextStep)
+			case got.NextStep != nil && got.NextStep.Missing == "":
+				t.Errorf("next step %s does not say what is missing", got.NextStep.Key)
+			}
+		})
+	}
+}
+
+func TestCompletenessWeights(t *testing.T) {
+	total := 0.0
+	for _, section := range completenessSections {
+		total += section.weight
+	}
+	if total != 100 {
+		t.Errorf("section weights add up to %v, want 100", total)
+	}
+}
@@ -0,0 +1,522 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// What a spreadsheet can be imported as
+const (
+	ImportKindProducts    = "products"
+	ImportKindProjections = "projections"
+	ImportKindFinancials  = "financials"
+)
+
+const (
+	// MaxImportRows is the most data rows one import can hold
+	MaxImportRows = 5000
+	// Years of historical data the projections hold, as when they are saved from the form
+	importProjectionYears = 5
+)
+
+const (
+	importText   = "text"
+	importNumber = "number"
+	importYear   = "year"
+)
+
+// ImportColumn is a field an import fills, with the header names it is recognized by
+type ImportColumn struct {
+	Field    string   `json:"field"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Aliases  []string `json:"aliases"`
+}
+
+var importColumns = map[string][]ImportColumn{
+	ImportKindProducts: {
+		{Field: "name", Type: importText, Required: true, Aliases: []string{"product", "product name", "nama", "nama produk", "produk", "item", "sku name"}},
+		{Field: "description", Type: importText, Aliases: []string{"deskripsi", "keterangan produk"}},
+		{Field: "category", Type: importText, Aliases: []string{"kategori", "product category", "type", "jenis"}},
+		{Field: "sku", Type: importText, Aliases: []string{"sku code", "kode", "kode produk", "product code"}},
+		{Field: "unit_price", Type: importNumber, Aliases: []string{"price", "unit price", "harga", "harga satuan"}},
+		{Field: "currency", Type: importText, Aliases: []string{"mata uang"}},
+		{Field: "production_capacity", Type: importNumber, Aliases: []string{"capacity", "production capacity", "kapasitas", "kapasitas produksi"}},
+		{Field: "capacity_unit", Type: importText, Aliases: []string{"unit", "satuan", "capacity unit"}},
+	},
+	ImportKindProjections: {
+		{Field: "year", Type: importYear, Required: true, Aliases: []string{"tahun", "fiscal year"}},
+		{Field: "revenue", Type: importNumber, Required: true, Aliases: []string{"pendapatan", "sales", "omzet", "penjualan"}},
+		{Field: "expenses", Type: importNumber, Aliases: []string{"expense", "costs", "beban", "biaya", "pengeluaran"}},
+		{Field: "net_income", Type: importNumber, Aliases: []string{"net income", "netincome", "profit", "laba", "laba bersih"}},
+		{Field: "cash_flow", Type: importNumber, Aliases: []string{"cash flow", "cashflow", "arus kas"}},
+	},
+	ImportKindFinancials: {
+		{Field: "revenue", Type: importNumber, Required: true, Aliases: []string{"pendapatan", "sales", "omzet", "penjualan"}},
+		{Field: "ebitda", Type: importNumber},
+		{Field: "assets", Type: importNumber, Aliases: []string{"asset", "total assets", "aset", "aktiva"}},
+		{Field: "liabilities", Type: importNumber, Aliases: []string{"liability", "total liabilities", "kewajiban", "liabilitas", "utang"}},
+		{Field: "equity", Type: importNumber, Aliases: []string{"total equity", "ekuitas", "modal"}},
+		{Field: "notes", Type: importText, Aliases: []string{"note", "catatan", "keterangan"}},
+	},
+}
+
+// ImportRowError is a problem with one cell or row of the sheet. Rows are numbered as the
+// spreadsheet shows them, with the header on row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport describes what an import found and, unless it was a dry run, what it saved
+type ImportReport struct {
+	Kind      string            `json:"kind"`
+	DryRun    bool              `json:"dry_run"`
+	Committed bool              `json:"committed"`
+	Mapping   map[string]string `json:"mapping"`           // Field to the sheet header it was read from
+	Ignored   []string          `json:"ignored,omitempty"` // Headers not mapped to any field
+	TotalRows int               `json:"total_rows"`
+	ValidRows int               `json:"valid_rows"`
+	Created   int               `json:"created"`
+	Errors    []ImportRowError  `json:"errors"`
+}
+
+// ImportColumns lists the fields a kind of import fills, or nil for unknown kinds
+func ImportColumns(kind string) []ImportColumn {
+	return importColumns[kind]
+}
+
+// importRow is a parsed data row with its spreadsheet row number
+type importRow struct {
+	number int
+	text   map[string]string
+	values map[string]float64
+}
+
+// ImportBusinessData validates the sheet rows, header first, and saves them in one
+// transaction unless dryRun is set or a row is invalid. mapping assigns sheet headers to
+// fields; fields left out are matched by name. The report lists every row error.
+func (s *BusinessService) ImportBusinessData(businessID uint, kind string, rows [][]string, mapping map[string]string, dryRun bool) (*ImportReport, error) {
+	columns, ok := importColumns[kind]
+	if !ok {
+		return nil, &ValidationError{Field: "kind", Message: "kind must be products, projections or financials"}
+	}
+
+	report := &ImportReport{Kind: kind, DryRun: dryRun, Errors: []ImportRowError{}}
+	if len(rows) == 0 {
+		return nil, &ValidationError{Field: "file", Message: "the sheet is empty"}
+	}
+
+	header := rows[0]
+	indexes, err := resolveImportMapping(columns, header, mapping)
+	if err != nil {
+		return nil, err
+	}
+	report.Mapping = make(map[string]string, len(indexes))
+	mapped := make(map[int]bool, len(indexes))
+	for field, idx := range indexes {
+		report.Mapping[field] = strings.TrimSpace(header[idx])
+		mapped[idx] = true
+	}
+	for i, h := range header {
+		if !mapped[i] && strings.TrimSpace(h) != "" {
+			report.Ignored = append(report.Ignored, strings.TrimSpace(h))
+		}
+	}
+
+	var parsed []importRow
+	for i, cells := range rows[1:] {
+		if blankRow(cells) {
+			continue
+		}
+		report.TotalRows++
+		if report.TotalRows > MaxImportRows {
+			return nil, &ValidationError{Field: "file", Message: fmt.Sprintf("an import can hold at most %d rows", MaxImportRows)}
+		}
+
+		row := importRow{number: i + 2, text: map[string]string{}, values: map[string]float64{}}
+		valid := true
+		for _, col := range columns {
+			idx, ok := indexes[col.Field]
+			cell := ""
+			if ok && idx < len(cells) {
+				cell = strings.TrimSpace(cells[idx])
+			}
+
+			if cell == "" {
+				if col.Required {
+					report.Errors = append(report.Errors, ImportRowError{Row: row.number, Column: report.Mapping[col.Field], Field: col.Field, Message: fmt.Sprintf("%s is required", col.Field)})
+					valid = false
+				}
+				continue
+			}
+
+			switch col.Type {
+			case importText:
+				row.text[col.Field] = cell
+			case importNumber, importYear:
+				value, err := parseImportNumber(cell)
+				if err == nil && col.Type == importYear && (value != math.Trunc(value) || value < 1900 || value > 9999) {
+					err = fmt.Errorf("%q is not a year", cell)
+				}
+				if err != nil {
+					report.Errors = append(report.Errors, ImportRowError{Row: row.number, Column: report.Mapping[col.Field], Field: col.Field, Message: err.Error()})
+					valid = false
+					continue
+				}
+				row.values[col.Field] = value
+			}
+		}
+		if valid {
+			parsed = append(parsed, row)
+		}
+	}
+
+	switch kind {
+	case ImportKindProducts:
+		err = s.validateProductImport(businessID, parsed, report)
+	case ImportKindProjections:
+		validateProjectionImport(parsed, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	invalidRows := make(map[int]bool)
+	for _, e := range report.Errors {
+		if e.Row > 1 {
+			invalidRows[e.Row] = true
+		}
+	}
+	report.ValidRows = report.TotalRows - len(invalidRows)
+
+	if report.TotalRows == 0 {
+		report.Errors = append(report.Errors, ImportRowError{Row: 1, Message: "the sheet has no data rows"})
+	}
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	// All or nothing: a failure on any row leaves the business untouched
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch kind {
+		case ImportKindProducts:
+			report.Created, err = s.importProducts(tx, businessID, parsed)
+		case ImportKindProjections:
+			report.Created, err = s.importProjections(tx, businessID, parsed)
+		case ImportKindFinancials:
+			report.Created, err = s.importFinancials(tx, businessID, parsed)
+		}
+		if err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Committed = true
+	return report, nil
+}
+
+// validateProductImport applies the product form's rules to every row and rejects names and
+// SKUs used twice in the sheet or already on the business
+func (s *BusinessService) validateProductImport(businessID uint, rows []importRow, report *ImportReport) error {
+	var existing []models.Product
+	if err := s.DB.Select("name", "sku").Where("business_id = ?", businessID).Find(&existing).Error; err != nil {
+		return err
+	}
+	takenNames := make(map[string]bool, len(existing))
+	takenSKUs := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		takenNames[strings.ToLower(strings.TrimSpace(p.Name))] = true
+		if p.SKU != "" {
+			takenSKUs[strings.ToLower(p.SKU)] = true
+		}
+	}
+
+	rowError := func(row importRow, field, message string) {
+		report.Errors = append(report.Errors, ImportRowError{Row: row.number, Column: report.Mapping[field], Field: field, Message: message})
+	}
+
+	seenNames := make(map[string]int)
+	seenSKUs := make(map[string]int)
+	for _, row := range rows {
+		product := importedProduct(businessID, row)
+		if err := normalizeProduct(&product); err != nil {
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				return err
+			}
+			rowError(row, validationErr.Field, validationErr.Message)
+			continue
+		}
+
+		name := strings.ToLower(product.Name)
+		switch {
+		case takenNames[name]:
+			rowError(row, "name", fmt.Sprintf("the business already has a product named %q", product.Name))
+		case seenNames[name] != 0:
+			rowError(row, "name", fmt.Sprintf("%q is also on row %d", product.Name, seenNames[name]))
+		default:
+			seenNames[name] = row.number
+		}
+
+		if product.SKU == "" {
+			continue
+		}
+		sku := strings.ToLower(product.SKU)
+		switch {
+		case takenSKUs[sku]:
+			rowError(row, "sku", fmt.Sprintf("another product already uses SKU %q", product.SKU))
+		case seenSKUs[sku] != 0:
+			rowError(row, "sku", fmt.Sprintf("SKU %q is also on row %d", product.SKU, seenSKUs[sku]))
+		default:
+			seenSKUs[sku] = row.number
+		}
+	}
+	return nil
+}
+
+// importedProduct builds the product a sheet row describes
+func importedProduct(businessID uint, row importRow) models.Product {
+	product := models.Product{
+		BusinessID:   businessID,
+		Name:         row.text["name"],
+		Description:  row.text["description"],
+		Category:     row.text["category"],
+		SKU:          row.text["sku"],
+		Currency:     row.text["currency"],
+		CapacityUnit: row.text["capacity_unit"],
+	}
+	if price, ok := row.values["unit_price"]; ok {
+		product.UnitPrice = &price
+	}
+	if capacity, ok := row.values["production_capacity"]; ok {
+		product.ProductionCapacity = &capacity
+	}
+	return product
+}
+
+// validateProjectionImport applies the rules of the projections form: five distinct past years
+func validateProjectionImport(rows []importRow, report *ImportReport) {
+	currentYear := time.Now().Year()
+	seen := make(map[int]int)
+	for _, row := range rows {
+		year := int(row.values["year"])
+		switch {
+		case year >= currentYear:
+			report.Errors = append(report.Errors, ImportRowError{Row: row.number, Column: report.Mapping["year"], Field: "year", Message: "historical data must be from past years only"})
+		case seen[year] != 0:
+			report.Errors = append(report.Errors, ImportRowError{Row: row.number, Column: report.Mapping["year"], Field: "year", Message: fmt.Sprintf("%d is also on row %d", year, seen[year])})
+		default:
+			seen[year] = row.number
+		}
+	}
+	if report.TotalRows > 0 && report.TotalRows != importProjectionYears {
+		report.Errors = append(report.Errors, ImportRowError{Row: 1, Message: fmt.Sprintf("exactly %d years of historical data are required, the sheet has %d", importProjectionYears, report.TotalRows)})
+	}
+}
+
+func (s *BusinessService) importProducts(tx *gorm.DB, businessID uint, rows []importRow) (int, error) {
+	products := make([]models.Product, 0, len(rows))
+	for _, row := range rows {
+		product := importedProduct(businessID, row)
+		if err := normalizeProduct(&product); err != nil {
+			return 0, err
+		}
+		products = append(products, product)
+	}
+	if err := tx.CreateInBatches(&products, 500).Error; err != nil {
+		return 0, err
+	}
+	for i := range products {
+		if err := s.audit(tx, businessID, models.AuditEntityProduct, products[i].ID, models.AuditActionCreate, nil, &products[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(products), nil
+}
+
+// importProjections replaces the business's projections, like saving the projections form
+func (s *BusinessService) importProjections(tx *gorm.DB, businessID uint, rows []importRow) (int, error) {
+	var before, after projectionsAudit
+	if err := tx.Where("business_id = ?", businessID).Order("year ASC").Find(&before.Projections).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("business_id = ?", businessID).Delete(&models.HistoricalProjection{}).Error; err != nil {
+		return 0, err
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].values["year"] < rows[j].values["year"] })
+	for _, row := range rows {
+		projection := models.HistoricalProjection{
+			BusinessID: businessID,
+			Year:       int(row.values["year"]),
+			Revenue:    row.values["revenue"],
+			Expenses:   row.values["expenses"],
+			NetIncome:  row.values["net_income"],
+			CashFlow:   row.values["cash_flow"],
+		}
+		if err := tx.Create(&projection).Error; err != nil {
+			return 0, err
+		}
+		after.Projections = append(after.Projections, projection)
+	}
+
+	action := models.AuditActionUpdate
+	if len(before.Projections) == 0 {
+		action = models.AuditActionCreate
+	}
+	if err := s.audit(tx, businessID, models.AuditEntityProjections, businessID, action, &before, &after); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// importFinancials adds one financial record per row, in sheet order so the last row becomes
+// the latest record
+func (s *BusinessService) importFinancials(tx *gorm.DB, businessID uint, rows []importRow) (int, error) {
+	for _, row := range rows {
+		financial := models.Financial{
+			BusinessID:  businessID,
+			Revenue:     row.values["revenue"],
+			EBITDA:      row.values["ebitda"],
+			Assets:      row.values["assets"],
+			Liabilities: row.values["liabilities"],
+			Equity:      row.values["equity"],
+			Notes:       row.text["notes"],
+		}
+		if err := tx.Create(&financial).Error; err != nil {
+			return 0, err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityFinancial, financial.ID, models.AuditActionCreate, nil, &financial); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), nil
+}
+
+// resolveImportMapping finds the header column of every field. Explicit mappings must name
+// an existing header; other fields are matched on their name or an alias.
+func resolveImportMapping(columns []ImportColumn, header []string, mapping map[string]string) (map[string]int, error) {
+	byName := make(map[string]int, len(header))
+	for i, h := range header {
+		key := normalizeImportHeader(h)
+		if _, dup := byName[key]; key != "" && !dup {
+			byName[key] = i
+		}
+	}
+
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col.Field] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, &ValidationError{Field: "mapping", Message: fmt.Sprintf("unknown field %q in the column mapping", field)}
+		}
+	}
+
+	indexes := make(map[string]int, len(columns))
+	for _, col := range columns {
+		if target, ok := mapping[col.Field]; ok {
+			if strings.TrimSpace(target) == "" {
+				continue // Explicitly not imported
+			}
+			idx, found := byName[normalizeImportHeader(target)]
+			if !found {
+				return nil, &ValidationError{Field: "mapping", Message: fmt.Sprintf("column %q mapped to %s is not in the sheet", target, col.Field)}
+			}
+			indexes[col.Field] = idx
+			continue
+		}
+
+		for _, name := range append([]string{col.Field}, col.Aliases...) {
+			if idx, found := byName[normalizeImportHeader(name)]; found {
+				indexes[col.Field] = idx
+				break
+			}
+		}
+	}
+
+	for _, col := range columns {
+		if _, ok := indexes[col.Field]; col.Required && !ok {
+			return nil, &ValidationError{Field: "mapping", Message: fmt.Sprintf("no column found for %s, map it to one of the sheet's headers", col.Field)}
+		}
+	}
+	return indexes, nil
+}
+
+var importHeaderUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizeImportHeader(h string) string {
+	return strings.Trim(importHeaderUnsafe.ReplaceAllString(strings.ToLower(h), "_"), "_")
+}
+
+var (
+	// Amounts written with dots for thousands and a comma for decimals, e.g. 1.250.000,50
+	dottedThousands = regexp.MustCompile(`^-?\d{1,3}(\.\d{3})+(,\d+)?$`)
+	// Amounts written with commas for thousands and a dot for decimals, e.g. 1,250,000.50
+	commaThousands = regexp.MustCompile(`^-?\d{1,3}(,\d{3})+(\.\d+)?$`)
+	// A single comma before one or two digits separates decimals, e.g. 12,5
+	decimalComma = regexp.MustCompile(`^-?\d+,\d{1,2}$`)
+)
+
+// parseImportNumber reads amounts as people type them in spreadsheets: with a currency
+// prefix, thousands separators or in parentheses when negative. Commas that could be read
+// either way are reported rather than guessed.
+func parseImportNumber(cell string) (float64, error) {
+	v := strings.TrimSpace(cell)
+	negative := strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")")
+	if negative {
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "("), ")")
+	}
+	for _, prefix := range []string{"Rp.", "Rp", "IDR", "$"} {
+		v = strings.TrimPrefix(v, prefix)
+	}
+	v = strings.ReplaceAll(strings.TrimSpace(v), " ", "")
+
+	switch {
+	case dottedThousands.MatchString(v):
+		v = strings.ReplaceAll(strings.ReplaceAll(v, ".", ""), ",", ".")
+	case decimalComma.MatchString(v):
+		v = strings.ReplaceAll(v, ",", ".")
+	case commaThousands.MatchString(v):
+		v = strings.ReplaceAll(v, ",", "")
+	case strings.Contains(v, ","):
+		return 0, fmt.Errorf("%q is ambiguous, write it without thousands separators", cell)
+	}
+
+	value, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%q is not a number", cell)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func blankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
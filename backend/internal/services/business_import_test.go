@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseImportNumber(t *testing.T) {
+	tests := []struct {
+		cell    string
+		want    float64
+		wantErr bool
+	}{
+		{cell: "1250000", want: 1250000},
+		{cell: " 1250000.5 ", want: 1250000.5},
+		{cell: "1.250.000", want: 1250000},
+		{cell: "1.250.000,50", want: 1250000.5},
+		{cell: "1,250,000", want: 1250000},
+		{cell: "1,250,000.50", want: 1250000.5},
+		{cell: "1,250", want: 1250},
+		{cell: "12,5", want: 12.5},
+		{cell: "1,5", want: 1.5},
+		{cell: "0,75", want: 0.75},
+		{cell: "-12,5", want: -12.5},
+		{cell: "Rp 1.250.000", want: 1250000},
+		{cell: "Rp. 12,5", want: 12.5},
+		{cell: "IDR 1,250,000", want: 1250000},
+		{cell: "$1,250.99", want: 1250.99},
+		{cell: "(1.250.000)", want: -1250000},
+		{cell: "-1250", want: -1250},
+		{cell: "1 250 000", want: 1250000},
+		{cell: "1,2345", wantErr: true},
+		{cell: "12,50,000", wantErr: true},
+		{cell: "1,250,00", wantErr: true},
+		{cell: "twelve", wantErr: true},
+		{cell: "NaN", wantErr: true},
+		{cell: "Inf", wantErr: true},
+		{cell: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseImportNumber(tt.cell)
+		switch {
+		case tt.wantErr && err == nil:
+			t.Errorf("parseImportNumber(%q) = %v, want an error", tt.cell, got)
+		case !tt.wantErr && (err != nil || got != tt.want):
+			t.Errorf("parseImportNumber(%q) = %v, %v; want %v", tt.cell, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveImportMapping(t *testing.T) {
+	columns := importColumns[ImportKindProducts]
+
+	tests := []struct {
+		name    string
+		header  []string
+		mapping map[string]string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:   "field names",
+			header: []string{"Name", "SKU", "Unit Price"},
+			want:   map[string]int{"name": 0, "sku": 1, "unit_price": 2},
+		},
+		{
+			name:   "aliases",
+			header: []string{"Harga Satuan", "Nama Produk", "Kapasitas"},
+			want:   map[string]int{"unit_price": 0, "name": 1, "production_capacity": 2},
+		},
+		{
+			name:   "field name before aliases",
+			header: []string{"Produk", "Name"},
+			want:   map[string]int{"name": 1},
+		},
+		{
+			name:    "explicit mapping",
+			header:  []string{"Item", "Label", "Harga"},
+			mapping: map[string]string{"name": "label"},
+			want:    map[string]int{"name": 1, "unit_price": 2},
+		},
+		{
+			name:    "column left out",
+			header:  []string{"Name", "Price"},
+			mapping: map[string]string{"unit_price": ""},
+			want:    map[string]int{"name": 0},
+		},
+		{
+			name:    "mapped column missing",
+			header:  []string{"Name"},
+			mapping: map[string]string{"unit_price": "Harga"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			header:  []string{"Name"},
+			mapping: map[string]string{"colour": "Name"},
+			wantErr: true,
+		},
+		{
+			name:    "required column missing",
+			header:  []string{"Price", "SKU"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveImportMapping(columns, tt.header, tt.mapping)
+			var validationErr *ValidationError
+			if tt.wantErr {
+				if !errors.As(err, &validationErr) || validationErr.Field != "mapping" {
+					t.Fatalf("resolveImportMapping() error = %v, want a mapping error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveImportMapping() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("resolveImportMapping() = %v, want %v", got, tt.want)
+			}
+			for field, idx := range tt.want {
+				if got[field] != idx {
+					t.Errorf("%s mapped to column %d, want %d", field, got[field], idx)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,537 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxLegalIssuerLength = 255
+	maxLegalChainLength  = 100 // Renewals followed when walking a document's history
+)
+
+// ErrAlreadySuperseded is returned when renewing a document that another one already renews
+var ErrAlreadySuperseded = errors.New("document has already been superseded")
+
+// LegalPatch holds the legal document fields to change. Nil fields are left as they are.
+// A new file replaces the current one, which is kept as an earlier version. Supersedes links
+// the document to the earlier one it renews.
+type LegalPatch struct {
+	LegalType       *string
+	IssuedBy        *string
+	Notes           *string
+	IssuedAt        *time.Time
+	ClearIssuedAt   bool
+	ValidUntil      *time.Time
+	ClearValidUntil bool
+	Supersedes      *uint
+	ClearSupersedes bool
+	File            multipart.File
+	FileHeader      *multipart.FileHeader
+}
+
+func (p *LegalPatch) empty() bool {
+	return p.LegalType == nil && p.IssuedBy == nil && p.Notes == nil &&
+		p.IssuedAt == nil && !p.ClearIssuedAt && p.ValidUntil == nil && !p.ClearValidUntil &&
+		p.Supersedes == nil && !p.ClearSupersedes && p.File == nil
+}
+
+// changes validates the patch and returns the metadata columns it writes
+func (p *LegalPatch) changes() (map[string]interface{}, error) {
+	changes := map[string]interface{}{}
+
+	texts := []struct {
+		field  string
+		value  *string
+		maxLen int
+	}{
+		{"legal_type", p.LegalType, maxBusinessLabelLength},
+		{"issued_by", p.IssuedBy, maxLegalIssuerLength},
+		{"notes", p.Notes, maxBusinessDescriptionLength},
+	}
+	for _, t := range texts {
+		if t.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*t.value)
+		if len(value) > t.maxLen {
+			return nil, &ValidationError{Field: t.field, Message: fmt.Sprintf("%s cannot be longer than %d characters", t.field, t.maxLen)}
+		}
+		changes[t.field] = value
+	}
+	if value, ok := changes["legal_type"]; ok && value == "" {
+		return nil, &ValidationError{Field: "legal_type", Message: "legal_type cannot be empty"}
+	}
+
+	switch {
+	case p.ClearIssuedAt:
+		changes["issued_at"] = nil
+	case p.IssuedAt != nil:
+		changes["issued_at"] = *p.IssuedAt
+	}
+	switch {
+	case p.ClearValidUntil:
+		changes["valid_until"] = nil
+	case p.ValidUntil != nil:
+		changes["valid_until"] = *p.ValidUntil
+	}
+	switch {
+	case p.ClearSupersedes:
+		changes["supersedes_id"] = nil
+	case p.Supersedes != nil:
+		changes["supersedes_id"] = *p.Supersedes
+	}
+	if p.IssuedAt != nil && p.ValidUntil != nil && p.ValidUntil.Before(*p.IssuedAt) {
+		return nil, &ValidationError{Field: "valid_until", Message: "valid_until cannot be before issued_at"}
+	}
+	return changes, nil
+}
+
+// UpdateBusinessLegal changes a business legal document and, when the patch has a file,
+// replaces its file while keeping the previous one
+func (s *BusinessService) UpdateBusinessLegal(businessID, legalID uint, patch LegalPatch) (*models.Legal, error) {
+	var before models.Legal
+	if err := s.DB.Where("id = ? AND business_id = ?", legalID, businessID).First(&before).Error; err != nil {
+		return nil, err
+	}
+
+	var after models.Legal
+	err := s.updateLegalDocument(businessID, models.AuditEntityLegal, legalID, fmt.Sprintf("legal/business/%d", businessID),
+		before.FileName, before.FileURL, patch,
+		func(tx *gorm.DB, changes map[string]interface{}) error {
+			if patch.Supersedes != nil {
+				if err := checkSupersedes(tx, "legals", &models.Legal{}, legalID, *patch.Supersedes, "business_id = ?", businessID); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&models.Legal{}).Where("id = ?", legalID).Updates(changes).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&after, legalID).Error; err != nil {
+				return err
+			}
+			return s.audit(tx, businessID, models.AuditEntityLegal, legalID, models.AuditActionUpdate, &before, &after)
+		})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// UpdateProductLegal changes a product legal document and, when the patch has a file,
+// replaces its file while keeping the previous one
+func (s *BusinessService) UpdateProductLegal(businessID, productID, legalID uint, patch LegalPatch) (*models.ProductLegal, error) {
+	before, err := s.findProductLegal(s.DB, businessID, productID, legalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var after models.ProductLegal
+	err = s.updateLegalDocument(businessID, models.AuditEntityProductLegal, legalID, fmt.Sprintf("legal/products/%d/%d", businessID, productID),
+		before.FileName, before.FileURL, patch,
+		func(tx *gorm.DB, changes map[string]interface{}) error {
+			if patch.Supersedes != nil {
+				if err := checkSupersedes(tx, "product_legals", &models.ProductLegal{}, legalID, *patch.Supersedes, "product_id = ?", productID); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&models.ProductLegal{}).Where("id = ?", legalID).Updates(changes).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&after, legalID).Error; err != nil {
+				return err
+			}
+			return s.audit(tx, businessID, models.AuditEntityProductLegal, legalID, models.AuditActionUpdate, before, &after)
+		})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// updateLegalDocument runs the shared part of a legal document update: the new file goes
+// through the upload pipeline before the transaction, the replaced file is recorded as a
+// version and the upload is removed again if the update fails
+func (s *BusinessService) updateLegalDocument(businessID uint, entityType string, entityID uint, dir, fileName, fileURL string, patch LegalPatch, update func(tx *gorm.DB, changes map[string]interface{}) error) error {
+	if patch.empty() {
+		return ErrEmptyPatch
+	}
+	changes, err := patch.changes()
+	if err != nil {
+		return err
+	}
+
+	var upload *storedUpload
+	if patch.File != nil {
+		upload, err = storeUpload(s.Storage, s.Scanner, dir, documentUploadPolicy(), patch.File, patch.FileHeader)
+		if err != nil {
+			return err
+		}
+		changes["file_name"] = upload.FileName
+		changes["file_url"] = upload.URL
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := update(tx, changes); err != nil {
+			return err
+		}
+		if upload != nil {
+			if err := s.keepReplacedFile(tx, businessID, entityType, entityID, fileName, fileURL, upload.URL); err != nil {
+				return err
+			}
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil && upload != nil {
+		removeUploads(s.Storage, []string{upload.Key})
+	}
+	return err
+}
+
+// keepReplacedFile records the file a document no longer points at as one of its versions.
+// A version that becomes current again, as when a snapshot is restored, is no longer listed.
+func (s *BusinessService) keepReplacedFile(tx *gorm.DB, businessID uint, entityType string, entityID uint, fileName, fileURL, currentURL string) error {
+	if err := tx.Where("entity_type = ? AND entity_id = ? AND file_url = ?", entityType, entityID, currentURL).
+		Delete(&models.LegalFileVersion{}).Error; err != nil {
+		return err
+	}
+	if fileURL == "" || fileURL == currentURL {
+		return nil
+	}
+	return tx.Create(&models.LegalFileVersion{
+		BusinessID:   businessID,
+		EntityType:   entityType,
+		EntityID:     entityID,
+		FileName:     fileName,
+		FileURL:      fileURL,
+		ReplacedByID: s.Actor.UserID,
+	}).Error
+}
+
+// DeleteBusinessLegal moves a business legal document to the trash, or with permanent
+// deletes it right away together with its stored files
+func (s *BusinessService) DeleteBusinessLegal(businessID, legalID uint, permanent bool) error {
+	var files []string
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx
+		if permanent {
+			// Documents already in the trash can be deleted for good as well
+			query = tx.Unscoped()
+		}
+		var before models.Legal
+		if err := query.Where("id = ? AND business_id = ?", legalID, businessID).First(&before).Error; err != nil {
+			return err
+		}
+
+		if permanent {
+			removed, err := purgeRecords(tx, nil, nil, []uint{legalID}, nil)
+			if err != nil {
+				return err
+			}
+			files = removed
+		} else if err := softDeleteAt(tx, &models.Legal{}, trashTimestamp(), "id = ?", legalID); err != nil {
+			return err
+		}
+
+		if err := s.audit(tx, businessID, models.AuditEntityLegal, legalID, models.AuditActionDelete, &before, nil); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeUploads(s.Storage, files)
+	return nil
+}
+
+// DeleteProductLegal moves a product legal document to the trash, or with permanent
+// deletes it right away together with its stored files
+func (s *BusinessService) DeleteProductLegal(businessID, productID, legalID uint, permanent bool) error {
+	var files []string
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx
+		if permanent {
+			query = tx.Unscoped()
+		}
+		before, err := s.findProductLegal(query, businessID, productID, legalID)
+		if err != nil {
+			return err
+		}
+
+		if permanent {
+			removed, err := purgeRecords(tx, nil, []uint{legalID}, nil, nil)
+			if err != nil {
+				return err
+			}
+			files = removed
+		} else if err := softDeleteAt(tx, &models.ProductLegal{}, trashTimestamp(), "id = ?", legalID); err != nil {
+			return err
+		}
+
+		if err := s.audit(tx, businessID, models.AuditEntityProductLegal, legalID, models.AuditActionDelete, before, nil); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeUploads(s.Storage, files)
+	return nil
+}
+
+// GetBusinessLegalVersions returns the files a business legal document had before, newest first
+func (s *BusinessService) GetBusinessLegalVersions(businessID, legalID uint) ([]models.LegalFileVersion, error) {
+	if err := s.DB.Where("id = ? AND business_id = ?", legalID, businessID).First(&models.Legal{}).Error; err != nil {
+		return nil, err
+	}
+	return s.legalFileVersions(models.AuditEntityLegal, legalID)
+}
+
+// GetProductLegalVersions returns the files a product legal document had before, newest first
+func (s *BusinessService) GetProductLegalVersions(businessID, productID, legalID uint) ([]models.LegalFileVersion, error) {
+	if _, err := s.findProductLegal(s.DB, businessID, productID, legalID); err != nil {
+		return nil, err
+	}
+	return s.legalFileVersions(models.AuditEntityProductLegal, legalID)
+}
+
+func (s *BusinessService) legalFileVersions(entityType string, entityID uint) ([]models.LegalFileVersion, error) {
+	versions := []models.LegalFileVersion{}
+	if err := s.DB.Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("replaced_at DESC, id DESC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetBusinessLegalHistory returns the renewal chain of a business legal document, oldest
+// first. The last document is the active one.
+func (s *BusinessService) GetBusinessLegalHistory(businessID, legalID uint) ([]models.Legal, error) {
+	if err := s.DB.Where("id = ? AND business_id = ?", legalID, businessID).First(&models.Legal{}).Error; err != nil {
+		return nil, err
+	}
+	ids, err := documentChain(s.DB, "legals", legalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []models.Legal
+	if err := s.DB.Where("id IN ? AND business_id = ?", ids, businessID).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Legal, len(found))
+	for _, l := range found {
+		byID[l.ID] = l
+	}
+	legals := make([]models.Legal, 0, len(found))
+	for _, id := range ids {
+		if l, ok := byID[id]; ok {
+			legals = append(legals, l)
+		}
+	}
+
+	if err := attachLegalSuccessors(s.DB, legals); err != nil {
+		return nil, err
+	}
+	if err := attachLegalCustomFields(s.DB, businessID, legals); err != nil {
+		return nil, err
+	}
+	return legals, nil
+}
+
+// GetProductLegalHistory returns the renewal chain of a product legal document, oldest
+// first. The last document is the active one.
+func (s *BusinessService) GetProductLegalHistory(businessID, productID, legalID uint) ([]models.ProductLegal, error) {
+	if _, err := s.findProductLegal(s.DB, businessID, productID, legalID); err != nil {
+		return nil, err
+	}
+	ids, err := documentChain(s.DB, "product_legals", legalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []models.ProductLegal
+	if err := s.DB.Where("id IN ? AND product_id = ?", ids, productID).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.ProductLegal, len(found))
+	for _, l := range found {
+		byID[l.ID] = l
+	}
+	legals := make([]models.ProductLegal, 0, len(found))
+	for _, id := range ids {
+		if l, ok := byID[id]; ok {
+			legals = append(legals, l)
+		}
+	}
+
+	if err := attachProductLegalSuccessors(s.DB, legals); err != nil {
+		return nil, err
+	}
+	return legals, nil
+}
+
+// renewalLink is the document a document renews, scanned as a struct so a NULL reads as nil
+type renewalLink struct {
+	SupersedesID *uint
+}
+
+// checkSupersedes checks that a document may renew the previous one, loading it into
+// previous. documentID is zero for a document being created; the owner condition keeps
+// renewals within the same business or product. The previous document stays locked until the
+// transaction ends, so two renewals of it cannot both pass the check.
+func checkSupersedes(tx *gorm.DB, table string, previous interface{}, documentID, previousID uint, owner string, ownerID uint) error {
+	if previousID == documentID {
+		return &ValidationError{Field: "supersedes_id", Message: "a document cannot supersede itself"}
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", previousID).Where(owner, ownerID).First(previous).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ValidationError{Field: "supersedes_id", Message: "the document to supersede was not found"}
+		}
+		return err
+	}
+
+	var renewals int64
+	if err := tx.Table(table).
+		Where("supersedes_id = ? AND id <> ? AND deleted_at IS NULL", previousID, documentID).
+		Count(&renewals).Error; err != nil {
+		return err
+	}
+	if renewals > 0 {
+		return ErrAlreadySuperseded
+	}
+
+	// An existing document must not end up renewing one of its own renewals
+	if documentID != 0 {
+		current := previousID
+		for i := 0; i < maxLegalChainLength; i++ {
+			var next []renewalLink
+			if err := tx.Table(table).Select("supersedes_id").Where("id = ?", current).Scan(&next).Error; err != nil {
+				return err
+			}
+			if len(next) == 0 || next[0].SupersedesID == nil {
+				break
+			}
+			if *next[0].SupersedesID == documentID {
+				return &ValidationError{Field: "supersedes_id", Message: "a document cannot supersede one of its own renewals"}
+			}
+			current = *next[0].SupersedesID
+		}
+	}
+	return nil
+}
+
+// documentChain returns the IDs of the documents renewed by and renewing the document,
+// oldest first. Documents in the trash do not continue the chain.
+func documentChain(tx *gorm.DB, table string, documentID uint) ([]uint, error) {
+	seen := map[uint]bool{documentID: true}
+	chain := []uint{documentID}
+
+	current := documentID
+	for i := 0; i < maxLegalChainLength; i++ {
+		var previous []renewalLink
+		if err := tx.Table(table).Select("supersedes_id").Where("id = ? AND deleted_at IS NULL", current).Scan(&previous).Error; err != nil {
+			return nil, err
+		}
+		if len(previous) == 0 || previous[0].SupersedesID == nil || seen[*previous[0].SupersedesID] {
+			break
+		}
+		current = *previous[0].SupersedesID
+		seen[current] = true
+		chain = append([]uint{current}, chain...)
+	}
+
+	current = documentID
+	for i := 0; i < maxLegalChainLength; i++ {
+		var next []uint
+		if err := tx.Table(table).Where("supersedes_id = ? AND deleted_at IS NULL", current).Order("id DESC").Limit(1).Pluck("id", &next).Error; err != nil {
+			return nil, err
+		}
+		if len(next) == 0 || seen[next[0]] {
+			break
+		}
+		current = next[0]
+		seen[current] = true
+		chain = append(chain, current)
+	}
+	return chain, nil
+}
+
+// documentSuccessors maps each of the documents to the one renewing it, if any
+func documentSuccessors(tx *gorm.DB, table string, ids []uint) (map[uint]uint, error) {
+	successors := map[uint]uint{}
+	if len(ids) == 0 {
+		return successors, nil
+	}
+	var rows []struct {
+		ID           uint
+		SupersedesID uint
+	}
+	if err := tx.Table(table).Select("id", "supersedes_id").
+		Where("supersedes_id IN ? AND deleted_at IS NULL", ids).
+		Order("id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		successors[row.SupersedesID] = row.ID
+	}
+	return successors, nil
+}
+
+func attachLegalSuccessors(tx *gorm.DB, legals []models.Legal) error {
+	ids := make([]uint, len(legals))
+	for i, l := range legals {
+		ids[i] = l.ID
+	}
+	successors, err := documentSuccessors(tx, "legals", ids)
+	if err != nil {
+		return err
+	}
+	for i := range legals {
+		if id, ok := successors[legals[i].ID]; ok {
+			legals[i].SupersededByID = &id
+		}
+	}
+	return nil
+}
+
+func attachProductLegalSuccessors(tx *gorm.DB, legals []models.ProductLegal) error {
+	ids := make([]uint, len(legals))
+	for i, l := range legals {
+		ids[i] = l.ID
+	}
+	successors, err := documentSuccessors(tx, "product_legals", ids)
+	if err != nil {
+		return err
+	}
+	for i := range legals {
+		if id, ok := successors[legals[i].ID]; ok {
+			legals[i].SupersededByID = &id
+		}
+	}
+	return nil
+}
+
+// findProductLegal loads a legal document of a product that belongs to the business
+func (s *BusinessService) findProductLegal(tx *gorm.DB, businessID, productID, legalID uint) (*models.ProductLegal, error) {
+	var legal models.ProductLegal
+	if err := tx.Where("id = ? AND product_id = ?", legalID, productID).
+		Where("product_id IN (?)", s.DB.Unscoped().Model(&models.Product{}).Select("id").Where("business_id = ?", businessID)).
+		First(&legal).Error; err != nil {
+		return nil, err
+	}
+	return &legal, nil
+}
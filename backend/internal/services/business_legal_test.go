@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// createLegalChain stores business legal documents that each renew the one before
+func createLegalChain(t *testing.T, db *gorm.DB, businessID uint, length int) []uint {
+	t.Helper()
+
+	var ids []uint
+	for i := 0; i < length; i++ {
+		legal := &models.Legal{BusinessID: businessID, LegalType: "NIB"}
+		if i > 0 {
+			legal.SupersedesID = &ids[i-1]
+		}
+		if err := db.Create(legal).Error; err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, legal.ID)
+	}
+	return ids
+}
+
+func TestCheckSupersedes(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+	other := createTestBusiness(t, db, owner, "Teh Manis")
+	chain := createLegalChain(t, db, business.ID, 3)
+	otherLegal := createLegalChain(t, db, other.ID, 1)[0]
+	standalone := createLegalChain(t, db, business.ID, 1)[0]
+
+	tests := []struct {
+		name       string
+		documentID uint
+		previousID uint
+		wantErr    error // With neither set, a ValidationError is expected
+		wantValid  bool
+	}{
+		{name: "new renewal of the active document", documentID: 0, previousID: chain[2], wantValid: true},
+		{name: "existing document renews the active one", documentID: standalone, previousID: chain[2], wantValid: true},
+		{name: "document already renewed", documentID: 0, previousID: chain[0], wantErr: ErrAlreadySuperseded},
+		{name: "itself", documentID: chain[1], previousID: chain[1]},
+		{name: "one of its own renewals", documentID: chain[0], previousID: chain[2]},
+		{name: "document of another business", documentID: 0, previousID: otherLegal},
+		{name: "unknown document", documentID: 0, previousID: 9999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSupersedes(db, "legals", &models.Legal{}, tt.documentID, tt.previousID, "business_id = ?", business.ID)
+
+			var validationErr *ValidationError
+			switch {
+			case tt.wantValid:
+				if err != nil {
+					t.Errorf("checkSupersedes() error = %v, want nil", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("checkSupersedes() error = %v, want %v", err, tt.wantErr)
+				}
+			case !errors.As(err, &validationErr):
+				t.Errorf("checkSupersedes() error = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestDocumentChain(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+	chain := createLegalChain(t, db, business.ID, 4)
+
+	for _, id := range chain {
+		got, err := documentChain(db, "legals", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, chain) {
+			t.Errorf("documentChain(%d) = %v, want %v", id, got, chain)
+		}
+	}
+
+	// A document in the trash ends the chain on either side of it
+	if err := db.Delete(&models.Legal{}, chain[2]).Error; err != nil {
+		t.Fatal(err)
+	}
+	got, err := documentChain(db, "legals", chain[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := chain[:2]; !slices.Equal(got, want) {
+		t.Errorf("with a trashed renewal: documentChain(%d) = %v, want %v", chain[1], got, want)
+	}
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"math"
+	"mime/multipart"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultProductCurrency     = "IDR"
+	maxProductSKULength        = 64
+	maxProductImages           = 10
+	maxProductVariants         = 50
+	maxProductVariantAttribute = 20
+	// Largest product picture accepted for upload
+	MaxProductImageSize = 5 << 20
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Picture types accepted for product images, by sniffed content type
+var productImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// ProductFilter narrows down and orders the products of a business
+type ProductFilter struct {
+	Query    string // Matches name, description or SKU
+	Category string
+	MinPrice *float64
+	MaxPrice *float64
+	Sort     string // name, category, price, capacity or created_at
+	Order    string // asc or desc
+}
+
+// Columns products can be sorted by
+var productSortColumns = map[string]string{
+	"name":       "name",
+	"category":   "category",
+	"price":      "unit_price",
+	"capacity":   "production_capacity",
+	"created_at": "created_at",
+}
+
+// scope validates the filter and returns it as a query scope
+func (f ProductFilter) scope() (func(*gorm.DB) *gorm.DB, error) {
+	order := strings.ToLower(f.Order)
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		return nil, &ValidationError{Field: "order", Message: "order must be asc or desc"}
+	}
+	column := "id"
+	if f.Sort != "" {
+		var ok bool
+		if column, ok = productSortColumns[f.Sort]; !ok {
+			return nil, &ValidationError{Field: "sort", Message: "sort must be name, category, price, capacity or created_at"}
+		}
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return nil, &ValidationError{Field: "min_price", Message: "min_price cannot be greater than max_price"}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if q := strings.TrimSpace(f.Query); q != "" {
+			like := "%" + q + "%"
+			db = db.Where("name ILIKE ? OR description ILIKE ? OR sku ILIKE ?", like, like, like)
+		}
+		if category := strings.TrimSpace(f.Category); category != "" {
+			db = db.Where("LOWER(category) = LOWER(?)", category)
+		}
+		if f.MinPrice != nil {
+			db = db.Where("unit_price >= ?", *f.MinPrice)
+		}
+		if f.MaxPrice != nil {
+			db = db.Where("unit_price <= ?", *f.MaxPrice)
+		}
+		if column == "id" {
+			return db.Order("id " + order)
+		}
+		// Products without a price or capacity go last either way
+		return db.Order(fmt.Sprintf("%s %s NULLS LAST, id", column, strings.ToUpper(order)))
+	}, nil
+}
+
+// ProductCategories lists the distinct categories used by the business's products
+func (s *BusinessService) ProductCategories(businessID uint) ([]string, error) {
+	categories := []string{}
+	if err := s.DB.Model(&models.Product{}).
+		Where("business_id = ? AND category <> ''", businessID).
+		Distinct("category").Order("category").
+		Pluck("category", &categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// ProductPatch holds the product fields to change. Nil fields are left as they are; images
+// and variants are replaced as a whole when given.
+type ProductPatch struct {
+	Name                    *string
+	Description             *string
+	Category                *string
+	SKU                     *string
+	UnitPrice               *float64
+	ClearUnitPrice          bool
+	Currency                *string
+	ProductionCapacity      *float64
+	ClearProductionCapacity bool
+	CapacityUnit            *string
+	Images                  *[]models.ProductImage
+	Variants                *[]models.ProductVariant
+
+	uploadedImage string // URL of the image AddProductImage just stored for the product
+}
+
+func (p *ProductPatch) empty() bool {
+	return p.Name == nil && p.Description == nil && p.Category == nil && p.SKU == nil &&
+		p.UnitPrice == nil && !p.ClearUnitPrice && p.Currency == nil &&
+		p.ProductionCapacity == nil && !p.ClearProductionCapacity && p.CapacityUnit == nil &&
+		p.Images == nil && p.Variants == nil
+}
+
+// apply copies the patch onto the product
+func (p *ProductPatch) apply(product *models.Product) {
+	for target, value := range map[*string]*string{
+		&product.Name:         p.Name,
+		&product.Description:  p.Description,
+		&product.Category:     p.Category,
+		&product.SKU:          p.SKU,
+		&product.Currency:     p.Currency,
+		&product.CapacityUnit: p.CapacityUnit,
+	} {
+		if value != nil {
+			*target = *value
+		}
+	}
+	switch {
+	case p.ClearUnitPrice:
+		product.UnitPrice = nil
+	case p.UnitPrice != nil:
+		product.UnitPrice = p.UnitPrice
+	}
+	switch {
+	case p.ClearProductionCapacity:
+		product.ProductionCapacity = nil
+	case p.ProductionCapacity != nil:
+		product.ProductionCapacity = p.ProductionCapacity
+	}
+	if p.Images != nil {
+		product.Images = *p.Images
+	}
+	if p.Variants != nil {
+		product.Variants = *p.Variants
+	}
+}
+
+// Product columns a patch can write
+var productCatalogColumns = []string{
+	"name", "description", "category", "sku", "unit_price", "currency",
+	"production_capacity", "capacity_unit", "images", "variants",
+}
+
+// normalizeProduct trims the product's catalog fields and checks them, naming the offending
+// field in the error
+func normalizeProduct(product *models.Product) error {
+	product.Name = strings.TrimSpace(product.Name)
+	if product.Name == "" {
+		return &ValidationError{Field: "name", Message: "name cannot be empty"}
+	}
+	if len(product.Name) > maxBusinessNameLength {
+		return &ValidationError{Field: "name", Message: fmt.Sprintf("name cannot be longer than %d characters", maxBusinessNameLength)}
+	}
+	if len(product.Description) > maxBusinessDescriptionLength {
+		return &ValidationError{Field: "description", Message: fmt.Sprintf("description cannot be longer than %d characters", maxBusinessDescriptionLength)}
+	}
+	for field, value := range map[string]*string{"category": &product.Category, "capacity_unit": &product.CapacityUnit} {
+		*value = strings.TrimSpace(*value)
+		if len(*value) > maxBusinessLabelLength {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("%s cannot be longer than %d characters", field, maxBusinessLabelLength)}
+		}
+	}
+
+	product.SKU = strings.TrimSpace(product.SKU)
+	if len(product.SKU) > maxProductSKULength {
+		return &ValidationError{Field: "sku", Message: fmt.Sprintf("sku cannot be longer than %d characters", maxProductSKULength)}
+	}
+
+	product.Currency = strings.ToUpper(strings.TrimSpace(product.Currency))
+	if product.Currency == "" {
+		product.Currency = defaultProductCurrency
+	}
+	if !currencyCode.MatchString(product.Currency) {
+		return &ValidationError{Field: "currency", Message: "currency must be a three letter ISO 4217 code such as IDR"}
+	}
+
+	if err := checkProductAmount("unit_price", product.UnitPrice); err != nil {
+		return err
+	}
+	if err := checkProductAmount("production_capacity", product.ProductionCapacity); err != nil {
+		return err
+	}
+
+	if err := normalizeProductImages(product); err != nil {
+		return err
+	}
+	return normalizeProductVariants(product)
+}
+
+func checkProductAmount(field string, value *float64) error {
+	if value == nil {
+		return nil
+	}
+	if math.IsNaN(*value) || math.IsInf(*value, 0) || *value < 0 {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("%s must be zero or a positive number", field)}
+	}
+	return nil
+}
+
+func normalizeProductImages(product *models.Product) error {
+	if len(product.Images) > maxProductImages {
+		return &ValidationError{Field: "images", Message: fmt.Sprintf("a product can have at most %d images", maxProductImages)}
+	}
+	seen := make(map[string]bool, len(product.Images))
+	for i := range product.Images {
+		image := &product.Images[i]
+		image.URL = strings.TrimSpace(image.URL)
+		image.Caption = strings.TrimSpace(image.Caption)
+		if !validProductImageURL(image.URL) {
+			return &ValidationError{Field: "images", Message: fmt.Sprintf("image %d must be an uploaded image or an http(s) URL", i+1)}
+		}
+		if seen[image.URL] {
+			return &ValidationError{Field: "images", Message: fmt.Sprintf("image %d is listed twice", i+1)}
+		}
+		seen[image.URL] = true
+		if len(image.Caption) > maxBusinessLabelLength {
+			return &ValidationError{Field: "images", Message: fmt.Sprintf("image captions cannot be longer than %d characters", maxBusinessLabelLength)}
+		}
+	}
+	return nil
+}
+
+// checkImageUploads rejects uploaded images the product did not already have. Uploads are
+// only added through AddProductImage, so a product cannot claim another business's files.
+func checkImageUploads(before, after []models.ProductImage, uploaded string) error {
+	had := make(map[string]bool, len(before))
+	for _, image := range before {
+		had[image.URL] = true
+	}
+	for i, image := range after {
+		if strings.HasPrefix(image.URL, "/") && !had[image.URL] && image.URL != uploaded {
+			return &ValidationError{Field: "images", Message: fmt.Sprintf("image %d must be uploaded to the product first", i+1)}
+		}
+	}
+	return nil
+}
+
+// validProductImageURL accepts product image uploads and pictures hosted elsewhere
+func validProductImageURL(raw string) bool {
+	if strings.HasPrefix(raw, "/") {
+		key, ok := uploadKeyFromURL(raw)
+		return ok && strings.HasPrefix(key, "products/")
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func normalizeProductVariants(product *models.Product) error {
+	if len(product.Variants) > maxProductVariants {
+		return &ValidationError{Field: "variants", Message: fmt.Sprintf("a product can have at most %d variants", maxProductVariants)}
+	}
+	names := make(map[string]bool, len(product.Variants))
+	skus := make(map[string]bool, len(product.Variants))
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		variant.Name = strings.TrimSpace(variant.Name)
+		variant.SKU = strings.TrimSpace(variant.SKU)
+
+		switch key := strings.ToLower(variant.Name); {
+		case variant.Name == "":
+			return &ValidationError{Field: "variants", Message: fmt.Sprintf("variant %d needs a name", i+1)}
+		case len(variant.Name) > maxBusinessLabelLength:
+			return &ValidationError{Field: "variants", Message: fmt.Sprintf("variant names cannot be longer than %d characters", maxBusinessLabelLength)}
+		case names[key]:
+			return &ValidationError{Field: "variants", Message: fmt.Sprintf("there are two variants named %q", variant.Name)}
+		default:
+			names[key] = true
+		}
+
+		if variant.SKU != "" {
+			key := strings.ToLower(variant.SKU)
+			if len(variant.SKU) > maxProductSKULength {
+				return &ValidationError{Field: "variants", Message: fmt.Sprintf("variant SKUs cannot be longer than %d characters", maxProductSKULength)}
+			}
+			if skus[key] {
+				return &ValidationError{Field: "variants", Message: fmt.Sprintf("SKU %q is used by two variants", variant.SKU)}
+			}
+			skus[key] = true
+		}
+
+		if err := checkProductAmount("variants", variant.UnitPrice); err != nil {
+			return &ValidationError{Field: "variants", Message: fmt.Sprintf("the price of variant %q must be zero or a positive number", variant.Name)}
+		}
+		if err := checkProductAmount("variants", variant.ProductionCapacity); err != nil {
+			return &ValidationError{Field: "variants", Message: fmt.Sprintf("the capacity of variant %q must be zero or a positive number", variant.Name)}
+		}
+
+		if len(variant.Attributes) > maxProductVariantAttribute {
+			return &ValidationError{Field: "variants", Message: fmt.Sprintf("a variant can have at most %d attributes", maxProductVariantAttribute)}
+		}
+		for name, value := range variant.Attributes {
+			if strings.TrimSpace(name) == "" || len(name) > maxBusinessLabelLength || len(value) > maxBusinessLabelLength {
+				return &ValidationError{Field: "variants", Message: fmt.Sprintf("variant attributes need a name and cannot be longer than %d characters", maxBusinessLabelLength)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkProductSKUs makes sure no two live products of the business share a SKU. except is
+// the product being updated, if any.
+func checkProductSKUs(tx *gorm.DB, businessID uint, products []models.Product, except uint) error {
+	seen := make(map[string]bool, len(products))
+	var wanted []string
+	for _, p := range products {
+		if p.SKU == "" {
+			continue
+		}
+		key := strings.ToLower(p.SKU)
+		if seen[key] {
+			return &ValidationError{Field: "sku", Message: fmt.Sprintf("SKU %q is given to two products", p.SKU)}
+		}
+		seen[key] = true
+		wanted = append(wanted, key)
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	var taken []string
+	if err := tx.Model(&models.Product{}).
+		Where("business_id = ? AND id <> ? AND LOWER(sku) IN ?", businessID, except, wanted).
+		Pluck("sku", &taken).Error; err != nil {
+		return err
+	}
+	if len(taken) > 0 {
+		return &ValidationError{Field: "sku", Message: fmt.Sprintf("another product already uses SKU %q", taken[0])}
+	}
+	return nil
+}
+
+// prepareProducts validates new products before they are created for the business
+func prepareProducts(tx *gorm.DB, businessID uint, products []models.Product) error {
+	for i := range products {
+		products[i].BusinessID = businessID
+		err := normalizeProduct(&products[i])
+		if err == nil {
+			err = checkImageUploads(nil, products[i].Images, "")
+		}
+		if err != nil {
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				validationErr.Field = fmt.Sprintf("products[%d].%s", i, validationErr.Field)
+			}
+			return err
+		}
+	}
+	return checkProductSKUs(tx, businessID, products, 0)
+}
+
+// AddProductImage stores an uploaded picture of the product and appends it to its images
+func (s *BusinessService) AddProductImage(businessID, productID uint, file multipart.File, header *multipart.FileHeader, caption string) (*models.Product, error) {
+	var product models.Product
+	if err := s.DB.Where("id = ? AND business_id = ?", productID, businessID).First(&product).Error; err != nil {
+		return nil, err
+	}
+	if len(product.Images) >= maxProductImages {
+		return nil, &ValidationError{Field: "file", Message: fmt.Sprintf("a product can have at most %d images", maxProductImages)}
+	}
+
+	policy := uploadPolicy{maxSize: MaxProductImageSize, types: productImageExtensions, typeNames: "JPEG, PNG, WebP or GIF images"}
+	upload, err := storeUpload(s.Storage, s.Scanner, fmt.Sprintf("products/%d/%d", businessID, productID), policy, file, header)
+	if err != nil {
+		return nil, err
+	}
+
+	caption = strings.TrimSpace(caption)
+	if caption == "" {
+		caption = strings.TrimSuffix(upload.FileName, path.Ext(upload.FileName))
+	}
+	images := append(append([]models.ProductImage{}, product.Images...), models.ProductImage{
+		URL:     upload.URL,
+		Caption: caption,
+	})
+
+	updated, err := s.UpdateBusinessProduct(businessID, productID, ProductPatch{Images: &images, uploadedImage: upload.URL})
+	if err != nil {
+		removeUploads(s.Storage, []string{upload.Key})
+		return nil, err
+	}
+	return updated, nil
+}
+
+// productImageFiles returns the uploaded image URLs of the products matching the query
+func productImageFiles(tx *gorm.DB, query string, args ...interface{}) ([]string, error) {
+	var products []models.Product
+	if err := tx.Unscoped().Select("id", "images").Where(query, args...).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, p := range products {
+		for _, image := range p.Images {
+			if strings.HasPrefix(image.URL, "/") {
+				urls = append(urls, image.URL)
+			}
+		}
+	}
+	return urls, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/scanner"
+	"go-gin-backend/internal/storage"
+	"mime/multipart"
+	"testing"
+)
+
+func TestProductImageUploads(t *testing.T) {
+	db := newTestDB(t)
+	s := NewBusinessService(db)
+	s.Storage = storage.NewLocalStorage(t.TempDir(), uploadURLPrefix, []byte("test-secret"))
+	s.Scanner = scanner.NewLocalScanner()
+
+	addProduct := func(businessID uint) *models.Product {
+		t.Helper()
+		products, err := s.AddBusinessProducts(businessID, []models.Product{{Name: "Arabica"}})
+		if err != nil {
+			t.Fatalf("AddBusinessProducts: %v", err)
+		}
+		file, size := newUploadFile(testPNG)
+		product, err := s.AddProductImage(businessID, products[0].ID, file, &multipart.FileHeader{Filename: "front.png", Size: size}, "")
+		if err != nil {
+			t.Fatalf("AddProductImage: %v", err)
+		}
+		return product
+	}
+
+	alice := createTestUser(t, db, "alice")
+	product := addProduct(createTestBusiness(t, db, alice, "Kopi Nusantara").ID)
+	bob := createTestUser(t, db, "bob")
+	otherBusiness := createTestBusiness(t, db, bob, "Teh Manis")
+	otherProduct := addProduct(otherBusiness.ID)
+
+	own := product.Images[0]
+	external := models.ProductImage{URL: "https://cdn.example.com/arabica.jpg"}
+	tests := []struct {
+		name    string
+		images  []models.ProductImage
+		invalid bool
+	}{
+		{name: "keep the upload", images: []models.ProductImage{external, {URL: own.URL, Caption: "Front"}}},
+		{name: "another business's upload", images: []models.ProductImage{own, otherProduct.Images[0]}, invalid: true},
+		{name: "upload that was never stored", images: []models.ProductImage{{URL: "/uploads/products/1/1/made-up.png"}}, invalid: true},
+		// Once removed, the upload cannot be listed again
+		{name: "remove the upload", images: []models.ProductImage{external}},
+		{name: "removed upload", images: []models.ProductImage{own}, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images := tt.images
+			_, err := s.UpdateBusinessProduct(product.BusinessID, product.ID, ProductPatch{Images: &images})
+			var validationErr *ValidationError
+			switch {
+			case tt.invalid && !errors.As(err, &validationErr):
+				t.Fatalf("UpdateBusinessProduct() error = %v, want a validation error", err)
+			case !tt.invalid && err != nil:
+				t.Fatalf("UpdateBusinessProduct() error = %v", err)
+			}
+		})
+	}
+
+	_, err := s.AddBusinessProducts(otherBusiness.ID, []models.Product{{Name: "Robusta", Images: []models.ProductImage{own}}})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("creating a product with another business's upload: error = %v, want a validation error", err)
+	}
+}
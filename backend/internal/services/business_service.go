@@ -1,35 +1,75 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"go-gin-backend/internal/models"
-	"io"
+	"go-gin-backend/internal/scanner"
+	"go-gin-backend/internal/storage"
 	"mime/multipart"
-	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	maxBusinessNameLength        = 255
+	maxBusinessLabelLength       = 100
+	maxBusinessDescriptionLength = 5000
+)
+
+var (
+	// ErrBusinessAccessDenied is returned when a user acts on a business they do not own
+	ErrBusinessAccessDenied = errors.New("access to business denied")
+	// ErrVersionConflict is returned when the business changed since the client read it
+	ErrVersionConflict = errors.New("business was modified since it was read")
+	// ErrEmptyPatch is returned for updates that do not change any field
+	ErrEmptyPatch = errors.New("no fields to update")
+)
+
+// ValidationError reports an invalid value for a field
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
 type BusinessService struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Storage storage.Storage // Where uploaded documents are kept
+	Scanner scanner.Scanner // Checks uploads for malware before they are stored
+	Actor   AuditActor      // Recorded in the audit log for every change
 }
 
 func NewBusinessService(db *gorm.DB) *BusinessService {
-	return &BusinessService{DB: db}
+	return &BusinessService{DB: db, Storage: storage.Files, Scanner: scanner.NewFromEnv()}
 }
 
-// Get all businesses by user_id
+// WithActor returns a copy of the service that attributes its changes to the actor
+func (s *BusinessService) WithActor(actor AuditActor) *BusinessService {
+	scoped := *s
+	scoped.Actor = actor
+	return &scoped
+}
+
+func (s *BusinessService) audit(tx *gorm.DB, businessID uint, entityType string, entityID uint, action string, before, after interface{}) error {
+	return recordAudit(tx, s.Actor, &businessID, entityType, entityID, action, before, after)
+}
+
+// Get all businesses the user is a member of
 func (s *BusinessService) GetBusinessesByUserID(userID uint) ([]models.Business, error) {
 	var businesses []models.Business
 	if err := s.DB.
-		Preload("Products").
+		Preload("Products.ProductLegals", models.ActiveProductLegals).
 		Preload("Financials", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at DESC") // Load financials ordered by most recent first
 		}).
-		Preload("Legals").
-		Where("user_id = ?", userID).
+		Preload("Legals", models.ActiveLegals).
+		Where("id IN (?)", s.DB.Model(&models.BusinessMember{}).Select("business_id").Where("user_id = ?", userID)).
 		Find(&businesses).Error; err != nil {
 		return nil, err
 	}
@@ -41,6 +81,10 @@ func (s *BusinessService) GetBusinessesByUserID(userID uint) ([]models.Business,
 		}
 	}
 
+	if err := attachCustomFields(s.DB, businesses); err != nil {
+		return nil, err
+	}
+
 	return businesses, nil
 }
 
@@ -50,33 +94,46 @@ func (s *BusinessService) CreateBusiness(business *models.Business, additionalIn
 		if err := tx.Create(business).Error; err != nil {
 			return err
 		}
+		if err := s.audit(tx, business.ID, models.AuditEntityBusiness, business.ID, models.AuditActionCreate, nil, business); err != nil {
+			return err
+		}
 
-		for i := range additionalInfo {
-			additionalInfo[i].BusinessID = business.ID
+		// The creator is the first owner of the business
+		if err := tx.Create(&models.BusinessMember{
+			BusinessID: business.ID,
+			UserID:     business.UserID,
+			Role:       models.BusinessRoleOwner,
+		}).Error; err != nil {
+			return err
 		}
-		if len(additionalInfo) > 0 {
-			if err := tx.Create(&additionalInfo).Error; err != nil {
-				return err
-			}
+
+		// Additional info sent at registration becomes text custom fields
+		if err := s.addLegacyCustomFields(tx, business.ID, models.CustomFieldEntityBusiness, business.ID, additionalInfo); err != nil {
+			return err
 		}
 
-		for i := range products {
-			products[i].BusinessID = business.ID
+		if err := prepareProducts(tx, business.ID, products); err != nil {
+			return err
 		}
 		if len(products) > 0 {
 			if err := tx.Create(&products).Error; err != nil {
 				return err
 			}
 		}
+		for i := range products {
+			if err := s.audit(tx, business.ID, models.AuditEntityProduct, products[i].ID, models.AuditActionCreate, nil, &products[i]); err != nil {
+				return err
+			}
+		}
 
-		return nil
+		return s.snapshot(tx, business.ID)
 	})
 }
 
 // Get business with relations
 func (s *BusinessService) GetBusinessByID(id uint) (*models.Business, error) {
 	var business models.Business
-	if err := s.DB.Preload("Legals").
+	if err := s.DB.Preload("Legals", models.ActiveLegals).
 		Preload("Products").
 		Preload("Financials", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at DESC") // Load financials ordered by most recent first
@@ -90,90 +147,359 @@ func (s *BusinessService) GetBusinessByID(id uint) (*models.Business, error) {
 		business.Financial = &business.Financials[0] // Most recent is first due to DESC order
 	}
 
-	return &business, nil
+	businesses := []models.Business{business}
+	if err := attachCustomFields(s.DB, businesses); err != nil {
+		return nil, err
+	}
+
+	return &businesses[0], nil
+}
+
+// GetListedBusinessByID fetches a business as investors see it, hiding taken down businesses.
+// A pinned snapshot is shown in place of the live profile.
+func (s *BusinessService) GetListedBusinessByID(id uint) (*models.Business, error) {
+	business, err := s.GetBusinessByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if business.TakenDownAt != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	// Owners can pin a snapshot for investors to evaluate instead of the live profile
+	if business.PinnedSnapshotID != nil {
+		snapshot, err := s.GetSnapshot(id, *business.PinnedSnapshotID)
+		if err != nil {
+			return nil, err
+		}
+		pinned := snapshot.Business()
+		pinned.PinnedSnapshotID = business.PinnedSnapshotID
+		pinned.Snapshot.Pinned = true
+		return pinned, nil
+	}
+
+	if business.Snapshot, err = latestSnapshotRef(s.DB, id); err != nil {
+		return nil, err
+	}
+	return business, nil
+}
+
+// AuthorizeBusinessAccess verifies that the business exists and that the user is a member
+// with at least minRole. It returns gorm.ErrRecordNotFound for unknown businesses and
+// ErrBusinessAccessDenied otherwise.
+func (s *BusinessService) AuthorizeBusinessAccess(userID, businessID uint, minRole string) error {
+	var business models.Business
+	if err := s.DB.Select("id").First(&business, businessID).Error; err != nil {
+		return err
+	}
+
+	role, err := s.GetMemberRole(userID, businessID)
+	if err != nil {
+		return err
+	}
+
+	if !models.BusinessRoleAtLeast(role, minRole) {
+		return ErrBusinessAccessDenied
+	}
+
+	return nil
+}
+
+// GetMemberRole returns the user's role in the business, or an empty string if they are not a member
+func (s *BusinessService) GetMemberRole(userID, businessID uint) (string, error) {
+	var member models.BusinessMember
+	err := s.DB.Where("business_id = ? AND user_id = ?", businessID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// GetBusinessProduct fetches a product only if it belongs to the given business
+func (s *BusinessService) GetBusinessProduct(businessID, productID uint) (*models.Product, error) {
+	var product models.Product
+	if err := s.DB.Where("id = ? AND business_id = ?", productID, businessID).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// BusinessPatch holds the basic info fields to change. Nil fields are left as they are.
+type BusinessPatch struct {
+	Name           *string
+	Type           *string
+	Description    *string
+	Industry       *string
+	FoundedAt      *time.Time
+	ClearFoundedAt bool
+}
+
+// changes validates the patch and returns the columns to update
+func (p *BusinessPatch) changes() (map[string]interface{}, error) {
+	changes := make(map[string]interface{})
+
+	if p.Name != nil {
+		name := strings.TrimSpace(*p.Name)
+		if name == "" {
+			return nil, &ValidationError{Field: "name", Message: "name cannot be empty"}
+		}
+		if len(name) > maxBusinessNameLength {
+			return nil, &ValidationError{Field: "name", Message: fmt.Sprintf("name cannot be longer than %d characters", maxBusinessNameLength)}
+		}
+		changes["name"] = name
+	}
+	for field, value := range map[string]*string{"type": p.Type, "industry": p.Industry} {
+		if value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*value)
+		if len(v) > maxBusinessLabelLength {
+			return nil, &ValidationError{Field: field, Message: fmt.Sprintf("%s cannot be longer than %d characters", field, maxBusinessLabelLength)}
+		}
+		changes[field] = v
+	}
+	if p.Description != nil {
+		if len(*p.Description) > maxBusinessDescriptionLength {
+			return nil, &ValidationError{Field: "description", Message: fmt.Sprintf("description cannot be longer than %d characters", maxBusinessDescriptionLength)}
+		}
+		changes["description"] = *p.Description
+	}
+	switch {
+	case p.ClearFoundedAt:
+		changes["founded_at"] = nil
+	case p.FoundedAt != nil:
+		if p.FoundedAt.After(time.Now()) {
+			return nil, &ValidationError{Field: "founded_at", Message: "founded_at cannot be in the future"}
+		}
+		changes["founded_at"] = *p.FoundedAt
+	}
+
+	return changes, nil
 }
 
-// Update business basic info
-func (s *BusinessService) UpdateBusiness(business *models.Business) error {
-	return s.DB.Save(business).Error
+// UpdateBusiness applies the patch to the business basic info. Ownership and timestamps are
+// never touched here. When expectedVersion is given the update only goes through if nobody
+// changed the business since the client read that version.
+func (s *BusinessService) UpdateBusiness(businessID uint, patch BusinessPatch, expectedVersion *uint) (*models.Business, error) {
+	changes, err := patch.changes()
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, ErrEmptyPatch
+	}
+
+	var business models.Business
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Business
+		if err := tx.First(&before, businessID).Error; err != nil {
+			return err
+		}
+		if expectedVersion != nil && before.Version != *expectedVersion {
+			return ErrVersionConflict
+		}
+
+		changes["version"] = gorm.Expr("version + 1")
+		result := tx.Model(&models.Business{}).
+			Where("id = ? AND version = ?", businessID, before.Version).
+			Updates(changes)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		if err := tx.First(&business, businessID).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityBusiness, businessID, models.AuditActionUpdate, &before, &business); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &business, nil
 }
 
-// Delete business
+// DeleteBusiness moves the business to the trash with its products, documents, financials
+// and projections. They all share one deletion time so a restore brings back exactly what
+// was deleted with the business.
 func (s *BusinessService) DeleteBusiness(id uint) error {
-	return s.DB.Delete(&models.Business{}, id).Error
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Business
+		if err := tx.First(&before, id).Error; err != nil {
+			return err
+		}
+
+		deletedAt := trashTimestamp()
+		cascade := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&models.ProductLegal{}, "product_id IN (?)", []interface{}{tx.Model(&models.Product{}).Select("id").Where("business_id = ?", id)}},
+			{&models.Product{}, "business_id = ?", []interface{}{id}},
+			{&models.Legal{}, "business_id = ?", []interface{}{id}},
+			{&models.Financial{}, "business_id = ?", []interface{}{id}},
+			{&models.HistoricalProjection{}, "business_id = ?", []interface{}{id}},
+			{&models.Business{}, "id = ?", []interface{}{id}},
+		}
+		for _, c := range cascade {
+			if err := softDeleteAt(tx, c.model, deletedAt, c.query, c.args...); err != nil {
+				return err
+			}
+		}
+		return s.audit(tx, id, models.AuditEntityBusiness, id, models.AuditActionDelete, &before, nil)
+	})
 }
 
 // ===== Product Management =====
-func (s *BusinessService) GetBusinessProducts(businessID uint) ([]models.Product, error) {
-	var products []models.Product
-	if err := s.DB.Where("business_id = ?", businessID).Find(&products).Error; err != nil {
+
+// GetBusinessProducts lists the business's products that match the filter
+func (s *BusinessService) GetBusinessProducts(businessID uint, filter ProductFilter) ([]models.Product, error) {
+	scope, err := filter.scope()
+	if err != nil {
+		return nil, err
+	}
+
+	products := []models.Product{}
+	if err := s.DB.Where("business_id = ?", businessID).Scopes(scope).Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
 func (s *BusinessService) AddBusinessProducts(businessID uint, products []models.Product) ([]models.Product, error) {
-	for i := range products {
-		products[i].BusinessID = businessID
-	}
-
-	if err := s.DB.Create(&products).Error; err != nil {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := prepareProducts(tx, businessID, products); err != nil {
+			return err
+		}
+		if err := tx.Create(&products).Error; err != nil {
+			return err
+		}
+		for i := range products {
+			if err := s.audit(tx, businessID, models.AuditEntityProduct, products[i].ID, models.AuditActionCreate, nil, &products[i]); err != nil {
+				return err
+			}
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return products, nil
 }
 
-func (s *BusinessService) UpdateBusinessProduct(businessID, productID uint, updateData map[string]interface{}) error {
-	return s.DB.Model(&models.Product{}).
-		Where("id = ? AND business_id = ?", productID, businessID).
-		Updates(updateData).Error
+// UpdateBusinessProduct applies the patch to a product of the business
+func (s *BusinessService) UpdateBusinessProduct(businessID, productID uint, patch ProductPatch) (*models.Product, error) {
+	if patch.empty() {
+		return nil, ErrEmptyPatch
+	}
+
+	var after models.Product
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Product
+		if err := tx.Where("id = ? AND business_id = ?", productID, businessID).First(&before).Error; err != nil {
+			return err
+		}
+
+		updated := before
+		patch.apply(&updated)
+		if err := normalizeProduct(&updated); err != nil {
+			return err
+		}
+		if err := checkImageUploads(before.Images, updated.Images, patch.uploadedImage); err != nil {
+			return err
+		}
+		if err := checkProductSKUs(tx, businessID, []models.Product{updated}, productID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Product{}).
+			Where("id = ? AND business_id = ?", productID, businessID).
+			Select(productCatalogColumns).
+			Updates(&updated).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&after, productID).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityProduct, productID, models.AuditActionUpdate, &before, &after); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
 }
 
 func (s *BusinessService) DeleteBusinessProduct(businessID, productID uint) error {
-	return s.DB.Where("id = ? AND business_id = ?", productID, businessID).
-		Delete(&models.Product{}).Error
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Product
+		if err := tx.Where("id = ? AND business_id = ?", productID, businessID).First(&before).Error; err != nil {
+			return err
+		}
+
+		// The product's permits go to the trash with it
+		deletedAt := trashTimestamp()
+		if err := softDeleteAt(tx, &models.ProductLegal{}, deletedAt, "product_id = ?", productID); err != nil {
+			return err
+		}
+		if err := softDeleteAt(tx, &models.Product{}, deletedAt, "id = ?", productID); err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityProduct, productID, models.AuditActionDelete, &before, nil); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
 }
 
 // ===== Legal Document Management =====
-func (s *BusinessService) GetBusinessLegal(businessID uint) ([]models.Legal, error) {
+
+// GetBusinessLegal returns the business's legal documents. Documents renewed by a newer one
+// are left out unless includeSuperseded is set.
+func (s *BusinessService) GetBusinessLegal(businessID uint, includeSuperseded bool) ([]models.Legal, error) {
 	var legals []models.Legal
-	if err := s.DB.Where("business_id = ?", businessID).Find(&legals).Error; err != nil {
+	query := s.DB.Where("business_id = ?", businessID)
+	if !includeSuperseded {
+		query = query.Scopes(models.ActiveLegals)
+	}
+	if err := query.Find(&legals).Error; err != nil {
+		return nil, err
+	}
+	if err := attachLegalSuccessors(s.DB, legals); err != nil {
+		return nil, err
+	}
+	if err := attachLegalCustomFields(s.DB, businessID, legals); err != nil {
 		return nil, err
 	}
 	return legals, nil
 }
 
-func (s *BusinessService) AddBusinessLegal(businessID uint, file multipart.File, header *multipart.FileHeader, legalType, issuedBy, validUntil, notes string) (*models.Legal, error) {
-	// Create upload directory if it doesn't exist
-	uploadDir := "uploads/legal/business"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create upload directory: %v", err)
-	}
-
-	// Generate unique filename
-	filename := fmt.Sprintf("%d_%d_%s", businessID, time.Now().Unix(), header.Filename)
-	filePath := filepath.Join(uploadDir, filename)
-
-	// Create the file on disk
-	dst, err := os.Create(filePath)
+// AddBusinessLegal stores a new legal document. With supersedesID it renews an earlier
+// document of the business, taking over its legal type when none is given.
+func (s *BusinessService) AddBusinessLegal(businessID uint, file multipart.File, header *multipart.FileHeader, legalType, issuedBy, validUntil, notes string, supersedesID uint) (*models.Legal, error) {
+	upload, err := storeUpload(s.Storage, s.Scanner, fmt.Sprintf("legal/business/%d", businessID), documentUploadPolicy(), file, header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file: %v", err)
-	}
-	defer dst.Close()
-
-	// Copy the uploaded file to the destination
-	if _, err := io.Copy(dst, file); err != nil {
-		return nil, fmt.Errorf("failed to save file: %v", err)
+		return nil, err
 	}
 
-	// Create URL for frontend access (relative to server root)
-	fileURL := fmt.Sprintf("/uploads/legal/business/%s", filename)
-
 	legal := &models.Legal{
 		BusinessID: businessID,
-		FileName:   header.Filename,
-		FileURL:    fileURL,
+		FileName:   upload.FileName,
+		FileURL:    upload.URL,
 		LegalType:  legalType,
 		IssuedBy:   issuedBy,
 		Notes:      notes,
@@ -185,14 +511,36 @@ func (s *BusinessService) AddBusinessLegal(businessID uint, file multipart.File,
 		}
 	}
 
-	if err := s.DB.Create(legal).Error; err != nil {
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if supersedesID != 0 {
+			var previous models.Legal
+			if err := checkSupersedes(tx, "legals", &previous, 0, supersedesID, "business_id = ?", businessID); err != nil {
+				return err
+			}
+			legal.SupersedesID = &supersedesID
+			if legal.LegalType == "" {
+				legal.LegalType = previous.LegalType
+			}
+		}
+		if err := tx.Create(legal).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityLegal, legal.ID, models.AuditActionCreate, nil, legal); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		removeUploads(s.Storage, []string{upload.Key})
 		return nil, err
 	}
 
 	return legal, nil
 }
 
-func (s *BusinessService) GetProductsLegal(businessID uint) ([]models.ProductLegal, error) {
+// GetProductsLegal returns the legal documents of the business's products. Permits renewed
+// by a newer one are left out unless includeSuperseded is set.
+func (s *BusinessService) GetProductsLegal(businessID uint, includeSuperseded bool) ([]models.ProductLegal, error) {
 	var legals []models.ProductLegal
 
 	// Use a simpler approach: get all products for the business first
@@ -205,9 +553,14 @@ func (s *BusinessService) GetProductsLegal(businessID uint) ([]models.ProductLeg
 
 	// Then get all legal documents for those products
 	if len(productIDs) > 0 {
-		if err := s.DB.Preload("Product").
-			Where("product_id IN ?", productIDs).
-			Find(&legals).Error; err != nil {
+		query := s.DB.Preload("Product").Where("product_id IN ?", productIDs)
+		if !includeSuperseded {
+			query = query.Scopes(models.ActiveProductLegals)
+		}
+		if err := query.Find(&legals).Error; err != nil {
+			return nil, err
+		}
+		if err := attachProductLegalSuccessors(s.DB, legals); err != nil {
 			return nil, err
 		}
 	}
@@ -215,42 +568,24 @@ func (s *BusinessService) GetProductsLegal(businessID uint) ([]models.ProductLeg
 	return legals, nil
 }
 
-func (s *BusinessService) AddProductLegal(businessID, productID uint, file multipart.File, header *multipart.FileHeader, legalType, issuedBy, validUntil, notes string) (*models.ProductLegal, error) {
+// AddProductLegal stores a new legal document of a product. With supersedesID it renews an
+// earlier permit of the same product, taking over its legal type when none is given.
+func (s *BusinessService) AddProductLegal(businessID, productID uint, file multipart.File, header *multipart.FileHeader, legalType, issuedBy, validUntil, notes string, supersedesID uint) (*models.ProductLegal, error) {
 	// First verify the product belongs to the business
 	var product models.Product
 	if err := s.DB.Where("id = ? AND business_id = ?", productID, businessID).First(&product).Error; err != nil {
 		return nil, err
 	}
 
-	// Create upload directory if it doesn't exist
-	uploadDir := "uploads/legal/products"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create upload directory: %v", err)
-	}
-
-	// Generate unique filename
-	filename := fmt.Sprintf("%d_%d_%d_%s", businessID, productID, time.Now().Unix(), header.Filename)
-	filePath := filepath.Join(uploadDir, filename)
-
-	// Create the file on disk
-	dst, err := os.Create(filePath)
+	upload, err := storeUpload(s.Storage, s.Scanner, fmt.Sprintf("legal/products/%d/%d", businessID, productID), documentUploadPolicy(), file, header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file: %v", err)
-	}
-	defer dst.Close()
-
-	// Copy the uploaded file to the destination
-	if _, err := io.Copy(dst, file); err != nil {
-		return nil, fmt.Errorf("failed to save file: %v", err)
+		return nil, err
 	}
 
-	// Create URL for frontend access (relative to server root)
-	fileURL := fmt.Sprintf("/uploads/legal/products/%s", filename)
-
 	legal := &models.ProductLegal{
 		ProductID: productID,
-		FileName:  header.Filename,
-		FileURL:   fileURL,
+		FileName:  upload.FileName,
+		FileURL:   upload.URL,
 		LegalType: legalType,
 		IssuedBy:  issuedBy,
 		Notes:     notes,
@@ -262,7 +597,27 @@ func (s *BusinessService) AddProductLegal(businessID, productID uint, file multi
 		}
 	}
 
-	if err := s.DB.Create(legal).Error; err != nil {
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if supersedesID != 0 {
+			var previous models.ProductLegal
+			if err := checkSupersedes(tx, "product_legals", &previous, 0, supersedesID, "product_id = ?", productID); err != nil {
+				return err
+			}
+			legal.SupersedesID = &supersedesID
+			if legal.LegalType == "" {
+				legal.LegalType = previous.LegalType
+			}
+		}
+		if err := tx.Create(legal).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityProductLegal, legal.ID, models.AuditActionCreate, nil, legal); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		removeUploads(s.Storage, []string{upload.Key})
 		return nil, err
 	}
 
@@ -281,6 +636,9 @@ func (s *BusinessService) GetFinancialData(businessID uint) (*models.Financial,
 		}
 		return nil, err
 	}
+	if err := attachFinancialCustomFields(s.DB, businessID, []*models.Financial{&financial}); err != nil {
+		return nil, err
+	}
 	return &financial, nil
 }
 
@@ -292,6 +650,13 @@ func (s *BusinessService) GetFinancialHistory(businessID uint) ([]models.Financi
 		Find(&financials).Error; err != nil {
 		return nil, err
 	}
+	records := make([]*models.Financial, len(financials))
+	for i := range financials {
+		records[i] = &financials[i]
+	}
+	if err := attachFinancialCustomFields(s.DB, businessID, records); err != nil {
+		return nil, err
+	}
 	return financials, nil
 }
 
@@ -306,8 +671,12 @@ func (s *BusinessService) UpdateFinancialData(businessID uint, ebitda, assets, l
 	}
 
 	// If record doesn't exist, create new one
+	var before *models.Financial
 	if err == gorm.ErrRecordNotFound {
 		financial = models.Financial{BusinessID: businessID}
+	} else {
+		existing := financial
+		before = &existing
 	}
 
 	// Update fields if provided
@@ -328,7 +697,21 @@ func (s *BusinessService) UpdateFinancialData(businessID uint, ebitda, assets, l
 	}
 
 	// Save the record
-	if err := s.DB.Save(&financial).Error; err != nil {
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&financial).Error; err != nil {
+			return err
+		}
+
+		action := models.AuditActionUpdate
+		if before == nil {
+			action = models.AuditActionCreate
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityFinancial, financial.ID, action, before, &financial); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -360,7 +743,16 @@ func (s *BusinessService) CreateFinancialData(businessID uint, revenue, ebitda,
 	}
 
 	// Create the new record
-	if err := s.DB.Create(&financial).Error; err != nil {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&financial).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityFinancial, financial.ID, models.AuditActionCreate, nil, &financial); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -375,6 +767,7 @@ func (s *BusinessService) GetAllBusinessesWithPagination(page, limit int, indust
 	var total int64
 
        query := s.DB.Model(&models.Business{}).
+	       Scopes(models.ListedBusinesses).
 	       Joins("JOIN products ON products.business_id = businesses.id").
 	       Joins("JOIN legals ON legals.business_id = businesses.id").
 	       Joins("JOIN financials ON financials.business_id = businesses.id").
@@ -382,7 +775,7 @@ func (s *BusinessService) GetAllBusinessesWithPagination(page, limit int, indust
 	       Preload("Financials", func(db *gorm.DB) *gorm.DB {
 		       return db.Order("created_at DESC")
 	       }).
-	       Preload("Legals").
+	       Preload("Legals", models.ActiveLegals).
 			   Group("businesses.id").
 			   Having("COUNT(DISTINCT products.id) > 0 AND COUNT(DISTINCT legals.id) > 0 AND COUNT(DISTINCT financials.id) > 0")
 
@@ -417,6 +810,11 @@ func (s *BusinessService) GetAllBusinessesWithPagination(page, limit int, indust
 
 func (s *BusinessService) StoreLegalAnalysisComparison(businessID uint, comparison *models.LegalComparison) error {
 	return s.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := loadLegalAnalysisAudit(tx, businessID)
+		if err != nil {
+			return err
+		}
+
 		// Clear existing analysis for this business (optional - depends on your requirements)
 		if err := tx.Where("business_id = ?", businessID).Delete(&models.MissingLegal{}).Error; err != nil {
 			return err
@@ -489,7 +887,11 @@ func (s *BusinessService) StoreLegalAnalysisComparison(businessID uint, comparis
 			}
 		}
 
-		return nil
+		after, err := loadLegalAnalysisAudit(tx, businessID)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, businessID, models.AuditEntityLegalAnalysis, businessID, models.AuditActionUpdate, before, after)
 	})
 }
 
@@ -620,6 +1022,11 @@ func (s *BusinessService) GetStoredLegalAnalysis(businessID uint) (*models.Legal
 // ClearStoredLegalAnalysis removes existing analysis data
 func (s *BusinessService) ClearStoredLegalAnalysis(businessID uint) error {
 	return s.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := loadLegalAnalysisAudit(tx, businessID)
+		if err != nil {
+			return err
+		}
+
 		// Clear business legals
 		if err := tx.Where("business_id = ?", businessID).Delete(&models.MissingLegal{}).Error; err != nil {
 			return err
@@ -631,10 +1038,31 @@ func (s *BusinessService) ClearStoredLegalAnalysis(businessID uint) error {
 			return err
 		}
 
-		return nil
+		return s.audit(tx, businessID, models.AuditEntityLegalAnalysis, businessID, models.AuditActionDelete, before, nil)
 	})
 }
 
+// legalAnalysisAudit is the stored legal analysis of a business as recorded in the audit log
+type legalAnalysisAudit struct {
+	MissingLegals        []models.MissingLegal        `json:"missing_legals"`
+	MissingProductLegals []models.MissingProductLegal `json:"missing_product_legals"`
+}
+
+func loadLegalAnalysisAudit(tx *gorm.DB, businessID uint) (*legalAnalysisAudit, error) {
+	state := &legalAnalysisAudit{}
+	if err := tx.Preload("StepsToGetLegal").
+		Where("business_id = ?", businessID).
+		Find(&state.MissingLegals).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Preload("StepsToGetProductLegal").
+		Where("product_id IN (SELECT id FROM products WHERE business_id = ?)", businessID).
+		Find(&state.MissingProductLegals).Error; err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
 // type LegalComparison struct {
 // 	Required []struct {
 // 		Type     string                  `json:"type"`
@@ -867,6 +1295,11 @@ func (s *BusinessService) SaveBusinessHistoricalProjections(businessID uint, pro
 
 	// Use transaction to ensure data consistency
 	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var before, after projectionsAudit
+		if err := tx.Where("business_id = ?", businessID).Order("year ASC").Find(&before.Projections).Error; err != nil {
+			return err
+		}
+
 		// First, delete existing historical projections for this business
 		if err := tx.Where("business_id = ?", businessID).Delete(&models.HistoricalProjection{}).Error; err != nil {
 			return err
@@ -886,8 +1319,21 @@ func (s *BusinessService) SaveBusinessHistoricalProjections(businessID uint, pro
 			if err := tx.Create(&historicalProjection).Error; err != nil {
 				return err
 			}
+			after.Projections = append(after.Projections, historicalProjection)
 		}
 
-		return nil
+		action := models.AuditActionUpdate
+		if len(before.Projections) == 0 {
+			action = models.AuditActionCreate
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityProjections, businessID, action, &before, &after); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
 	})
 }
+
+// projectionsAudit is the set of historical projections of a business as recorded in the audit log
+type projectionsAudit struct {
+	Projections []models.HistoricalProjection `json:"projections"`
+}
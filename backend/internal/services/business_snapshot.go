@@ -0,0 +1,840 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxSnapshotLabelLength = 100
+
+// ErrSnapshotNotFound is returned for snapshots the business does not have
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ListSnapshots returns the business's snapshots without their data, newest first, with the
+// total count
+func (s *BusinessService) ListSnapshots(businessID uint, page, limit int) ([]models.BusinessSnapshot, int64, error) {
+	query := s.DB.Model(&models.BusinessSnapshot{}).Where("business_id = ?", businessID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var snapshots []models.BusinessSnapshot
+	if err := query.Omit("data").
+		Order("number DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&snapshots).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return snapshots, total, nil
+}
+
+// GetSnapshot returns one snapshot of the business with its data
+func (s *BusinessService) GetSnapshot(businessID, snapshotID uint) (*models.BusinessSnapshot, error) {
+	var snapshot models.BusinessSnapshot
+	if err := s.DB.Where("id = ? AND business_id = ?", snapshotID, businessID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// CreateSnapshot takes a snapshot on demand, even when nothing changed since the last one
+func (s *BusinessService) CreateSnapshot(businessID uint, label string) (*models.BusinessSnapshot, error) {
+	label = strings.TrimSpace(label)
+	if len(label) > maxSnapshotLabelLength {
+		return nil, &ValidationError{Field: "label", Message: fmt.Sprintf("label cannot be longer than %d characters", maxSnapshotLabelLength)}
+	}
+
+	var snapshot *models.BusinessSnapshot
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		snapshot, err = s.takeSnapshot(tx, businessID, models.SnapshotTriggerManual, label, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// DiffSnapshot lists what changed from the snapshot to the one with againstID, or to the
+// current profile when againstID is nil
+func (s *BusinessService) DiffSnapshot(businessID, snapshotID uint, againstID *uint) ([]models.SnapshotChange, error) {
+	from, err := s.GetSnapshot(businessID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	var to models.BusinessSnapshotData
+	if againstID != nil {
+		against, err := s.GetSnapshot(businessID, *againstID)
+		if err != nil {
+			return nil, err
+		}
+		to = against.Data
+	} else {
+		current, _, err := buildSnapshotData(s.DB, businessID)
+		if err != nil {
+			return nil, err
+		}
+		to = *current
+	}
+
+	return diffSnapshotData(from.Data, to)
+}
+
+// PinSnapshot shows investors the business as the snapshot captured it until it is unpinned
+func (s *BusinessService) PinSnapshot(businessID, snapshotID uint) (*models.Business, error) {
+	snapshot, err := s.GetSnapshot(businessID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setPinnedSnapshot(businessID, &snapshot.ID); err != nil {
+		return nil, err
+	}
+	return s.GetBusinessByID(businessID)
+}
+
+// UnpinSnapshot shows investors the live profile again
+func (s *BusinessService) UnpinSnapshot(businessID uint) (*models.Business, error) {
+	if err := s.setPinnedSnapshot(businessID, nil); err != nil {
+		return nil, err
+	}
+	return s.GetBusinessByID(businessID)
+}
+
+func (s *BusinessService) setPinnedSnapshot(businessID uint, snapshotID *uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Business
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, businessID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Business{}).Where("id = ?", businessID).Update("pinned_snapshot_id", snapshotID).Error; err != nil {
+			return err
+		}
+		var after models.Business
+		if err := tx.First(&after, businessID).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, businessID, models.AuditEntityBusiness, businessID, models.AuditActionUpdate, &before, &after)
+	})
+}
+
+// GetListedBusinessSnapshot renders a snapshot of a listed business for investors
+func (s *BusinessService) GetListedBusinessSnapshot(businessID, snapshotID uint) (*models.Business, error) {
+	if err := s.DB.Scopes(models.ListedBusinesses).Select("id").First(&models.Business{}, businessID).Error; err != nil {
+		return nil, err
+	}
+	snapshot, err := s.GetSnapshot(businessID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Business(), nil
+}
+
+// latestSnapshotRef returns the newest snapshot of the business, or nil when it has none
+func latestSnapshotRef(db *gorm.DB, businessID uint) (*models.BusinessSnapshotRef, error) {
+	var snapshot models.BusinessSnapshot
+	err := db.Omit("data").Where("business_id = ?", businessID).Order("number DESC").First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Ref(), nil
+}
+
+// BackfillSnapshots takes a first snapshot of the businesses that have none, which were
+// stored before snapshots were introduced. Every later change takes its own, and deleted
+// businesses get theirs when restored.
+func BackfillSnapshots(db *gorm.DB) error {
+	var businessIDs []uint
+	if err := db.Model(&models.Business{}).
+		Where("id NOT IN (?)", db.Model(&models.BusinessSnapshot{}).Select("business_id")).
+		Pluck("id", &businessIDs).Error; err != nil {
+		return err
+	}
+
+	s := &BusinessService{DB: db}
+	for _, businessID := range businessIDs {
+		if err := db.Transaction(func(tx *gorm.DB) error { return s.snapshot(tx, businessID) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreSnapshot brings the profile back to what the snapshot captured: basic info and
+// custom field values, products and legal documents still on record, the latest financial
+// figures and the projections. Financial history is kept and the restored figures become
+// the latest record. When expectedVersion is given the restore only goes through if the
+// basic info has not changed since the client read that version.
+func (s *BusinessService) RestoreSnapshot(businessID, snapshotID uint, expectedVersion *uint) (*models.Business, error) {
+	snapshot, err := s.GetSnapshot(businessID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	data := snapshot.Data
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Business
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, businessID).Error; err != nil {
+			return err
+		}
+		if expectedVersion != nil && before.Version != *expectedVersion {
+			return ErrVersionConflict
+		}
+
+		// Basic info always moves to a new version so clients holding the old ETag notice
+		if err := tx.Model(&models.Business{}).Where("id = ?", businessID).Updates(map[string]interface{}{
+			"name":        data.Name,
+			"type":        data.Type,
+			"description": data.Description,
+			"industry":    data.Industry,
+			"founded_at":  data.FoundedAt,
+			"version":     gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		var after models.Business
+		if err := tx.First(&after, businessID).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityBusiness, businessID, models.AuditActionUpdate, &before, &after); err != nil {
+			return err
+		}
+		if err := restoreCustomFieldValues(tx, businessID, models.CustomFieldEntityBusiness, businessID, data.CustomFields); err != nil {
+			return err
+		}
+
+		if err := s.restoreProducts(tx, businessID, data.Products); err != nil {
+			return err
+		}
+		if err := s.restoreLegals(tx, businessID, data.Legals); err != nil {
+			return err
+		}
+		if err := s.restoreFinancial(tx, businessID, data.Financial); err != nil {
+			return err
+		}
+		if err := s.restoreProjections(tx, businessID, data.Projections); err != nil {
+			return err
+		}
+
+		_, err := s.takeSnapshot(tx, businessID, models.SnapshotTriggerRestore, fmt.Sprintf("Restored from snapshot #%d", snapshot.Number), &snapshot.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBusinessByID(businessID)
+}
+
+func (s *BusinessService) restoreProducts(tx *gorm.DB, businessID uint, products []models.SnapshotProduct) error {
+	var current []models.Product
+	if err := tx.Unscoped().Where("business_id = ?", businessID).Find(&current).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]models.Product, len(current))
+	for _, p := range current {
+		byID[p.ID] = p
+	}
+
+	keep := make(map[uint]bool, len(products))
+	for _, snap := range products {
+		existing, ok := byID[snap.ID]
+		if !ok {
+			// Purged since the snapshot, so it comes back as a new product without its permits
+			product := snap.Product(businessID)
+			if err := tx.Create(&product).Error; err != nil {
+				return err
+			}
+			keep[product.ID] = true
+			if err := s.audit(tx, businessID, models.AuditEntityProduct, product.ID, models.AuditActionCreate, nil, &product); err != nil {
+				return err
+			}
+			continue
+		}
+
+		keep[snap.ID] = true
+		if existing.DeletedAt.Valid || !sameSnapshotProduct(snapshotProduct(existing), snap) {
+			restored := snap.Product(businessID)
+			if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", snap.ID).
+				Select(append([]string{"deleted_at"}, productCatalogColumns...)).
+				Updates(&restored).Error; err != nil {
+				return err
+			}
+			var after models.Product
+			if err := tx.First(&after, snap.ID).Error; err != nil {
+				return err
+			}
+			action := models.AuditActionUpdate
+			if existing.DeletedAt.Valid {
+				action = models.AuditActionCreate
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityProduct, snap.ID, action, &existing, &after); err != nil {
+				return err
+			}
+		}
+		if err := s.restoreProductLegals(tx, businessID, snap.ID, snap.Legals); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range current {
+		if keep[p.ID] || p.DeletedAt.Valid {
+			continue
+		}
+		product := p
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityProduct, p.ID, models.AuditActionDelete, &p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotProduct captures the catalog fields of a product, without its permits
+func snapshotProduct(p models.Product) models.SnapshotProduct {
+	return models.SnapshotProduct{
+		ID:                 p.ID,
+		Name:               p.Name,
+		Description:        p.Description,
+		Category:           p.Category,
+		SKU:                p.SKU,
+		UnitPrice:          p.UnitPrice,
+		Currency:           p.Currency,
+		ProductionCapacity: p.ProductionCapacity,
+		CapacityUnit:       p.CapacityUnit,
+		Images:             p.Images,
+		Variants:           p.Variants,
+	}
+}
+
+// sameSnapshotProduct compares the catalog fields as they are stored, so a list that is
+// empty on one side and missing on the other counts as equal
+func sameSnapshotProduct(a, b models.SnapshotProduct) bool {
+	a.Legals, b.Legals = nil, nil
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(rawA) == string(rawB)
+}
+
+func (s *BusinessService) restoreProductLegals(tx *gorm.DB, businessID, productID uint, documents []models.SnapshotDocument) error {
+	var current []models.ProductLegal
+	if err := tx.Unscoped().Where("product_id = ?", productID).Find(&current).Error; err != nil {
+		return err
+	}
+
+	wanted := make(map[uint]models.SnapshotDocument, len(documents))
+	for _, d := range documents {
+		wanted[d.ID] = d
+	}
+
+	for _, legal := range current {
+		before := legal
+		doc, ok := wanted[legal.ID]
+		switch {
+		case ok:
+			changes := documentChanges(doc, legal.DeletedAt.Valid, legal.FileURL, legal.LegalType, legal.IssuedBy, legal.Notes, legal.IssuedAt, legal.ValidUntil, legal.SupersedesID)
+			if len(changes) == 0 {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.ProductLegal{}).Where("id = ?", legal.ID).Updates(changes).Error; err != nil {
+				return err
+			}
+			if _, ok := changes["file_url"]; ok {
+				if err := s.keepReplacedFile(tx, businessID, models.AuditEntityProductLegal, legal.ID, legal.FileName, legal.FileURL, doc.FileURL); err != nil {
+					return err
+				}
+			}
+			var after models.ProductLegal
+			if err := tx.First(&after, legal.ID).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityProductLegal, legal.ID, models.AuditActionUpdate, &before, &after); err != nil {
+				return err
+			}
+		case !legal.DeletedAt.Valid:
+			if err := tx.Delete(&legal).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityProductLegal, legal.ID, models.AuditActionDelete, &before, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *BusinessService) restoreLegals(tx *gorm.DB, businessID uint, documents []models.SnapshotDocument) error {
+	var current []models.Legal
+	if err := tx.Unscoped().Where("business_id = ?", businessID).Find(&current).Error; err != nil {
+		return err
+	}
+
+	wanted := make(map[uint]models.SnapshotDocument, len(documents))
+	for _, d := range documents {
+		wanted[d.ID] = d
+	}
+
+	for _, legal := range current {
+		before := legal
+		doc, ok := wanted[legal.ID]
+		switch {
+		case ok:
+			changes := documentChanges(doc, legal.DeletedAt.Valid, legal.FileURL, legal.LegalType, legal.IssuedBy, legal.Notes, legal.IssuedAt, legal.ValidUntil, legal.SupersedesID)
+			if len(changes) > 0 {
+				if err := tx.Unscoped().Model(&models.Legal{}).Where("id = ?", legal.ID).Updates(changes).Error; err != nil {
+					return err
+				}
+				if _, ok := changes["file_url"]; ok {
+					if err := s.keepReplacedFile(tx, businessID, models.AuditEntityLegal, legal.ID, legal.FileName, legal.FileURL, doc.FileURL); err != nil {
+						return err
+					}
+				}
+				var after models.Legal
+				if err := tx.First(&after, legal.ID).Error; err != nil {
+					return err
+				}
+				if err := s.audit(tx, businessID, models.AuditEntityLegal, legal.ID, models.AuditActionUpdate, &before, &after); err != nil {
+					return err
+				}
+			}
+			if err := restoreCustomFieldValues(tx, businessID, models.CustomFieldEntityLegal, legal.ID, doc.CustomFields); err != nil {
+				return err
+			}
+		case !legal.DeletedAt.Valid:
+			if err := tx.Delete(&legal).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityLegal, legal.ID, models.AuditActionDelete, &before, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *BusinessService) restoreFinancial(tx *gorm.DB, businessID uint, snap *models.SnapshotFinancial) error {
+	// Financial history is never rewritten, a snapshot without figures leaves it alone
+	if snap == nil {
+		return nil
+	}
+
+	var latest models.Financial
+	err := tx.Where("business_id = ?", businessID).Order("created_at DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && latest.Revenue == snap.Revenue && latest.EBITDA == snap.EBITDA && latest.Assets == snap.Assets &&
+		latest.Liabilities == snap.Liabilities && latest.Equity == snap.Equity &&
+		latest.ReportFileURL == snap.ReportFileURL && latest.Notes == snap.Notes {
+		return restoreCustomFieldValues(tx, businessID, models.CustomFieldEntityFinancial, latest.ID, snap.CustomFields)
+	}
+
+	financial := models.Financial{
+		BusinessID:    businessID,
+		Revenue:       snap.Revenue,
+		EBITDA:        snap.EBITDA,
+		Assets:        snap.Assets,
+		Liabilities:   snap.Liabilities,
+		Equity:        snap.Equity,
+		ReportFileURL: snap.ReportFileURL,
+		Notes:         snap.Notes,
+	}
+	if err := tx.Create(&financial).Error; err != nil {
+		return err
+	}
+	if err := s.audit(tx, businessID, models.AuditEntityFinancial, financial.ID, models.AuditActionCreate, nil, &financial); err != nil {
+		return err
+	}
+	return restoreCustomFieldValues(tx, businessID, models.CustomFieldEntityFinancial, financial.ID, snap.CustomFields)
+}
+
+func (s *BusinessService) restoreProjections(tx *gorm.DB, businessID uint, projections []models.SnapshotProjection) error {
+	var before, after projectionsAudit
+	if err := tx.Where("business_id = ?", businessID).Order("year ASC").Find(&before.Projections).Error; err != nil {
+		return err
+	}
+
+	current := make([]models.SnapshotProjection, 0, len(before.Projections))
+	for _, p := range before.Projections {
+		current = append(current, snapshotProjection(p))
+	}
+	if reflect.DeepEqual(current, projections) || (len(current) == 0 && len(projections) == 0) {
+		return nil
+	}
+
+	if err := tx.Where("business_id = ?", businessID).Delete(&models.HistoricalProjection{}).Error; err != nil {
+		return err
+	}
+	for _, p := range projections {
+		projection := models.HistoricalProjection{
+			BusinessID: businessID,
+			Year:       p.Year,
+			Revenue:    p.Revenue,
+			Expenses:   p.Expenses,
+			NetIncome:  p.NetIncome,
+			CashFlow:   p.CashFlow,
+		}
+		if err := tx.Create(&projection).Error; err != nil {
+			return err
+		}
+		after.Projections = append(after.Projections, projection)
+	}
+	return s.audit(tx, businessID, models.AuditEntityProjections, businessID, models.AuditActionUpdate, &before, &after)
+}
+
+// restoreCustomFieldValues sets the entity's custom field values to the snapshot's. Fields
+// deleted since are skipped, as are values that no longer fit the field's settings.
+func restoreCustomFieldValues(tx *gorm.DB, businessID uint, entityType string, entityID uint, fields []models.CustomField) error {
+	var definitions []models.CustomFieldDefinition
+	if err := tx.Where("business_id = ? AND entity_type = ?", businessID, entityType).Find(&definitions).Error; err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		values[f.Key] = f.Value
+	}
+
+	for i := range definitions {
+		definition := &definitions[i]
+		value, ok := values[definition.Key]
+		if !ok || value == nil {
+			if definition.Required {
+				continue
+			}
+			if err := tx.Where("definition_id = ? AND entity_id = ?", definition.ID, entityID).
+				Delete(&models.CustomFieldValue{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		normalized, err := normalizeCustomFieldValue(definition, value)
+		if err != nil {
+			continue
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "definition_id"}, {Name: "entity_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&models.CustomFieldValue{
+			DefinitionID: definition.ID,
+			BusinessID:   businessID,
+			EntityType:   entityType,
+			EntityID:     entityID,
+			Value:        normalized,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documentChanges returns the columns to update so a legal document matches the snapshot.
+// A file replaced since the snapshot is still kept as a version, so it is brought back too.
+func documentChanges(doc models.SnapshotDocument, deleted bool, fileURL, legalType, issuedBy, notes string, issuedAt, validUntil *time.Time, supersedesID *uint) map[string]interface{} {
+	changes := map[string]interface{}{}
+	if deleted {
+		changes["deleted_at"] = nil
+	}
+	if doc.FileURL != "" && doc.FileURL != fileURL {
+		changes["file_name"] = doc.FileName
+		changes["file_url"] = doc.FileURL
+	}
+	if doc.LegalType != legalType {
+		changes["legal_type"] = doc.LegalType
+	}
+	if doc.IssuedBy != issuedBy {
+		changes["issued_by"] = doc.IssuedBy
+	}
+	if doc.Notes != notes {
+		changes["notes"] = doc.Notes
+	}
+	if !sameTime(doc.IssuedAt, issuedAt) {
+		changes["issued_at"] = doc.IssuedAt
+	}
+	if !sameTime(doc.ValidUntil, validUntil) {
+		changes["valid_until"] = doc.ValidUntil
+	}
+	if !sameID(doc.SupersedesID, supersedesID) {
+		changes["supersedes_id"] = doc.SupersedesID
+	}
+	return changes
+}
+
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// snapshot records the profile after a change, unless it matches the latest snapshot
+func (s *BusinessService) snapshot(tx *gorm.DB, businessID uint) error {
+	_, err := s.takeSnapshot(tx, businessID, models.SnapshotTriggerChange, "", nil)
+	return err
+}
+
+// takeSnapshot captures the business as the transaction sees it. Change snapshots are
+// skipped when nothing differs from the latest one, in which case it returns nil.
+func (s *BusinessService) takeSnapshot(tx *gorm.DB, businessID uint, trigger, label string, restoredFromID *uint) (*models.BusinessSnapshot, error) {
+	// Serialize snapshots of the business so numbers stay sequential
+	var business models.Business
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&business, businessID).Error; err != nil {
+		return nil, err
+	}
+
+	data, version, err := buildSnapshotData(tx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	checksum := hex.EncodeToString(sum[:])
+
+	var latest models.BusinessSnapshot
+	err = tx.Omit("data").Where("business_id = ?", businessID).Order("number DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if trigger == models.SnapshotTriggerChange && err == nil && latest.Checksum == checksum {
+		return nil, nil
+	}
+
+	snapshot := &models.BusinessSnapshot{
+		BusinessID:      businessID,
+		Number:          latest.Number + 1,
+		BusinessVersion: version,
+		Trigger:         trigger,
+		Label:           label,
+		RestoredFromID:  restoredFromID,
+		Checksum:        checksum,
+		Data:            *data,
+	}
+	if s.Actor.UserID != 0 {
+		actorID := s.Actor.UserID
+		snapshot.CreatedByID = &actorID
+	}
+	if err := tx.Create(snapshot).Error; err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// buildSnapshotData loads the business aggregate in a stable order and returns it with the
+// business version
+func buildSnapshotData(db *gorm.DB, businessID uint) (*models.BusinessSnapshotData, uint, error) {
+	businesses := make([]models.Business, 1)
+	business := &businesses[0]
+	if err := db.
+		Preload("Products", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Products.ProductLegals", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Legals", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Financials", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC, id DESC").Limit(1) }).
+		First(business, businessID).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(business.Financials) > 0 {
+		business.Financial = &business.Financials[0]
+	}
+	if err := attachCustomFields(db, businesses); err != nil {
+		return nil, 0, err
+	}
+
+	var projections []models.HistoricalProjection
+	if err := db.Where("business_id = ?", businessID).Order("year ASC").Find(&projections).Error; err != nil {
+		return nil, 0, err
+	}
+
+	data := &models.BusinessSnapshotData{
+		Name:         business.Name,
+		Type:         business.Type,
+		Description:  business.Description,
+		Industry:     business.Industry,
+		FoundedAt:    business.FoundedAt,
+		CustomFields: filledCustomFields(business.CustomFields),
+		Products:     []models.SnapshotProduct{},
+		Legals:       []models.SnapshotDocument{},
+	}
+	for _, p := range business.Products {
+		product := snapshotProduct(p)
+		for _, l := range p.ProductLegals {
+			product.Legals = append(product.Legals, models.SnapshotDocument{
+				ID:           l.ID,
+				FileName:     l.FileName,
+				FileURL:      l.FileURL,
+				LegalType:    l.LegalType,
+				IssuedBy:     l.IssuedBy,
+				IssuedAt:     l.IssuedAt,
+				ValidUntil:   l.ValidUntil,
+				Notes:        l.Notes,
+				SupersedesID: l.SupersedesID,
+			})
+		}
+		data.Products = append(data.Products, product)
+	}
+	for _, l := range business.Legals {
+		data.Legals = append(data.Legals, models.SnapshotDocument{
+			ID:           l.ID,
+			FileName:     l.FileName,
+			FileURL:      l.FileURL,
+			LegalType:    l.LegalType,
+			IssuedBy:     l.IssuedBy,
+			IssuedAt:     l.IssuedAt,
+			ValidUntil:   l.ValidUntil,
+			Notes:        l.Notes,
+			SupersedesID: l.SupersedesID,
+			CustomFields: filledCustomFields(l.CustomFields),
+		})
+	}
+	if f := business.Financial; f != nil {
+		data.Financial = &models.SnapshotFinancial{
+			ID:            f.ID,
+			Revenue:       f.Revenue,
+			EBITDA:        f.EBITDA,
+			Assets:        f.Assets,
+			Liabilities:   f.Liabilities,
+			Equity:        f.Equity,
+			ReportFileURL: f.ReportFileURL,
+			Notes:         f.Notes,
+			CustomFields:  filledCustomFields(f.CustomFields),
+		}
+	}
+	for _, p := range projections {
+		data.Projections = append(data.Projections, snapshotProjection(p))
+	}
+
+	return data, business.Version, nil
+}
+
+func snapshotProjection(p models.HistoricalProjection) models.SnapshotProjection {
+	return models.SnapshotProjection{
+		Year:      p.Year,
+		Revenue:   p.Revenue,
+		Expenses:  p.Expenses,
+		NetIncome: p.NetIncome,
+		CashFlow:  p.CashFlow,
+	}
+}
+
+// filledCustomFields drops fields without a value, so defining a field alone is not a change
+func filledCustomFields(fields []models.CustomField) []models.CustomField {
+	var filled []models.CustomField
+	for _, f := range fields {
+		if f.Value != nil {
+			filled = append(filled, f)
+		}
+	}
+	return filled
+}
+
+// diffSnapshotData compares two snapshots value by value. List elements are matched by their
+// id, key or year so reordering is not reported as a change.
+func diffSnapshotData(before, after models.BusinessSnapshotData) ([]models.SnapshotChange, error) {
+	beforeValues, err := flattenSnapshotData(before)
+	if err != nil {
+		return nil, err
+	}
+	afterValues, err := flattenSnapshotData(after)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(beforeValues)+len(afterValues))
+	for path := range mergeKeys(beforeValues, afterValues) {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	changes := []models.SnapshotChange{}
+	for _, path := range paths {
+		b, a := beforeValues[path], afterValues[path]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		changes = append(changes, models.SnapshotChange{Path: path, Before: b, After: a})
+	}
+	return changes, nil
+}
+
+func flattenSnapshotData(data models.BusinessSnapshotData) (map[string]interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	flattenSnapshotValue("", value, values)
+	return values, nil
+}
+
+func flattenSnapshotValue(path string, value interface{}, values map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenSnapshotValue(childPath, child, values)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenSnapshotValue(path+snapshotElementKey(child, i), child, values)
+		}
+	default:
+		values[path] = v
+	}
+}
+
+func snapshotElementKey(element interface{}, index int) string {
+	if fields, ok := element.(map[string]interface{}); ok {
+		for _, identity := range []string{"id", "key", "year", "name", "url"} {
+			switch id := fields[identity].(type) {
+			case float64:
+				return fmt.Sprintf("[%s=%s]", identity, strconv.FormatFloat(id, 'f', -1, 64))
+			case string:
+				return fmt.Sprintf("[%s=%s]", identity, id)
+			}
+		}
+	}
+	return fmt.Sprintf("[%d]", index)
+}
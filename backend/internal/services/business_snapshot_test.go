@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"testing"
+)
+
+func countSnapshots(t *testing.T, s *BusinessService, businessID uint) int64 {
+	t.Helper()
+
+	var count int64
+	if err := s.DB.Model(&models.BusinessSnapshot{}).Where("business_id = ?", businessID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestBackfillSnapshots(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara") // Stored without a snapshot
+	s := NewBusinessService(db)
+
+	// Reads do not write anything
+	listed, err := s.GetListedBusinessByID(business.ID)
+	if err != nil {
+		t.Fatalf("GetListedBusinessByID: %v", err)
+	}
+	if _, total, err := s.ListSnapshots(business.ID, 1, 20); err != nil || total != 0 {
+		t.Fatalf("ListSnapshots() total = %d, error = %v; want no snapshots", total, err)
+	}
+	if listed.Snapshot != nil || countSnapshots(t, s, business.ID) != 0 {
+		t.Fatalf("reading the business took a snapshot")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := BackfillSnapshots(db); err != nil {
+			t.Fatalf("BackfillSnapshots: %v", err)
+		}
+	}
+	snapshots, total, err := s.ListSnapshots(business.ID, 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || snapshots[0].Number != 1 || snapshots[0].Trigger != models.SnapshotTriggerChange {
+		t.Fatalf("ListSnapshots() = %+v, %d; want one first snapshot", snapshots, total)
+	}
+
+	listed, err = s.GetListedBusinessByID(business.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.Snapshot == nil || listed.Snapshot.ID != snapshots[0].ID || listed.Snapshot.Pinned {
+		t.Errorf("listed business points at snapshot %+v, want %d", listed.Snapshot, snapshots[0].ID)
+	}
+}
+
+func TestPinSnapshot(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+	s := NewBusinessService(db)
+
+	evaluated, err := s.CreateSnapshot(business.ID, "Sent to investors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(business).Update("name", "Kopi Nusantara Group").Error; err != nil {
+		t.Fatal(err)
+	}
+	latest, err := s.CreateSnapshot(business.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, err := s.PinSnapshot(business.ID, evaluated.ID)
+	if err != nil {
+		t.Fatalf("PinSnapshot: %v", err)
+	}
+	if pinned.PinnedSnapshotID == nil || *pinned.PinnedSnapshotID != evaluated.ID {
+		t.Errorf("PinSnapshot() pinned %v, want snapshot %d", pinned.PinnedSnapshotID, evaluated.ID)
+	}
+
+	listed, err := s.GetListedBusinessByID(business.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.Name != "Kopi Nusantara" || listed.Snapshot == nil || listed.Snapshot.ID != evaluated.ID || !listed.Snapshot.Pinned {
+		t.Errorf("investors see %q from snapshot %+v, want the pinned snapshot %d", listed.Name, listed.Snapshot, evaluated.ID)
+	}
+
+	if _, err := s.UnpinSnapshot(business.ID); err != nil {
+		t.Fatalf("UnpinSnapshot: %v", err)
+	}
+	listed, err = s.GetListedBusinessByID(business.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.Name != "Kopi Nusantara Group" || listed.Snapshot == nil || listed.Snapshot.ID != latest.ID || listed.Snapshot.Pinned {
+		t.Errorf("investors see %q from snapshot %+v, want the live profile", listed.Name, listed.Snapshot)
+	}
+
+	var changes int64
+	if err := db.Model(&models.AuditLog{}).
+		Where("entity_type = ? AND entity_id = ? AND action = ?", models.AuditEntityBusiness, business.ID, models.AuditActionUpdate).
+		Count(&changes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if changes != 2 {
+		t.Errorf("%d business updates were audited, want the pin and the unpin", changes)
+	}
+
+	other := createTestBusiness(t, db, owner, "Teh Manis")
+	if _, err := s.PinSnapshot(other.ID, evaluated.ID); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("pinning another business's snapshot: error = %v, want %v", err, ErrSnapshotNotFound)
+	}
+}
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/storage"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTrashRetentionDays = 30
+	trashPurgeInterval        = time.Hour
+)
+
+// Entity types that can be restored from a business's trash
+const (
+	TrashEntityProduct      = "product"
+	TrashEntityLegal        = "legal"
+	TrashEntityProductLegal = "product_legal"
+)
+
+var (
+	// ErrNotInTrash is returned when restoring something that is not deleted
+	ErrNotInTrash = errors.New("item is not in the trash")
+	// ErrParentInTrash is returned when restoring an item whose parent is still deleted
+	ErrParentInTrash = errors.New("restore the item's parent first")
+)
+
+// TrashItem is a deleted business, product or document that can still be restored
+type TrashItem struct {
+	EntityType string    `json:"entity_type"`
+	EntityID   uint      `json:"entity_id"`
+	BusinessID uint      `json:"business_id"`
+	ProductID  uint      `json:"product_id,omitempty"` // For product legals
+	Name       string    `json:"name"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAt    time.Time `json:"purge_at"` // When it is deleted for good
+}
+
+// TrashRetention is how long deleted items stay restorable, from TRASH_RETENTION_DAYS
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// trashTimestamp is the deletion time shared by a parent and the children deleted with it,
+// at the database's microsecond precision so restores can match them exactly
+func trashTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// ListTrashedBusinesses returns the deleted businesses the user owns
+func (s *BusinessService) ListTrashedBusinesses(userID uint) ([]TrashItem, error) {
+	var businesses []models.Business
+	if err := s.DB.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where("id IN (?)", s.DB.Model(&models.BusinessMember{}).Select("business_id").
+			Where("user_id = ? AND role = ?", userID, models.BusinessRoleOwner)).
+		Order("deleted_at DESC").
+		Find(&businesses).Error; err != nil {
+		return nil, err
+	}
+
+	retention := TrashRetention()
+	items := make([]TrashItem, 0, len(businesses))
+	for _, b := range businesses {
+		items = append(items, TrashItem{
+			EntityType: models.AuditEntityBusiness,
+			EntityID:   b.ID,
+			BusinessID: b.ID,
+			Name:       b.Name,
+			DeletedAt:  b.DeletedAt.Time,
+			PurgeAt:    b.DeletedAt.Time.Add(retention),
+		})
+	}
+	return items, nil
+}
+
+// ListBusinessTrash returns the products and documents deleted from a business. Documents
+// deleted together with their product are restored with it and not listed separately.
+func (s *BusinessService) ListBusinessTrash(businessID uint) ([]TrashItem, error) {
+	retention := TrashRetention()
+	var items []TrashItem
+
+	var products []models.Product
+	if err := s.DB.Unscoped().Where("business_id = ? AND deleted_at IS NOT NULL", businessID).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	deletedProducts := make(map[uint]bool, len(products))
+	for _, p := range products {
+		deletedProducts[p.ID] = true
+		items = append(items, TrashItem{
+			EntityType: TrashEntityProduct,
+			EntityID:   p.ID,
+			BusinessID: businessID,
+			Name:       p.Name,
+			DeletedAt:  p.DeletedAt.Time,
+			PurgeAt:    p.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	var legals []models.Legal
+	if err := s.DB.Unscoped().Where("business_id = ? AND deleted_at IS NOT NULL", businessID).Find(&legals).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range legals {
+		items = append(items, TrashItem{
+			EntityType: TrashEntityLegal,
+			EntityID:   l.ID,
+			BusinessID: businessID,
+			Name:       documentName(l.LegalType, l.FileName),
+			DeletedAt:  l.DeletedAt.Time,
+			PurgeAt:    l.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	var productLegals []models.ProductLegal
+	if err := s.DB.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where("product_id IN (?)", s.DB.Unscoped().Model(&models.Product{}).Select("id").Where("business_id = ?", businessID)).
+		Find(&productLegals).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range productLegals {
+		if deletedProducts[l.ProductID] {
+			continue
+		}
+		items = append(items, TrashItem{
+			EntityType: TrashEntityProductLegal,
+			EntityID:   l.ID,
+			BusinessID: businessID,
+			ProductID:  l.ProductID,
+			Name:       documentName(l.LegalType, l.FileName),
+			DeletedAt:  l.DeletedAt.Time,
+			PurgeAt:    l.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	if items == nil {
+		items = []TrashItem{}
+	}
+	return items, nil
+}
+
+// AuthorizeTrashedBusiness checks that the business is deleted and the user owns it. It
+// returns gorm.ErrRecordNotFound when the business is not in the trash.
+func (s *BusinessService) AuthorizeTrashedBusiness(userID, businessID uint) error {
+	var business models.Business
+	if err := s.DB.Unscoped().Select("id").Where("deleted_at IS NOT NULL").First(&business, businessID).Error; err != nil {
+		return err
+	}
+
+	var member models.BusinessMember
+	if err := s.DB.Where("business_id = ? AND user_id = ?", businessID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBusinessAccessDenied
+		}
+		return err
+	}
+	if member.Role != models.BusinessRoleOwner {
+		return ErrBusinessAccessDenied
+	}
+	return nil
+}
+
+// RestoreTrashedBusiness brings back a deleted business together with the products,
+// documents, financials and projections deleted with it
+func (s *BusinessService) RestoreTrashedBusiness(businessID uint) (*models.Business, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var business models.Business
+		if err := tx.Unscoped().First(&business, businessID).Error; err != nil {
+			return err
+		}
+		if !business.DeletedAt.Valid {
+			return ErrNotInTrash
+		}
+		deletedAt := business.DeletedAt.Time
+
+		var productIDs []uint
+		if err := tx.Unscoped().Model(&models.Product{}).
+			Where("business_id = ? AND deleted_at = ?", businessID, deletedAt).
+			Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+
+		restores := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&models.ProductLegal{}, "product_id IN (?)", []interface{}{tx.Unscoped().Model(&models.Product{}).Select("id").Where("business_id = ?", businessID)}},
+			{&models.Product{}, "business_id = ?", []interface{}{businessID}},
+			{&models.Legal{}, "business_id = ?", []interface{}{businessID}},
+			{&models.Financial{}, "business_id = ?", []interface{}{businessID}},
+			{&models.HistoricalProjection{}, "business_id = ?", []interface{}{businessID}},
+			{&models.Business{}, "id = ?", []interface{}{businessID}},
+		}
+		for _, r := range restores {
+			if err := restoreDeletedAt(tx, r.model, deletedAt, r.query, r.args...); err != nil {
+				return err
+			}
+		}
+
+		var after models.Business
+		if err := tx.First(&after, businessID).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityBusiness, businessID, models.AuditActionRestore, &business, &after); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBusinessByID(businessID)
+}
+
+// RestoreTrashedItem brings back a product, with the documents deleted together with it, or
+// a single document of the business
+func (s *BusinessService) RestoreTrashedItem(businessID uint, entityType string, entityID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		switch entityType {
+		case TrashEntityProduct:
+			var product models.Product
+			if err := tx.Unscoped().Where("id = ? AND business_id = ?", entityID, businessID).First(&product).Error; err != nil {
+				return err
+			}
+			if !product.DeletedAt.Valid {
+				return ErrNotInTrash
+			}
+			deletedAt := product.DeletedAt.Time
+			if err := restoreDeletedAt(tx, &models.ProductLegal{}, deletedAt, "product_id = ?", entityID); err != nil {
+				return err
+			}
+			if err := restoreDeletedAt(tx, &models.Product{}, deletedAt, "id = ?", entityID); err != nil {
+				return err
+			}
+			var after models.Product
+			if err := tx.First(&after, entityID).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityProduct, entityID, models.AuditActionRestore, &product, &after); err != nil {
+				return err
+			}
+
+		case TrashEntityLegal:
+			var legal models.Legal
+			if err := tx.Unscoped().Where("id = ? AND business_id = ?", entityID, businessID).First(&legal).Error; err != nil {
+				return err
+			}
+			if !legal.DeletedAt.Valid {
+				return ErrNotInTrash
+			}
+			if err := restoreDeletedAt(tx, &models.Legal{}, legal.DeletedAt.Time, "id = ?", entityID); err != nil {
+				return err
+			}
+			var after models.Legal
+			if err := tx.First(&after, entityID).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityLegal, entityID, models.AuditActionRestore, &legal, &after); err != nil {
+				return err
+			}
+
+		case TrashEntityProductLegal:
+			var legal models.ProductLegal
+			if err := tx.Unscoped().
+				Where("id = ? AND product_id IN (?)", entityID, tx.Unscoped().Model(&models.Product{}).Select("id").Where("business_id = ?", businessID)).
+				First(&legal).Error; err != nil {
+				return err
+			}
+			if !legal.DeletedAt.Valid {
+				return ErrNotInTrash
+			}
+			var product models.Product
+			if err := tx.Unscoped().Select("id", "deleted_at").First(&product, legal.ProductID).Error; err != nil {
+				return err
+			}
+			if product.DeletedAt.Valid {
+				return ErrParentInTrash
+			}
+			if err := restoreDeletedAt(tx, &models.ProductLegal{}, legal.DeletedAt.Time, "id = ?", entityID); err != nil {
+				return err
+			}
+			var after models.ProductLegal
+			if err := tx.First(&after, entityID).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, businessID, models.AuditEntityProductLegal, entityID, models.AuditActionRestore, &legal, &after); err != nil {
+				return err
+			}
+
+		default:
+			return &ValidationError{Field: "entity_type", Message: "entity_type must be product, legal or product_legal"}
+		}
+
+		return s.snapshot(tx, businessID)
+	})
+}
+
+// softDeleteAt marks the matching rows deleted at the given time
+func softDeleteAt(tx *gorm.DB, model interface{}, deletedAt time.Time, query string, args ...interface{}) error {
+	return tx.Model(model).Where(query, args...).Update("deleted_at", deletedAt).Error
+}
+
+// restoreDeletedAt undeletes the matching rows that were deleted at exactly the given time
+func restoreDeletedAt(tx *gorm.DB, model interface{}, deletedAt time.Time, query string, args ...interface{}) error {
+	return tx.Unscoped().Model(model).
+		Where(query, args...).
+		Where("deleted_at = ?", deletedAt).
+		Update("deleted_at", nil).Error
+}
+
+// PurgeExpiredTrash permanently deletes businesses, products and documents that have been in
+// the trash since before the cutoff, along with their uploaded files
+func PurgeExpiredTrash(db *gorm.DB, store storage.Storage, cutoff time.Time) (int, error) {
+	var files []string
+	purged := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		unscoped := tx.Unscoped().Session(&gorm.Session{})
+
+		var businessIDs []uint
+		if err := unscoped.Model(&models.Business{}).Where("deleted_at < ?", cutoff).Pluck("id", &businessIDs).Error; err != nil {
+			return err
+		}
+		for _, businessID := range businessIDs {
+			id := businessID
+			if err := recordAudit(tx, AuditActor{Reason: "trash retention expired"}, &id, models.AuditEntityBusiness, id, models.AuditActionDelete, nil, nil); err != nil {
+				return err
+			}
+		}
+		removed, err := purgeBusinesses(tx, businessIDs)
+		if err != nil {
+			return err
+		}
+		files = append(files, removed...)
+		purged += len(businessIDs)
+
+		// Products and documents deleted on their own from businesses that are still around
+		var productIDs, productLegalIDs, legalIDs, financialIDs []uint
+		plucks := []struct {
+			model  interface{}
+			target *[]uint
+		}{
+			{&models.Product{}, &productIDs},
+			{&models.ProductLegal{}, &productLegalIDs},
+			{&models.Legal{}, &legalIDs},
+			{&models.Financial{}, &financialIDs},
+		}
+		for _, p := range plucks {
+			if err := unscoped.Model(p.model).Where("deleted_at < ?", cutoff).Pluck("id", p.target).Error; err != nil {
+				return err
+			}
+		}
+
+		removed, err = purgeRecords(tx, productIDs, productLegalIDs, legalIDs, financialIDs)
+		if err != nil {
+			return err
+		}
+		files = append(files, removed...)
+		purged += len(productIDs) + len(productLegalIDs) + len(legalIDs) + len(financialIDs)
+
+		// Projections are replaced on every save, the old rows have nothing to restore
+		result := unscoped.Where("deleted_at < ?", cutoff).Delete(&models.HistoricalProjection{})
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removeUploads(store, files)
+	return purged, nil
+}
+
+// purgeRecords hard-deletes products, product legals, legals and financials with what hangs
+// off them, returning the storage keys of the uploads to remove once the transaction commits
+func purgeRecords(tx *gorm.DB, productIDs, productLegalIDs, legalIDs, financialIDs []uint) ([]string, error) {
+	tx = tx.Unscoped().Session(&gorm.Session{})
+
+	var missingProductLegalIDs []uint
+	if len(productIDs) > 0 {
+		if err := tx.Model(&models.MissingProductLegal{}).Where("product_id IN ?", productIDs).Pluck("id", &missingProductLegalIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var fileURLs []string
+	urls := []struct {
+		model  interface{}
+		column string
+		query  string
+		ids    []uint
+	}{
+		{&models.ProductLegal{}, "file_url", "product_id IN ?", productIDs},
+		{&models.ProductLegal{}, "file_url", "id IN ?", productLegalIDs},
+		{&models.Legal{}, "file_url", "id IN ?", legalIDs},
+		{&models.Financial{}, "report_file_url", "id IN ?", financialIDs},
+	}
+	for _, u := range urls {
+		if len(u.ids) == 0 {
+			continue
+		}
+		var found []string
+		if err := tx.Model(u.model).Where(u.query, u.ids).Pluck(u.column, &found).Error; err != nil {
+			return nil, err
+		}
+		fileURLs = append(fileURLs, found...)
+	}
+	if len(productIDs) > 0 {
+		imageURLs, err := productImageFiles(tx, "id IN ?", productIDs)
+		if err != nil {
+			return nil, err
+		}
+		fileURLs = append(fileURLs, imageURLs...)
+	}
+
+	// Earlier files of the documents go with them
+	if len(productIDs) > 0 {
+		var ids []uint
+		if err := tx.Model(&models.ProductLegal{}).Where("product_id IN ?", productIDs).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		productLegalIDs = append(productLegalIDs, ids...)
+	}
+	var versionIDs []uint
+	versions := []struct {
+		entityType string
+		ids        []uint
+	}{
+		{models.AuditEntityProductLegal, productLegalIDs},
+		{models.AuditEntityLegal, legalIDs},
+	}
+	for _, v := range versions {
+		if len(v.ids) == 0 {
+			continue
+		}
+		var found []models.LegalFileVersion
+		if err := tx.Select("id", "file_url").Where("entity_type = ? AND entity_id IN ?", v.entityType, v.ids).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, version := range found {
+			versionIDs = append(versionIDs, version.ID)
+			fileURLs = append(fileURLs, version.FileURL)
+		}
+	}
+
+	// Renewals of the purged documents no longer point at them
+	unlinks := []struct {
+		model interface{}
+		ids   []uint
+	}{
+		{&models.ProductLegal{}, productLegalIDs},
+		{&models.Legal{}, legalIDs},
+	}
+	for _, u := range unlinks {
+		if len(u.ids) == 0 {
+			continue
+		}
+		if err := tx.Model(u.model).Where("supersedes_id IN ?", u.ids).UpdateColumn("supersedes_id", nil).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	deletes := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+		ids   []uint
+	}{
+		{&models.LegalFileVersion{}, "id IN ?", nil, versionIDs},
+		{&models.StepToGetProductLegal{}, "missing_product_legal_id IN ?", nil, missingProductLegalIDs},
+		{&models.MissingProductLegal{}, "id IN ?", nil, missingProductLegalIDs},
+		{&models.ProductLegal{}, "product_id IN ?", nil, productIDs},
+		{&models.ProductLegal{}, "id IN ?", nil, productLegalIDs},
+		{&models.Product{}, "id IN ?", nil, productIDs},
+		{&models.LegalAdditionalInfo{}, "legal_id IN ?", nil, legalIDs},
+		{&models.CustomFieldValue{}, "entity_type = ? AND entity_id IN ?", []interface{}{models.CustomFieldEntityLegal}, legalIDs},
+		{&models.Legal{}, "id IN ?", nil, legalIDs},
+		{&models.FinancialAdditionalInfo{}, "financial_id IN ?", nil, financialIDs},
+		{&models.CustomFieldValue{}, "entity_type = ? AND entity_id IN ?", []interface{}{models.CustomFieldEntityFinancial}, financialIDs},
+		{&models.Financial{}, "id IN ?", nil, financialIDs},
+	}
+	for _, d := range deletes {
+		if len(d.ids) == 0 {
+			continue
+		}
+		args := append(append([]interface{}{}, d.args...), d.ids)
+		if err := tx.Where(d.query, args...).Delete(d.model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	for _, fileURL := range fileURLs {
+		if key, ok := uploadKeyFromURL(fileURL); ok {
+			files = append(files, key)
+		}
+	}
+	return files, nil
+}
+
+// StartTrashPurger purges expired trash right away and then every hour in the background
+func StartTrashPurger(db *gorm.DB, store storage.Storage) {
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			cutoff := time.Now().Add(-TrashRetention())
+			if purged, err := PurgeExpiredTrash(db, store, cutoff); err != nil {
+				log.Printf("Failed to purge expired trash: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired trash items", purged)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestPurgeExpiredTrashRemovesFiles(t *testing.T) {
+	db := newTestDB(t)
+	store := storage.NewLocalStorage(t.TempDir(), uploadURLPrefix, []byte("test-secret"))
+	s := NewBusinessService(db)
+	s.Storage = store
+	create := func(record interface{}) {
+		t.Helper()
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice := createTestUser(t, db, "alice")
+	var purgedFiles, keptFiles []string
+	upload := func(keep bool, format string, args ...interface{}) string {
+		key := fmt.Sprintf(format, args...)
+		if keep {
+			keptFiles = append(keptFiles, key)
+		} else {
+			purgedFiles = append(purgedFiles, key)
+		}
+		return storeTestUpload(t, store, key)
+	}
+
+	// A business deleted with everything in it
+	deleted := createTestBusiness(t, db, alice, "Kopi Nusantara")
+	create(&models.Legal{BusinessID: deleted.ID, FileURL: upload(false, "legal/business/%d/permit.pdf", deleted.ID)})
+	product := &models.Product{BusinessID: deleted.ID, Name: "Arabica", Images: []models.ProductImage{{URL: upload(false, "products/%d/1/front.png", deleted.ID)}}}
+	create(product)
+	create(&models.ProductLegal{ProductID: product.ID, FileURL: upload(false, "legal/products/%d/%d/halal.pdf", deleted.ID, product.ID)})
+	create(&models.Financial{BusinessID: deleted.ID, ReportFileURL: upload(false, "financials/%d/report.pdf", deleted.ID)})
+	check(s.DeleteBusiness(deleted.ID))
+
+	// Documents and products deleted on their own from a business still in use
+	live := createTestBusiness(t, db, alice, "Teh Manis")
+	create(&models.Legal{BusinessID: live.ID, FileURL: upload(true, "legal/business/%d/nib.pdf", live.ID)})
+	renewed := &models.Legal{BusinessID: live.ID, FileURL: upload(false, "legal/business/%d/license.pdf", live.ID)}
+	create(renewed)
+	create(&models.LegalFileVersion{
+		BusinessID: live.ID,
+		EntityType: models.AuditEntityLegal,
+		EntityID:   renewed.ID,
+		FileURL:    upload(false, "legal/business/%d/license-2023.pdf", live.ID),
+	})
+	check(s.DeleteBusinessLegal(live.ID, renewed.ID, false))
+	create(&models.Product{BusinessID: live.ID, Name: "Jasmine", Images: []models.ProductImage{{URL: upload(true, "products/%d/2/jasmine.png", live.ID)}}})
+	discontinued := &models.Product{BusinessID: live.ID, Name: "Oolong", Images: []models.ProductImage{{URL: upload(false, "products/%d/3/oolong.png", live.ID)}}}
+	create(discontinued)
+	check(s.DeleteBusinessProduct(live.ID, discontinued.ID))
+
+	// Everything in the trash so far was deleted before the retention period
+	expiredAt := time.Now().UTC().AddDate(0, 0, -40)
+	for _, model := range []interface{}{&models.Business{}, &models.Product{}, &models.ProductLegal{}, &models.Legal{}, &models.Financial{}} {
+		check(db.Unscoped().Model(model).Where("deleted_at IS NOT NULL").Update("deleted_at", expiredAt).Error)
+	}
+
+	// Deleted recently, it can still be restored
+	recent := createTestBusiness(t, db, alice, "Roti Bakar")
+	create(&models.Legal{BusinessID: recent.ID, FileURL: upload(true, "legal/business/%d/permit.pdf", recent.ID)})
+	check(s.DeleteBusiness(recent.ID))
+
+	purged, err := PurgeExpiredTrash(db, store, time.Now().UTC().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("PurgeExpiredTrash: %v", err)
+	}
+	if purged != 3 {
+		t.Errorf("PurgeExpiredTrash() = %d, want the business, the legal and the product", purged)
+	}
+
+	for _, key := range purgedFiles {
+		if _, _, err := store.Get(key); err == nil {
+			t.Errorf("%s was not removed", key)
+		}
+	}
+	for _, key := range keptFiles {
+		reader, _, err := store.Get(key)
+		if err != nil {
+			t.Errorf("%s was removed: %v", key, err)
+			continue
+		}
+		reader.Close()
+	}
+
+	var remaining int64
+	check(db.Unscoped().Model(&models.Business{}).Where("id = ?", deleted.ID).Count(&remaining).Error)
+	if remaining != 0 {
+		t.Errorf("the expired business is still stored")
+	}
+	if _, err := s.RestoreTrashedBusiness(recent.ID); err != nil {
+		t.Errorf("RestoreTrashedBusiness(recent) error = %v", err)
+	}
+}
@@ -0,0 +1,676 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxCustomFieldsPerEntity = 50
+	maxCustomFieldTextLength = 2000
+	maxCustomFieldOptions    = 100
+)
+
+var (
+	// ErrCustomFieldNotFound is returned for custom fields the business does not have
+	ErrCustomFieldNotFound = errors.New("custom field not found")
+	// ErrCustomFieldKeyTaken is returned when the business already has a field with the key
+	ErrCustomFieldKeyTaken = errors.New("a custom field with this key already exists")
+	// ErrCustomFieldEntityNotFound is returned when the entity does not belong to the business
+	ErrCustomFieldEntityNotFound = errors.New("entity not found")
+)
+
+var (
+	customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+	currencyCodePattern   = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// CustomFieldSettings are the attributes of a definition that can change after it is created
+type CustomFieldSettings struct {
+	Label     string
+	Required  bool
+	Options   []string
+	Currency  string
+	Min       *float64
+	Max       *float64
+	MaxLength int
+	Position  *int
+}
+
+// ListCustomFieldDefinitions returns the business's custom fields, optionally for one entity type
+func (s *BusinessService) ListCustomFieldDefinitions(businessID uint, entityType string) ([]models.CustomFieldDefinition, error) {
+	query := s.DB.Where("business_id = ?", businessID)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+
+	var definitions []models.CustomFieldDefinition
+	if err := query.Order("entity_type, position, id").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// CreateCustomFieldDefinition adds a custom field to the business
+func (s *BusinessService) CreateCustomFieldDefinition(businessID uint, entityType, key, fieldType string, settings CustomFieldSettings) (*models.CustomFieldDefinition, error) {
+	if !models.IsValidCustomFieldEntity(entityType) {
+		return nil, &ValidationError{Field: "entity_type", Message: "entity_type must be business, financial or legal"}
+	}
+	if !models.IsValidCustomFieldType(fieldType) {
+		return nil, &ValidationError{Field: "type", Message: "type must be text, number, date, enum or currency"}
+	}
+	if key == "" {
+		key = models.CustomFieldKey(settings.Label)
+	}
+	if !customFieldKeyPattern.MatchString(key) {
+		return nil, &ValidationError{Field: "key", Message: "key must start with a letter and only contain lowercase letters, digits and underscores"}
+	}
+
+	definition := &models.CustomFieldDefinition{
+		BusinessID: businessID,
+		EntityType: entityType,
+		Key:        key,
+		Type:       fieldType,
+	}
+	if err := applyCustomFieldSettings(definition, settings); err != nil {
+		return nil, err
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.CustomFieldDefinition
+		if err := tx.Where("business_id = ? AND entity_type = ?", businessID, entityType).Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) >= maxCustomFieldsPerEntity {
+			return &ValidationError{Field: "entity_type", Message: fmt.Sprintf("a business can have at most %d custom fields per entity type", maxCustomFieldsPerEntity)}
+		}
+		for _, d := range existing {
+			if d.Key == key {
+				return ErrCustomFieldKeyTaken
+			}
+		}
+		if settings.Position == nil {
+			definition.Position = len(existing)
+		}
+
+		// Required fields would make every existing entity invalid, so they start optional
+		// until the existing entities have a value
+		if definition.Required {
+			count, err := countCustomFieldEntities(tx, businessID, entityType)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return &ValidationError{Field: "required", Message: "a new field cannot be required while entities without a value exist; create it as optional, fill it in, then make it required"}
+			}
+		}
+
+		if err := tx.Create(definition).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityCustomField, definition.ID, models.AuditActionCreate, nil, definition); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+// UpdateCustomFieldDefinition replaces the settings of a custom field. The key, type and entity
+// type cannot change.
+func (s *BusinessService) UpdateCustomFieldDefinition(businessID, fieldID uint, settings CustomFieldSettings) (*models.CustomFieldDefinition, error) {
+	var definition models.CustomFieldDefinition
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND business_id = ?", fieldID, businessID).First(&definition).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCustomFieldNotFound
+			}
+			return err
+		}
+		before := definition
+
+		if err := applyCustomFieldSettings(&definition, settings); err != nil {
+			return err
+		}
+
+		var values []models.CustomFieldValue
+		if err := tx.Where("definition_id = ?", fieldID).Find(&values).Error; err != nil {
+			return err
+		}
+
+		// Stored values have to stay valid under the new settings
+		for _, value := range values {
+			if _, err := normalizeCustomFieldValue(&definition, definition.TypedValue(value.Value)); err != nil {
+				return &ValidationError{Field: definition.Key, Message: fmt.Sprintf("existing value %q does not fit the new settings: %s", value.Value, err.Error())}
+			}
+		}
+		if definition.Required && !before.Required {
+			count, err := countCustomFieldEntities(tx, businessID, definition.EntityType)
+			if err != nil {
+				return err
+			}
+			if count > int64(len(values)) {
+				return &ValidationError{Field: "required", Message: "the field cannot be required until every entity has a value"}
+			}
+		}
+
+		if err := tx.Select("label", "required", "options", "currency", "min", "max", "max_length", "position").
+			Updates(&definition).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityCustomField, definition.ID, models.AuditActionUpdate, &before, &definition); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// DeleteCustomFieldDefinition removes the custom field and every value stored for it
+func (s *BusinessService) DeleteCustomFieldDefinition(businessID, fieldID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var definition models.CustomFieldDefinition
+		if err := tx.Where("id = ? AND business_id = ?", fieldID, businessID).First(&definition).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCustomFieldNotFound
+			}
+			return err
+		}
+
+		if err := tx.Where("definition_id = ?", fieldID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&definition).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityCustomField, definition.ID, models.AuditActionDelete, &definition, nil); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+}
+
+// GetCustomFields returns every custom field defined for the entity with its value
+func (s *BusinessService) GetCustomFields(businessID uint, entityType string, entityID uint) ([]models.CustomField, error) {
+	if err := s.verifyCustomFieldEntity(s.DB, businessID, entityType, entityID); err != nil {
+		return nil, err
+	}
+
+	fields, err := loadCustomFields(s.DB, entityType, map[uint]uint{entityID: businessID})
+	if err != nil {
+		return nil, err
+	}
+	return fields[entityID], nil
+}
+
+// SetCustomFieldValues validates and stores custom field values for the entity. Keys that are
+// not in values keep their value, and a nil value clears the field.
+func (s *BusinessService) SetCustomFieldValues(businessID uint, entityType string, entityID uint, values map[string]interface{}) ([]models.CustomField, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verifyCustomFieldEntity(tx, businessID, entityType, entityID); err != nil {
+			return err
+		}
+
+		var definitions []models.CustomFieldDefinition
+		if err := tx.Where("business_id = ? AND entity_type = ?", businessID, entityType).Find(&definitions).Error; err != nil {
+			return err
+		}
+		byKey := make(map[string]*models.CustomFieldDefinition, len(definitions))
+		for i := range definitions {
+			byKey[definitions[i].Key] = &definitions[i]
+		}
+
+		var existing []models.CustomFieldValue
+		if err := tx.Where("entity_type = ? AND entity_id = ?", entityType, entityID).Find(&existing).Error; err != nil {
+			return err
+		}
+		current := make(map[uint]models.CustomFieldValue, len(existing))
+		for _, v := range existing {
+			current[v.DefinitionID] = v
+		}
+
+		before := map[string]interface{}{}
+		after := map[string]interface{}{}
+		for _, d := range definitions {
+			if v, ok := current[d.ID]; ok {
+				before["custom_fields."+d.Key] = v.Value
+				after["custom_fields."+d.Key] = v.Value
+			}
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			definition, ok := byKey[key]
+			if !ok {
+				return &ValidationError{Field: key, Message: fmt.Sprintf("unknown custom field %q", key)}
+			}
+
+			if values[key] == nil {
+				if definition.Required {
+					return &ValidationError{Field: key, Message: fmt.Sprintf("%s is required", definition.Label)}
+				}
+				if err := tx.Where("definition_id = ? AND entity_id = ?", definition.ID, entityID).
+					Delete(&models.CustomFieldValue{}).Error; err != nil {
+					return err
+				}
+				delete(current, definition.ID)
+				delete(after, "custom_fields."+key)
+				continue
+			}
+
+			normalized, err := normalizeCustomFieldValue(definition, values[key])
+			if err != nil {
+				return &ValidationError{Field: key, Message: err.Error()}
+			}
+
+			value := models.CustomFieldValue{
+				DefinitionID: definition.ID,
+				BusinessID:   businessID,
+				EntityType:   entityType,
+				EntityID:     entityID,
+				Value:        normalized,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "definition_id"}, {Name: "entity_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(&value).Error; err != nil {
+				return err
+			}
+			current[definition.ID] = value
+			after["custom_fields."+key] = normalized
+		}
+
+		for _, d := range definitions {
+			if _, ok := current[d.ID]; d.Required && !ok {
+				return &ValidationError{Field: d.Key, Message: fmt.Sprintf("%s is required", d.Label)}
+			}
+		}
+
+		if err := s.audit(tx, businessID, entityType, entityID, models.AuditActionUpdate, before, after); err != nil {
+			return err
+		}
+		return s.snapshot(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCustomFields(businessID, entityType, entityID)
+}
+
+// addLegacyCustomFields stores name/value pairs sent the old way as text custom fields,
+// defining the fields the business does not have yet. Values of fields that are already
+// defined are checked against the definition like any other value.
+func (s *BusinessService) addLegacyCustomFields(tx *gorm.DB, businessID uint, entityType string, entityID uint, info []models.BusinessAdditionalInfo) error {
+	for _, item := range info {
+		if strings.TrimSpace(item.Name) == "" {
+			continue
+		}
+
+		definition := models.CustomFieldDefinition{
+			BusinessID: businessID,
+			EntityType: entityType,
+			Key:        models.CustomFieldKey(item.Name),
+		}
+		if err := tx.Where(&definition).
+			Attrs(models.CustomFieldDefinition{Label: strings.TrimSpace(item.Name), Type: models.CustomFieldTypeText}).
+			FirstOrCreate(&definition).Error; err != nil {
+			return err
+		}
+		normalized, err := normalizeCustomFieldValue(&definition, item.Value)
+		if err != nil {
+			return &ValidationError{Field: definition.Key, Message: err.Error()}
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "definition_id"}, {Name: "entity_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&models.CustomFieldValue{
+			DefinitionID: definition.ID,
+			BusinessID:   businessID,
+			EntityType:   entityType,
+			EntityID:     entityID,
+			Value:        normalized,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachCustomFields fills in the custom fields of the businesses and of their legals and financials
+func attachCustomFields(db *gorm.DB, businesses []models.Business) error {
+	if len(businesses) == 0 {
+		return nil
+	}
+
+	businessOwners := make(map[uint]uint)
+	legalOwners := make(map[uint]uint)
+	financialOwners := make(map[uint]uint)
+	for _, b := range businesses {
+		businessOwners[b.ID] = b.ID
+		for _, l := range b.Legals {
+			legalOwners[l.ID] = b.ID
+		}
+		for _, f := range b.Financials {
+			financialOwners[f.ID] = b.ID
+		}
+		if b.Financial != nil {
+			financialOwners[b.Financial.ID] = b.ID
+		}
+	}
+
+	businessFields, err := loadCustomFields(db, models.CustomFieldEntityBusiness, businessOwners)
+	if err != nil {
+		return err
+	}
+	legalFields, err := loadCustomFields(db, models.CustomFieldEntityLegal, legalOwners)
+	if err != nil {
+		return err
+	}
+	financialFields, err := loadCustomFields(db, models.CustomFieldEntityFinancial, financialOwners)
+	if err != nil {
+		return err
+	}
+
+	for i := range businesses {
+		b := &businesses[i]
+		b.CustomFields = businessFields[b.ID]
+		for j := range b.Legals {
+			b.Legals[j].CustomFields = legalFields[b.Legals[j].ID]
+		}
+		for j := range b.Financials {
+			b.Financials[j].CustomFields = financialFields[b.Financials[j].ID]
+		}
+		if b.Financial != nil {
+			b.Financial.CustomFields = financialFields[b.Financial.ID]
+		}
+	}
+	return nil
+}
+
+// attachLegalCustomFields fills in the custom fields of one business's legal documents
+func attachLegalCustomFields(db *gorm.DB, businessID uint, legals []models.Legal) error {
+	owners := make(map[uint]uint, len(legals))
+	for _, l := range legals {
+		owners[l.ID] = businessID
+	}
+	fields, err := loadCustomFields(db, models.CustomFieldEntityLegal, owners)
+	if err != nil {
+		return err
+	}
+	for i := range legals {
+		legals[i].CustomFields = fields[legals[i].ID]
+	}
+	return nil
+}
+
+// attachFinancialCustomFields fills in the custom fields of one business's financial records
+func attachFinancialCustomFields(db *gorm.DB, businessID uint, financials []*models.Financial) error {
+	owners := make(map[uint]uint, len(financials))
+	for _, f := range financials {
+		owners[f.ID] = businessID
+	}
+	fields, err := loadCustomFields(db, models.CustomFieldEntityFinancial, owners)
+	if err != nil {
+		return err
+	}
+	for _, f := range financials {
+		f.CustomFields = fields[f.ID]
+	}
+	return nil
+}
+
+// loadCustomFields returns the custom fields of each entity, keyed by entity ID. owners maps
+// every entity to its business, whose definitions apply to it.
+func loadCustomFields(db *gorm.DB, entityType string, owners map[uint]uint) (map[uint][]models.CustomField, error) {
+	result := make(map[uint][]models.CustomField, len(owners))
+	if len(owners) == 0 {
+		return result, nil
+	}
+
+	entityIDs := make([]uint, 0, len(owners))
+	businessIDSet := make(map[uint]bool)
+	for entityID, businessID := range owners {
+		entityIDs = append(entityIDs, entityID)
+		businessIDSet[businessID] = true
+	}
+	businessIDs := make([]uint, 0, len(businessIDSet))
+	for id := range businessIDSet {
+		businessIDs = append(businessIDs, id)
+	}
+
+	var definitions []models.CustomFieldDefinition
+	if err := db.Where("business_id IN ? AND entity_type = ?", businessIDs, entityType).
+		Order("position, id").
+		Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		return result, nil
+	}
+
+	var values []models.CustomFieldValue
+	if err := db.Where("entity_type = ? AND entity_id IN ?", entityType, entityIDs).Find(&values).Error; err != nil {
+		return nil, err
+	}
+	byEntity := make(map[uint]map[uint]*models.CustomFieldValue)
+	for i := range values {
+		v := &values[i]
+		if byEntity[v.EntityID] == nil {
+			byEntity[v.EntityID] = make(map[uint]*models.CustomFieldValue)
+		}
+		byEntity[v.EntityID][v.DefinitionID] = v
+	}
+
+	for entityID, businessID := range owners {
+		fields := []models.CustomField{}
+		for i := range definitions {
+			if definitions[i].BusinessID == businessID {
+				fields = append(fields, definitions[i].Field(byEntity[entityID][definitions[i].ID]))
+			}
+		}
+		if len(fields) > 0 {
+			result[entityID] = fields
+		}
+	}
+	return result, nil
+}
+
+// verifyCustomFieldEntity checks that the entity exists and belongs to the business
+func (s *BusinessService) verifyCustomFieldEntity(tx *gorm.DB, businessID uint, entityType string, entityID uint) error {
+	var query *gorm.DB
+	switch entityType {
+	case models.CustomFieldEntityBusiness:
+		if entityID != businessID {
+			return ErrCustomFieldEntityNotFound
+		}
+		query = tx.Model(&models.Business{}).Where("id = ?", businessID)
+	case models.CustomFieldEntityFinancial:
+		query = tx.Model(&models.Financial{}).Where("id = ? AND business_id = ?", entityID, businessID)
+	case models.CustomFieldEntityLegal:
+		query = tx.Model(&models.Legal{}).Where("id = ? AND business_id = ?", entityID, businessID)
+	default:
+		return &ValidationError{Field: "entity_type", Message: "entity_type must be business, financial or legal"}
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrCustomFieldEntityNotFound
+	}
+	return nil
+}
+
+// countCustomFieldEntities counts the entities of the type a business's custom fields apply to
+func countCustomFieldEntities(tx *gorm.DB, businessID uint, entityType string) (int64, error) {
+	var count int64
+	var err error
+	switch entityType {
+	case models.CustomFieldEntityBusiness:
+		count = 1
+	case models.CustomFieldEntityFinancial:
+		err = tx.Model(&models.Financial{}).Where("business_id = ?", businessID).Count(&count).Error
+	case models.CustomFieldEntityLegal:
+		err = tx.Model(&models.Legal{}).Where("business_id = ?", businessID).Count(&count).Error
+	}
+	return count, err
+}
+
+// applyCustomFieldSettings validates the settings against the field type and copies them over
+func applyCustomFieldSettings(definition *models.CustomFieldDefinition, settings CustomFieldSettings) error {
+	label := strings.TrimSpace(settings.Label)
+	if label == "" || len(label) > 100 {
+		return &ValidationError{Field: "label", Message: "label must be between 1 and 100 characters"}
+	}
+
+	definition.Label = label
+	definition.Required = settings.Required
+	definition.Options = nil
+	definition.Currency = ""
+	definition.Min, definition.Max = nil, nil
+	definition.MaxLength = 0
+	if settings.Position != nil {
+		definition.Position = *settings.Position
+	}
+
+	switch definition.Type {
+	case models.CustomFieldTypeText:
+		if settings.MaxLength < 0 || settings.MaxLength > maxCustomFieldTextLength {
+			return &ValidationError{Field: "max_length", Message: fmt.Sprintf("max_length must be between 0 and %d", maxCustomFieldTextLength)}
+		}
+		definition.MaxLength = settings.MaxLength
+
+	case models.CustomFieldTypeEnum:
+		if len(settings.Options) == 0 || len(settings.Options) > maxCustomFieldOptions {
+			return &ValidationError{Field: "options", Message: fmt.Sprintf("enum fields need between 1 and %d options", maxCustomFieldOptions)}
+		}
+		seen := make(map[string]bool)
+		for _, option := range settings.Options {
+			option = strings.TrimSpace(option)
+			if option == "" || seen[option] {
+				return &ValidationError{Field: "options", Message: "options must be unique and not empty"}
+			}
+			seen[option] = true
+			definition.Options = append(definition.Options, option)
+		}
+
+	case models.CustomFieldTypeCurrency:
+		currency := strings.ToUpper(strings.TrimSpace(settings.Currency))
+		if currency == "" {
+			currency = "IDR"
+		}
+		if !currencyCodePattern.MatchString(currency) {
+			return &ValidationError{Field: "currency", Message: "currency must be a three-letter ISO 4217 code"}
+		}
+		definition.Currency = currency
+		fallthrough
+
+	case models.CustomFieldTypeNumber:
+		if settings.Min != nil && settings.Max != nil && *settings.Min > *settings.Max {
+			return &ValidationError{Field: "min", Message: "min cannot be greater than max"}
+		}
+		definition.Min, definition.Max = settings.Min, settings.Max
+	}
+
+	return nil
+}
+
+// normalizeCustomFieldValue validates a JSON value against the definition and returns its
+// canonical stored form
+func normalizeCustomFieldValue(definition *models.CustomFieldDefinition, value interface{}) (string, error) {
+	switch definition.Type {
+	case models.CustomFieldTypeText:
+		text, ok := value.(string)
+		if !ok {
+			return "", errors.New("must be a string")
+		}
+		limit := definition.MaxLength
+		if limit == 0 {
+			limit = maxCustomFieldTextLength
+		}
+		if len([]rune(text)) > limit {
+			return "", fmt.Errorf("cannot be longer than %d characters", limit)
+		}
+		return text, nil
+
+	case models.CustomFieldTypeNumber, models.CustomFieldTypeCurrency:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", errors.New("must be a number")
+			}
+			number = parsed
+		default:
+			return "", errors.New("must be a number")
+		}
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return "", errors.New("must be a number")
+		}
+		if definition.Min != nil && number < *definition.Min {
+			return "", fmt.Errorf("must be at least %v", *definition.Min)
+		}
+		if definition.Max != nil && number > *definition.Max {
+			return "", fmt.Errorf("must be at most %v", *definition.Max)
+		}
+		if definition.Type == models.CustomFieldTypeCurrency {
+			// Amounts are kept to the cent
+			return strconv.FormatFloat(math.Round(number*100)/100, 'f', -1, 64), nil
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+
+	case models.CustomFieldTypeDate:
+		text, ok := value.(string)
+		if !ok {
+			return "", errors.New("must be a date in YYYY-MM-DD format")
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(text))
+		if err != nil {
+			return "", errors.New("must be a date in YYYY-MM-DD format")
+		}
+		return date.Format("2006-01-02"), nil
+
+	case models.CustomFieldTypeEnum:
+		text, ok := value.(string)
+		if !ok {
+			return "", errors.New("must be one of the field's options")
+		}
+		for _, option := range definition.Options {
+			if option == text {
+				return text, nil
+			}
+		}
+		return "", fmt.Errorf("must be one of: %s", strings.Join(definition.Options, ", "))
+	}
+
+	return "", fmt.Errorf("unsupported field type %q", definition.Type)
+}
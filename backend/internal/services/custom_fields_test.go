@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestNormalizeCustomFieldValue(t *testing.T) {
+	min, max := 0.0, 100.0
+	text := &models.CustomFieldDefinition{Type: models.CustomFieldTypeText, MaxLength: 5}
+	number := &models.CustomFieldDefinition{Type: models.CustomFieldTypeNumber, Min: &min, Max: &max}
+	currency := &models.CustomFieldDefinition{Type: models.CustomFieldTypeCurrency, Currency: "IDR"}
+	date := &models.CustomFieldDefinition{Type: models.CustomFieldTypeDate}
+	enum := &models.CustomFieldDefinition{Type: models.CustomFieldTypeEnum, Options: []string{"PT", "CV"}}
+
+	tests := []struct {
+		name       string
+		definition *models.CustomFieldDefinition
+		value      interface{}
+		want       string
+		wantErr    bool
+	}{
+		{"text", text, "Halal", "Halal", false},
+		{"text over the length limit", text, "Halal!", "", true},
+		{"text counts characters", text, "ééééé", "ééééé", false},
+		{"text from a number", text, 12.0, "", true},
+		{"number", number, 42.5, "42.5", false},
+		{"number from a string", number, " 42 ", "42", false},
+		{"number below the minimum", number, -1.0, "", true},
+		{"number above the maximum", number, 100.5, "", true},
+		{"number from a word", number, "many", "", true},
+		{"number from NaN", number, "NaN", "", true},
+		{"currency rounded to the cent", currency, 1250.456, "1250.46", false},
+		{"date", date, "2024-02-29", "2024-02-29", false},
+		{"date in another format", date, "29/02/2024", "", true},
+		{"date that does not exist", date, "2023-02-29", "", true},
+		{"enum option", enum, "CV", "CV", false},
+		{"enum option in another case", enum, "cv", "", true},
+		{"enum from a number", enum, 1.0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeCustomFieldValue(tt.definition, tt.value)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Errorf("normalizeCustomFieldValue(%v) = %q, want an error", tt.value, got)
+			case !tt.wantErr && (err != nil || got != tt.want):
+				t.Errorf("normalizeCustomFieldValue(%v) = %q, %v; want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCustomFieldValues(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+	s := NewBusinessService(db)
+
+	if _, err := s.CreateCustomFieldDefinition(business.ID, models.CustomFieldEntityBusiness, "employees", models.CustomFieldTypeNumber, CustomFieldSettings{Label: "Employees"}); err != nil {
+		t.Fatalf("CreateCustomFieldDefinition: %v", err)
+	}
+	if _, err := s.CreateCustomFieldDefinition(business.ID, models.CustomFieldEntityBusiness, "", models.CustomFieldTypeEnum, CustomFieldSettings{Label: "Legal Form", Options: []string{"PT", "CV"}}); err != nil {
+		t.Fatalf("CreateCustomFieldDefinition: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		values    map[string]interface{}
+		wantField string // Field named in the validation error, empty for success
+	}{
+		{"valid values", map[string]interface{}{"employees": "12", "legal_form": "PT"}, ""},
+		{"unknown field", map[string]interface{}{"colour": "red"}, "colour"},
+		{"invalid value", map[string]interface{}{"employees": "twelve"}, "employees"},
+		{"clear a value", map[string]interface{}{"legal_form": nil}, ""},
+	}
+	for _, tt := range tests {
+		_, err := s.SetCustomFieldValues(business.ID, models.CustomFieldEntityBusiness, business.ID, tt.values)
+		var validationErr *ValidationError
+		switch {
+		case tt.wantField == "" && err != nil:
+			t.Errorf("%s: SetCustomFieldValues() error = %v", tt.name, err)
+		case tt.wantField != "" && (!errors.As(err, &validationErr) || validationErr.Field != tt.wantField):
+			t.Errorf("%s: SetCustomFieldValues() error = %v, want a validation error for %s", tt.name, err, tt.wantField)
+		}
+	}
+
+	fields, err := s.GetCustomFields(business.ID, models.CustomFieldEntityBusiness, business.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	for _, f := range fields {
+		got[f.Key] = f.Value
+	}
+	if got["employees"] != 12.0 || got["legal_form"] != nil {
+		t.Errorf("custom fields = %v, want 12 employees and no legal form", got)
+	}
+}
+
+func TestCreateCustomFieldDefinitionTakesSnapshot(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara") // Stored without a snapshot
+	s := NewBusinessService(db)
+
+	if _, err := s.CreateCustomFieldDefinition(business.ID, models.CustomFieldEntityBusiness, "", models.CustomFieldTypeText, CustomFieldSettings{Label: "Brand"}); err != nil {
+		t.Fatalf("CreateCustomFieldDefinition: %v", err)
+	}
+	if count := countSnapshots(t, s, business.ID); count != 1 {
+		t.Errorf("%d snapshots were taken, want 1", count)
+	}
+}
+
+func TestAddLegacyCustomFields(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+	s := NewBusinessService(db)
+	if _, err := s.CreateCustomFieldDefinition(business.ID, models.CustomFieldEntityBusiness, "employees", models.CustomFieldTypeNumber, CustomFieldSettings{Label: "Employees"}); err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(info ...models.BusinessAdditionalInfo) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return s.addLegacyCustomFields(tx, business.ID, models.CustomFieldEntityBusiness, business.ID, info)
+		})
+	}
+
+	err := add(models.BusinessAdditionalInfo{Name: "Employees", Value: "a dozen"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "employees" {
+		t.Fatalf("text for a number field: error = %v, want a validation error for employees", err)
+	}
+	err = add(models.BusinessAdditionalInfo{Name: "Notes", Value: strings.Repeat("a", maxCustomFieldTextLength+1)})
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("over-long text: error = %v, want a validation error", err)
+	}
+
+	if err := add(
+		models.BusinessAdditionalInfo{Name: "Employees", Value: " 12 "},
+		models.BusinessAdditionalInfo{Name: "Export Markets", Value: "Japan, Korea"},
+		models.BusinessAdditionalInfo{Name: " ", Value: "skipped"},
+	); err != nil {
+		t.Fatalf("addLegacyCustomFields: %v", err)
+	}
+
+	fields, err := s.GetCustomFields(business.ID, models.CustomFieldEntityBusiness, business.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]models.CustomField{}
+	for _, f := range fields {
+		got[f.Key] = f
+	}
+	if len(got) != 2 || got["employees"].Value != 12.0 {
+		t.Errorf("custom fields = %+v, want 12 employees and export markets", fields)
+	}
+	if markets := got["export_markets"]; markets.Type != models.CustomFieldTypeText || markets.Value != "Japan, Korea" {
+		t.Errorf("export_markets = %+v, want a text field", markets)
+	}
+}
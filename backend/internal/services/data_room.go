@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const maxDataRoomNoteLength = 500
+
+var (
+	// ErrGrantNotFound is returned for unknown or already revoked data room grants
+	ErrGrantNotFound = errors.New("data room grant not found")
+	// ErrInvestorNotFound is returned when granting access to someone who is not an investor
+	ErrInvestorNotFound = errors.New("investor not found")
+)
+
+// DataRoomGrantRequest names the investor to let in, by user ID or email
+type DataRoomGrantRequest struct {
+	InvestorID uint
+	Email      string
+	ExpiresAt  *time.Time
+	Note       string
+}
+
+// GrantDataRoomAccess lets an investor open the business's documents. An investor who
+// already has access gets the new expiry and note instead of a second grant.
+func (s *BusinessService) GrantDataRoomAccess(businessID, grantedByID uint, req DataRoomGrantRequest) (*models.DataRoomGrant, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, &ValidationError{Field: "expires_at", Message: "expires_at must be in the future"}
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxDataRoomNoteLength {
+		return nil, &ValidationError{Field: "note", Message: "note cannot be longer than 500 characters"}
+	}
+
+	var investor models.User
+	query := s.DB.Where("role IN ?", []string{models.RoleInvestor, models.RoleAdmin})
+	switch {
+	case req.InvestorID != 0:
+		query = query.Where("id = ?", req.InvestorID)
+	case strings.TrimSpace(req.Email) != "":
+		query = query.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(req.Email)))
+	default:
+		return nil, &ValidationError{Field: "investor_id", Message: "investor_id or email is required"}
+	}
+	if err := query.First(&investor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvestorNotFound
+		}
+		return nil, err
+	}
+
+	var grant models.DataRoomGrant
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var before *models.DataRoomGrant
+		var existing models.DataRoomGrant
+		err := tx.Where("business_id = ? AND investor_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			businessID, investor.ID, time.Now()).
+			First(&existing).Error
+		switch {
+		case err == nil:
+			before = &existing
+			grant = existing
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			grant = models.DataRoomGrant{BusinessID: businessID, InvestorID: investor.ID}
+		default:
+			return err
+		}
+
+		grant.GrantedByID = grantedByID
+		grant.ExpiresAt = req.ExpiresAt
+		grant.Note = note
+		if err := tx.Save(&grant).Error; err != nil {
+			return err
+		}
+
+		action := models.AuditActionCreate
+		if before != nil {
+			action = models.AuditActionUpdate
+		}
+		return s.audit(tx, businessID, models.AuditEntityDataRoomGrant, grant.ID, action, before, &grant)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grant.Investor = &investor
+	return &grant, nil
+}
+
+// ListDataRoomGrants returns the business's grants that still give access, with the investors
+func (s *BusinessService) ListDataRoomGrants(businessID uint) ([]models.DataRoomGrant, error) {
+	grants := []models.DataRoomGrant{}
+	if err := s.DB.Preload("Investor").
+		Where("business_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", businessID, time.Now()).
+		Order("created_at DESC").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// RevokeDataRoomGrant ends an investor's access to the business's documents
+func (s *BusinessService) RevokeDataRoomGrant(businessID, grantID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.DataRoomGrant
+		if err := tx.Where("id = ? AND business_id = ? AND revoked_at IS NULL", grantID, businessID).First(&before).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGrantNotFound
+			}
+			return err
+		}
+
+		after := before
+		now := time.Now()
+		after.RevokedAt = &now
+		if err := tx.Model(&after).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, businessID, models.AuditEntityDataRoomGrant, grantID, models.AuditActionDelete, &before, &after)
+	})
+}
+
+// ListInvestorDataRooms returns the listed businesses whose documents the investor can open
+func (s *BusinessService) ListInvestorDataRooms(investorID uint) ([]models.DataRoomGrant, error) {
+	grants := []models.DataRoomGrant{}
+	if err := s.DB.Preload("Business").
+		Joins("JOIN businesses ON businesses.id = data_room_grants.business_id AND businesses.deleted_at IS NULL").
+		Scopes(models.ListedBusinesses).
+		Where("data_room_grants.investor_id = ? AND data_room_grants.revoked_at IS NULL AND (data_room_grants.expires_at IS NULL OR data_room_grants.expires_at > ?)",
+			investorID, time.Now()).
+		Order("data_room_grants.created_at DESC").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// hasDataRoomAccess reports whether the investor holds a live grant on a listed business
+func (s *BusinessService) hasDataRoomAccess(investorID, businessID uint) (bool, error) {
+	var count int64
+	err := s.DB.Model(&models.DataRoomGrant{}).
+		Joins("JOIN businesses ON businesses.id = data_room_grants.business_id AND businesses.deleted_at IS NULL").
+		Scopes(models.ListedBusinesses).
+		Where("data_room_grants.business_id = ? AND data_room_grants.investor_id = ? AND data_room_grants.revoked_at IS NULL AND (data_room_grants.expires_at IS NULL OR data_room_grants.expires_at > ?)",
+			businessID, investorID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
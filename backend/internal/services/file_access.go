@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"go-gin-backend/internal/models"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultFileURLTTLMinutes = 5
+
+// ErrFileAccessDenied is returned when the user may not open a stored file
+var ErrFileAccessDenied = errors.New("access to this file is denied")
+
+// uploadOwner is the business a stored file belongs to
+type uploadOwner struct {
+	BusinessID uint
+	Trashed    bool // The record pointing at the file is in the trash
+	Document   bool // Legal documents and financial reports, as opposed to product pictures
+}
+
+// Upload directories with the records that can point at the files in them. Keys name the
+// business they were uploaded for right after the prefix, either as a directory or, for
+// files stored before uploads had their own directories, as the file name's first part.
+// Soft-deleted rows are included so members can still open files of items in the trash.
+var uploadDirs = []struct {
+	prefix   string
+	document bool
+	queries  []string
+}{
+	{"legal/business/", true, []string{
+		`SELECT deleted_at IS NOT NULL AS trashed FROM legals WHERE file_url = ? AND business_id = ?`,
+		`SELECT legals.deleted_at IS NOT NULL AS trashed
+			FROM legal_file_versions JOIN legals ON legals.id = legal_file_versions.entity_id
+			WHERE legal_file_versions.entity_type = 'legal' AND legal_file_versions.file_url = ? AND legals.business_id = ?`,
+	}},
+	{"legal/products/", true, []string{
+		`SELECT product_legals.deleted_at IS NOT NULL OR products.deleted_at IS NOT NULL AS trashed
+			FROM product_legals JOIN products ON products.id = product_legals.product_id
+			WHERE product_legals.file_url = ? AND products.business_id = ?`,
+		`SELECT product_legals.deleted_at IS NOT NULL OR products.deleted_at IS NOT NULL AS trashed
+			FROM legal_file_versions
+			JOIN product_legals ON product_legals.id = legal_file_versions.entity_id
+			JOIN products ON products.id = product_legals.product_id
+			WHERE legal_file_versions.entity_type = 'product_legal' AND legal_file_versions.file_url = ? AND products.business_id = ?`,
+	}},
+	{"financials/", true, []string{
+		`SELECT deleted_at IS NOT NULL AS trashed FROM financials WHERE report_file_url = ? AND business_id = ?`,
+	}},
+	{"products/", false, []string{
+		`SELECT deleted_at IS NOT NULL AS trashed FROM products WHERE images @> ?::jsonb AND business_id = ?`,
+	}},
+}
+
+// resolveUpload finds the business the stored file was uploaded for and checks that its
+// records still point at the file. References from other businesses are ignored.
+func (s *BusinessService) resolveUpload(key string) (*uploadOwner, error) {
+	for _, dir := range uploadDirs {
+		rest, ok := strings.CutPrefix(key, dir.prefix)
+		if !ok {
+			continue
+		}
+		if end := strings.IndexAny(rest, "/_"); end >= 0 {
+			rest = rest[:end]
+		}
+		businessID, err := strconv.ParseUint(rest, 10, 64)
+		if err != nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		arg := any(uploadURL(key))
+		if !dir.document {
+			images, err := json.Marshal([]models.ProductImage{{URL: uploadURL(key)}})
+			if err != nil {
+				return nil, err
+			}
+			arg = string(images)
+		}
+
+		for _, query := range dir.queries {
+			var rows []struct{ Trashed bool }
+			// A live record wins over one in the trash
+			if err := s.DB.Raw(query+" ORDER BY trashed ASC LIMIT 1", arg, businessID).Scan(&rows).Error; err != nil {
+				return nil, err
+			}
+			if len(rows) > 0 {
+				return &uploadOwner{BusinessID: uint(businessID), Trashed: rows[0].Trashed, Document: dir.document}, nil
+			}
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// AuthorizeFileAccess checks that the user may open the stored file. Members of the business
+// can open all of its files. Investors can see the product pictures of listed businesses,
+// and open their documents once the owner has granted them the data room.
+func (s *BusinessService) AuthorizeFileAccess(userID uint, role, key string) error {
+	owner, err := s.resolveUpload(key)
+	if err != nil {
+		return err
+	}
+
+	if models.RoleHasPermission(role, models.PermissionAdministerSystem) {
+		return nil
+	}
+
+	memberRole, err := s.GetMemberRole(userID, owner.BusinessID)
+	if err != nil {
+		return err
+	}
+	if memberRole != "" {
+		return nil
+	}
+
+	if owner.Trashed || !models.RoleHasPermission(role, models.PermissionBrowseListings) {
+		return ErrFileAccessDenied
+	}
+
+	if owner.Document {
+		granted, err := s.hasDataRoomAccess(userID, owner.BusinessID)
+		if err != nil {
+			return err
+		}
+		if !granted {
+			return ErrFileAccessDenied
+		}
+		return nil
+	}
+
+	var listed int64
+	if err := s.DB.Model(&models.Business{}).Scopes(models.ListedBusinesses).Where("id = ?", owner.BusinessID).Count(&listed).Error; err != nil {
+		return err
+	}
+	if listed == 0 {
+		return ErrFileAccessDenied
+	}
+	return nil
+}
+
+// SignFileURLs signs the file URLs the user may open, keyed by the URL as stored. URLs of
+// files that do not exist or that the user may not open are left out.
+func (s *BusinessService) SignFileURLs(userID uint, role string, fileURLs []string) (map[string]string, time.Time, error) {
+	ttl := FileURLTTL()
+	expiresAt := time.Now().Add(ttl)
+	signed := make(map[string]string, len(fileURLs))
+	for _, fileURL := range fileURLs {
+		key, ok := uploadKeyFromURL(fileURL)
+		if !ok {
+			continue
+		}
+		err := s.AuthorizeFileAccess(userID, role, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrFileAccessDenied) {
+			continue
+		}
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		signedURL, err := s.Storage.SignedURL(key, ttl)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		signed[fileURL] = signedURL
+	}
+	return signed, expiresAt, nil
+}
+
+// FileURLTTL is how long signed file URLs stay valid, from FILE_URL_TTL_MINUTES
+func FileURLTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("FILE_URL_TTL_MINUTES"))
+	if err != nil || minutes < 1 {
+		minutes = defaultFileURLTTLMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/storage"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestAuthorizeFileAccess(t *testing.T) {
+	db := newTestDB(t)
+	s := NewBusinessService(db)
+	create := func(record interface{}) {
+		t.Helper()
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	createUser := func(username, role string) *models.User {
+		t.Helper()
+		user := createTestUser(t, db, username)
+		if err := db.Model(user).Update("role", role).Error; err != nil {
+			t.Fatal(err)
+		}
+		user.Role = role
+		return user
+	}
+
+	alice := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, alice, "Kopi Nusantara")
+	viewer := createTestUser(t, db, "carol")
+	create(&models.BusinessMember{BusinessID: business.ID, UserID: viewer.ID, Role: models.BusinessRoleViewer})
+
+	create(&models.Legal{BusinessID: business.ID, FileURL: uploadURL("legal/business/1/permit.pdf")})
+	create(&models.Legal{BusinessID: business.ID, FileURL: uploadURL("legal/business/1_1700000000_nib.pdf")}) // Stored before upload directories
+	trashed := &models.Legal{BusinessID: business.ID, FileURL: uploadURL("legal/business/1/license.pdf")}
+	create(trashed)
+	create(&models.LegalFileVersion{
+		BusinessID: business.ID,
+		EntityType: models.AuditEntityLegal,
+		EntityID:   trashed.ID,
+		FileURL:    uploadURL("legal/business/1/license-2023.pdf"),
+	})
+	if err := db.Delete(trashed).Error; err != nil {
+		t.Fatal(err)
+	}
+	product := &models.Product{BusinessID: business.ID, Name: "Arabica"}
+	create(product)
+	create(&models.ProductLegal{ProductID: product.ID, FileURL: uploadURL("legal/products/1/1/halal.pdf")})
+	create(&models.Financial{BusinessID: business.ID, ReportFileURL: uploadURL("financials/1/report.pdf")})
+
+	// Another owner points their records at the business's files
+	bob := createTestUser(t, db, "bob")
+	otherBusiness := createTestBusiness(t, db, bob, "Teh Manis")
+	create(&models.Legal{BusinessID: otherBusiness.ID, FileURL: uploadURL("legal/business/1/permit.pdf")})
+	create(&models.Legal{BusinessID: otherBusiness.ID, FileURL: uploadURL("legal/business/1/claimed.pdf")})
+
+	investor := createUser("ivan", models.RoleInvestor)
+	create(&models.DataRoomGrant{BusinessID: business.ID, InvestorID: investor.ID, GrantedByID: alice.ID})
+	otherInvestor := createUser("irene", models.RoleInvestor)
+	admin := createUser("adam", models.RoleAdmin)
+
+	tests := []struct {
+		name    string
+		user    *models.User
+		key     string
+		wantErr error
+	}{
+		{"owner", alice, "legal/business/1/permit.pdf", nil},
+		{"viewer", viewer, "legal/products/1/1/halal.pdf", nil},
+		{"member opens a trashed document", alice, "legal/business/1/license.pdf", nil},
+		{"member opens an earlier file of a trashed document", viewer, "legal/business/1/license-2023.pdf", nil},
+		{"admin", admin, "financials/1/report.pdf", nil},
+		{"data room legal", investor, "legal/business/1/permit.pdf", nil},
+		{"data room file stored before upload directories", investor, "legal/business/1_1700000000_nib.pdf", nil},
+		{"data room product legal", investor, "legal/products/1/1/halal.pdf", nil},
+		{"data room financial report", investor, "financials/1/report.pdf", nil},
+		{"data room trashed document", investor, "legal/business/1/license.pdf", ErrFileAccessDenied},
+		{"data room earlier file of a trashed document", investor, "legal/business/1/license-2023.pdf", ErrFileAccessDenied},
+		{"investor without a grant", otherInvestor, "legal/business/1/permit.pdf", ErrFileAccessDenied},
+		{"another business's owner", bob, "legal/business/1/permit.pdf", ErrFileAccessDenied},
+		{"file only another business points at", bob, "legal/business/1/claimed.pdf", gorm.ErrRecordNotFound},
+		{"unknown file", alice, "legal/business/1/missing.pdf", gorm.ErrRecordNotFound},
+		{"unknown directory", admin, "avatars/1/alice.png", gorm.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AuthorizeFileAccess(tt.user.ID, tt.user.Role, tt.key); !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthorizeFileAccess(%s, %s) error = %v, want %v", tt.user.Username, tt.key, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignFileURLs(t *testing.T) {
+	db := newTestDB(t)
+	store := storage.NewLocalStorage(t.TempDir(), uploadURLPrefix, []byte("test-secret"))
+	s := NewBusinessService(db)
+	s.Storage = store
+
+	alice := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, alice, "Kopi Nusantara")
+	permit := uploadURL("legal/business/1/permit.pdf")
+	if err := db.Create(&models.Legal{BusinessID: business.ID, FileURL: permit}).Error; err != nil {
+		t.Fatal(err)
+	}
+	bob := createTestUser(t, db, "bob")
+
+	signed, _, err := s.SignFileURLs(alice.ID, alice.Role, []string{permit, uploadURL("legal/business/1/missing.pdf"), "https://cdn.example.com/a.pdf"})
+	if err != nil {
+		t.Fatalf("SignFileURLs: %v", err)
+	}
+	if len(signed) != 1 {
+		t.Fatalf("SignFileURLs() = %v, want only the stored file", signed)
+	}
+	u, err := url.Parse(signed[permit])
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := strings.CutPrefix(u.Path, uploadURLPrefix+"/")
+	if key != "legal/business/1/permit.pdf" || !store.VerifySignedURL(key, u.Query().Get("expires"), u.Query().Get("signature")) {
+		t.Errorf("signed URL %s does not open the file", signed[permit])
+	}
+
+	if signed, _, err := s.SignFileURLs(bob.ID, bob.Role, []string{permit}); err != nil || len(signed) != 0 {
+		t.Errorf("SignFileURLs() for another user = %v, %v; want nothing signed", signed, err)
+	}
+}
@@ -11,8 +11,9 @@ import (
 	"gorm.io/gorm"
 )
 
-// GenerateBusinessSuggestions generates AI-powered business improvement suggestions
-func (s *Service) GenerateBusinessSuggestions(businessID uint, isRefresh bool) (*AISuggestionsResponse, error) {
+// GenerateBusinessSuggestions generates AI-powered business improvement suggestions. The
+// onboarding order and what is missing come from the business completeness.
+func (s *Service) GenerateBusinessSuggestions(businessID uint, isRefresh bool, completeness *models.BusinessCompleteness) (*AISuggestionsResponse, error) {
 	ctx := context.Background()
 
 	if !isRefresh {
@@ -41,7 +42,7 @@ func (s *Service) GenerateBusinessSuggestions(businessID uint, isRefresh bool) (
 	var business models.Business
 	if err := s.DB.
 		Preload("Products").
-		Preload("Legals").
+		Preload("Legals", models.ActiveLegals).
 		Preload("Financial").
 		Preload("Financials", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at desc").Limit(1)
@@ -55,10 +56,7 @@ func (s *Service) GenerateBusinessSuggestions(businessID uint, isRefresh bool) (
 
 	prompt := fmt.Sprintf(`untuk melengkapi profil sebuah bisnis di aplikasi kami untuk kelancaran investasi, user perlu mengisi hal yang terkait, sesuai urutan berikut:
 
-1 hak merek
-2 surat terkait produk  
-3 data terkait finansial (diinput sesuai dengan laporan pajak)
-
+%s
 data yang dimiliki user adalah
 %s
 
@@ -81,7 +79,7 @@ Catatan:
 - Prioritaskan saran berdasarkan kelengkapan data yang sudah ada
 - Berikan saran spesifik dan actionable dengan menyebutkan halaman yang harus dikunjungi
 - Maksimal 5 saran yang paling penting
-- Sebutkan link seperti "Kunjungi halaman Products", "Kunjungi halaman Legal Documents", "Kunjungi halaman Financial Data", "Kunjungi halaman Projections"`, businessData)
+- Sebutkan link seperti "Kunjungi halaman Products", "Kunjungi halaman Legal Documents", "Kunjungi halaman Financial Data", "Kunjungi halaman Projections"`, buildOnboardingSummary(completeness), businessData)
 
 	parts := []*genai.Part{
 		genai.NewPartFromText(prompt),
@@ -151,6 +149,29 @@ Catatan:
 	return &suggestions, nil
 }
 
+// buildOnboardingSummary lists the profile sections in onboarding order with what is still missing
+func buildOnboardingSummary(completeness *models.BusinessCompleteness) string {
+	if completeness == nil {
+		return ""
+	}
+
+	var summary strings.Builder
+	for i, section := range completeness.Sections {
+		status := "belum lengkap"
+		if section.Complete {
+			status = "sudah lengkap"
+		}
+		summary.WriteString(fmt.Sprintf("%d %s (bobot %.0f%%, progres %.0f%%): %s\n", i+1, section.Title, section.Weight, section.Progress, status))
+		for _, item := range section.Items {
+			if !item.Complete {
+				summary.WriteString(fmt.Sprintf("   - %s: %s\n", item.Label, item.Missing))
+			}
+		}
+	}
+	summary.WriteString(fmt.Sprintf("progres keseluruhan: %.1f%%\n", completeness.OverallProgress))
+	return summary.String()
+}
+
 // buildBusinessDataSummary creates a comprehensive summary of business data for AI analysis
 func (s *Service) buildBusinessDataSummary(business models.Business) string {
 	var summary strings.Builder
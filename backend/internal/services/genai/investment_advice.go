@@ -60,7 +60,7 @@ func (s *Service) getRelevantBusinesses(query string, preferences map[string]int
 	var businesses []models.Business
 
 	// Build dynamic query based on user input
-	dbQuery := s.DB.Preload("Financial").Preload("Legals").Preload("Products")
+	dbQuery := s.DB.Scopes(models.ListedBusinesses).Preload("Financial").Preload("Legals", models.ActiveLegals).Preload("Products")
 
 	// Extract keywords from query for filtering
 	keywords := s.extractKeywords(query)
@@ -102,12 +102,12 @@ func (s *Service) getMarketStatistics() (map[string]interface{}, error) {
 
 	// Total businesses count
 	var totalBusinesses int64
-	s.DB.Model(&models.Business{}).Count(&totalBusinesses)
+	s.DB.Model(&models.Business{}).Scopes(models.ListedBusinesses).Count(&totalBusinesses)
 	stats["total_businesses"] = totalBusinesses
 
 	// Businesses with financial data
 	var withFinancial int64
-	s.DB.Model(&models.Business{}).Joins("JOIN financials ON businesses.id = financials.business_id").Count(&withFinancial)
+	s.DB.Model(&models.Business{}).Scopes(models.ListedBusinesses).Joins("JOIN financials ON businesses.id = financials.business_id").Count(&withFinancial)
 	stats["businesses_with_financial"] = withFinancial
 
 	// Industry distribution
@@ -115,7 +115,7 @@ func (s *Service) getMarketStatistics() (map[string]interface{}, error) {
 		Industry string
 		Count    int64
 	}
-	s.DB.Model(&models.Business{}).Select("industry, COUNT(*) as count").Where("industry IS NOT NULL AND industry != ''").Group("industry").Scan(&industries)
+	s.DB.Model(&models.Business{}).Scopes(models.ListedBusinesses).Select("industry, COUNT(*) as count").Where("industry IS NOT NULL AND industry != ''").Group("industry").Scan(&industries)
 	stats["industry_distribution"] = industries
 
 	// Financial averages (only for businesses with financial data)
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-gin-backend/internal/models"
+	"sort"
 	"strings"
 
 	"google.golang.org/genai"
@@ -16,7 +17,7 @@ func (s *Service) AnalyzeBusinessLegals(businessID uint) (*models.LegalCompariso
 
 	// Fetch business data with all relations
 	var business models.Business
-	if err := s.DB.Preload("Products").Preload("Legals").Preload("Products.ProductLegals").
+	if err := s.DB.Preload("Products").Preload("Legals", models.ActiveLegals).Preload("Products.ProductLegals", models.ActiveProductLegals).
 		First(&business, businessID).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch business: %w", err)
 	}
@@ -210,6 +211,7 @@ func (s *Service) buildBusinessProfileText(business models.Business) string {
 	} else {
 		for _, product := range business.Products {
 			profile.WriteString(fmt.Sprintf("- Product: %s\n", product.Name))
+			writeProductDetails(&profile, product)
 
 			if len(product.ProductLegals) == 0 {
 				profile.WriteString("  Legal documents: None\n")
@@ -231,3 +233,42 @@ func (s *Service) buildBusinessProfileText(business models.Business) string {
 
 	return profile.String()
 }
+
+// writeProductDetails adds the catalog attributes that tell what kind of product it is, which
+// decides the permits it needs (food safety, halal, product standards and so on)
+func writeProductDetails(profile *strings.Builder, product models.Product) {
+	if product.Category != "" {
+		profile.WriteString(fmt.Sprintf("  Category: %s\n", product.Category))
+	}
+	if product.SKU != "" {
+		profile.WriteString(fmt.Sprintf("  SKU: %s\n", product.SKU))
+	}
+	if product.Description != "" {
+		profile.WriteString(fmt.Sprintf("  Description: %s\n", product.Description))
+	}
+	if product.UnitPrice != nil {
+		profile.WriteString(fmt.Sprintf("  Unit price: %s %.2f\n", product.Currency, *product.UnitPrice))
+	}
+	if product.ProductionCapacity != nil {
+		profile.WriteString(fmt.Sprintf("  Production capacity: %g %s\n", *product.ProductionCapacity, product.CapacityUnit))
+	}
+	if len(product.Variants) > 0 {
+		profile.WriteString("  Variants:\n")
+		for _, variant := range product.Variants {
+			profile.WriteString(fmt.Sprintf("    - %s", variant.Name))
+			if len(variant.Attributes) > 0 {
+				names := make([]string, 0, len(variant.Attributes))
+				for name := range variant.Attributes {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				attributes := make([]string, 0, len(names))
+				for _, name := range names {
+					attributes = append(attributes, fmt.Sprintf("%s: %s", name, variant.Attributes[name]))
+				}
+				profile.WriteString(fmt.Sprintf(" (%s)", strings.Join(attributes, ", ")))
+			}
+			profile.WriteString("\n")
+		}
+	}
+}
@@ -37,9 +37,14 @@ func (s *GenAIService) AnalyzeBusinessLegals(businessID uint) (*models.LegalComp
 	return s.Service.AnalyzeBusinessLegals(businessID)
 }
 
-// GenerateBusinessSuggestions generates AI-powered business improvement suggestions
+// GenerateBusinessSuggestions generates AI-powered business improvement suggestions, guided by
+// the profile completeness
 func (s *GenAIService) GenerateBusinessSuggestions(businessID uint, isRefresh bool) (*genai.AISuggestionsResponse, error) {
-	return s.Service.GenerateBusinessSuggestions(businessID, isRefresh)
+	completeness, err := NewBusinessService(s.DB).GetBusinessCompleteness(businessID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Service.GenerateBusinessSuggestions(businessID, isRefresh, completeness)
 }
 
 // GetInvestmentAdviceWithContext provides AI investment advice with real database context
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"fmt"
+	"go-gin-backend/internal/models"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Failed attempts on one username since its last successful login
+	loginFailureWindow    = 15 * time.Minute
+	loginDelayThreshold   = 3 // Failures before each attempt has to wait
+	loginMaxDelay         = time.Minute
+	loginLockoutThreshold = 10 // Failures before the account is locked
+	loginLockoutDuration  = 15 * time.Minute
+
+	// Failed attempts from one IP address across all usernames
+	ipFailureWindow = 15 * time.Minute
+	ipFailureLimit  = 30
+)
+
+// SecurityEventFilter narrows down the security events returned to admins
+type SecurityEventFilter struct {
+	Type      string
+	UserID    uint
+	IPAddress string
+	Page      int
+	Limit     int
+}
+
+// LoginGuardService throttles sign-in attempts per account and per IP address
+type LoginGuardService struct {
+	DB *gorm.DB
+}
+
+func NewLoginGuardService(db *gorm.DB) *LoginGuardService {
+	return &LoginGuardService{DB: db}
+}
+
+// Check returns a *RateLimitError when the username or IP address has to wait
+// before trying again. An empty username only checks the IP address.
+func (s *LoginGuardService) Check(username, ipAddress string) error {
+	now := time.Now()
+
+	if ipAddress != "" {
+		var ipFailures []models.LoginAttempt
+		if err := s.DB.Where("ip_address = ? AND success = ? AND created_at > ?", ipAddress, false, now.Add(-ipFailureWindow)).
+			Order("created_at ASC").
+			Limit(ipFailureLimit).
+			Find(&ipFailures).Error; err != nil {
+			return err
+		}
+		if len(ipFailures) >= ipFailureLimit {
+			return &RateLimitError{RetryAfter: ipFailures[0].CreatedAt.Add(ipFailureWindow).Sub(now)}
+		}
+	}
+
+	if username == "" {
+		return nil
+	}
+
+	failures, lastFailure, err := s.recentFailures(normalizeUsername(username))
+	if err != nil {
+		return err
+	}
+
+	var wait time.Duration
+	switch {
+	case failures >= loginLockoutThreshold:
+		wait = lastFailure.Add(loginLockoutDuration).Sub(now)
+	case failures >= loginDelayThreshold:
+		wait = lastFailure.Add(progressiveDelay(failures)).Sub(now)
+	}
+	if wait > 0 {
+		return &RateLimitError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// RecordFailure stores a failed attempt and locks the account or flags the IP address
+// once they cross their thresholds
+func (s *LoginGuardService) RecordFailure(username string, userID *uint, ipAddress string) {
+	username = normalizeUsername(username)
+
+	if err := s.DB.Create(&models.LoginAttempt{
+		Username:  username,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		Success:   false,
+	}).Error; err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+		return
+	}
+
+	if username != "" {
+		failures, _, err := s.recentFailures(username)
+		if err == nil && failures == loginLockoutThreshold {
+			s.lockAccount(username, userID, ipAddress)
+		}
+	}
+
+	if ipAddress != "" {
+		var ipFailures int64
+		err := s.DB.Model(&models.LoginAttempt{}).
+			Where("ip_address = ? AND success = ? AND created_at > ?", ipAddress, false, time.Now().Add(-ipFailureWindow)).
+			Count(&ipFailures).Error
+		if err == nil && ipFailures == ipFailureLimit {
+			blockedUntil := time.Now().Add(ipFailureWindow)
+			s.recordEvent(&models.SecurityEvent{
+				Type:      models.SecurityEventIPBlocked,
+				IPAddress: ipAddress,
+				Details:   fmt.Sprintf("%d failed logins within %s", ipFailureLimit, ipFailureWindow),
+				ExpiresAt: &blockedUntil,
+			})
+		}
+	}
+}
+
+// RecordSuccess stores a successful attempt, which resets the username's failure count
+func (s *LoginGuardService) RecordSuccess(username string, userID uint, ipAddress string) {
+	if err := s.DB.Create(&models.LoginAttempt{
+		Username:  normalizeUsername(username),
+		UserID:    &userID,
+		IPAddress: ipAddress,
+		Success:   true,
+	}).Error; err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+	}
+
+	s.DB.Model(&models.User{}).Where("id = ? AND locked_until IS NOT NULL", userID).Update("locked_until", nil)
+}
+
+// Unlock clears the failures that locked the account so the user can sign in right away
+func (s *LoginGuardService) Unlock(user *models.User) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ? AND success = ?", normalizeUsername(user.Username), false).
+			Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Update("locked_until", nil).Error
+	})
+}
+
+// ListSecurityEvents returns the lockouts and IP blocks matching the filter, newest first,
+// with the total count
+func (s *LoginGuardService) ListSecurityEvents(filter SecurityEventFilter) ([]models.SecurityEvent, int64, error) {
+	query := s.DB.Model(&models.SecurityEvent{})
+
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.SecurityEvent
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(filter.Limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// recentFailures counts failures for the username since its last success within the window
+func (s *LoginGuardService) recentFailures(username string) (int, time.Time, error) {
+	since := time.Now().Add(-loginFailureWindow)
+
+	var lastSuccess models.LoginAttempt
+	err := s.DB.Where("username = ? AND success = ? AND created_at > ?", username, true, since).
+		Order("created_at DESC").
+		First(&lastSuccess).Error
+	if err == nil {
+		since = lastSuccess.CreatedAt
+	} else if err != gorm.ErrRecordNotFound {
+		return 0, time.Time{}, err
+	}
+
+	var failures []models.LoginAttempt
+	if err := s.DB.Where("username = ? AND success = ? AND created_at > ?", username, false, since).
+		Order("created_at DESC").
+		Find(&failures).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if len(failures) == 0 {
+		return 0, time.Time{}, nil
+	}
+	return len(failures), failures[0].CreatedAt, nil
+}
+
+func (s *LoginGuardService) lockAccount(username string, userID *uint, ipAddress string) {
+	lockedUntil := time.Now().Add(loginLockoutDuration)
+
+	if userID != nil {
+		if err := s.DB.Model(&models.User{}).Where("id = ?", *userID).Update("locked_until", lockedUntil).Error; err != nil {
+			log.Printf("Failed to lock user %d: %v", *userID, err)
+		}
+	}
+
+	s.recordEvent(&models.SecurityEvent{
+		Type:      models.SecurityEventAccountLocked,
+		UserID:    userID,
+		Username:  username,
+		IPAddress: ipAddress,
+		Details:   fmt.Sprintf("%d failed logins within %s", loginLockoutThreshold, loginFailureWindow),
+		ExpiresAt: &lockedUntil,
+	})
+}
+
+func (s *LoginGuardService) recordEvent(event *models.SecurityEvent) {
+	if err := s.DB.Create(event).Error; err != nil {
+		log.Printf("Failed to record security event: %v", err)
+	}
+}
+
+// progressiveDelay doubles the wait with every failure past the delay threshold
+func progressiveDelay(failures int) time.Duration {
+	delay := time.Second << (failures - loginDelayThreshold)
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"testing"
+)
+
+func TestLoginGuardLocksAccount(t *testing.T) {
+	db := newTestDB(t)
+	guard := NewLoginGuardService(db)
+	user := createTestUser(t, db, "alice")
+
+	for i := 0; i < loginLockoutThreshold; i++ {
+		guard.RecordFailure("Alice", &user.ID, "10.0.0.1")
+	}
+
+	var rateLimit *RateLimitError
+	if err := guard.Check("alice", "10.0.0.2"); !errors.As(err, &rateLimit) {
+		t.Fatalf("Check() after %d failures: error = %v, want a rate limit", loginLockoutThreshold, err)
+	}
+	if rateLimit.RetryAfter <= loginMaxDelay {
+		t.Errorf("RetryAfter = %v, want the lockout duration", rateLimit.RetryAfter)
+	}
+
+	events, total, err := guard.ListSecurityEvents(SecurityEventFilter{UserID: user.ID, Page: 1, Limit: 20})
+	if err != nil {
+		t.Fatalf("ListSecurityEvents: %v", err)
+	}
+	if total != 1 || len(events) != 1 || events[0].Type != models.SecurityEventAccountLocked {
+		t.Fatalf("got %d events %+v, want one account lock", total, events)
+	}
+
+	if err := guard.Unlock(user); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := guard.Check("alice", "10.0.0.2"); err != nil {
+		t.Errorf("Check() after unlocking: error = %v, want nil", err)
+	}
+}
+
+func TestProgressiveDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     string
+	}{
+		{loginDelayThreshold, "1s"},
+		{loginDelayThreshold + 1, "2s"},
+		{loginDelayThreshold + 3, "8s"},
+		{loginDelayThreshold + 10, "1m0s"},
+	}
+	for _, tt := range tests {
+		if got := progressiveDelay(tt.failures).String(); got != tt.want {
+			t.Errorf("progressiveDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
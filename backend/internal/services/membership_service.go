@@ -0,0 +1,345 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/mailer"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/sms"
+	"go-gin-backend/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidBusinessRole is returned for roles other than owner, editor and viewer
+	ErrInvalidBusinessRole = errors.New("invalid business role")
+	// ErrLastOwner is returned when a change would leave a business without an owner
+	ErrLastOwner = errors.New("business must keep at least one owner")
+	// ErrMemberNotFound is returned when a membership does not exist in the business
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrInvitationNotFound is returned for unknown, used, revoked or expired invitations
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationMismatch is returned when the invitation was sent to someone else
+	ErrInvitationMismatch = errors.New("invitation was sent to a different email or phone number")
+	// ErrInvitationRecipientRequired is returned when inviting without an email or phone number
+	ErrInvitationRecipientRequired = errors.New("email or phone number is required")
+)
+
+// MembershipService manages who can access a business and with which role
+type MembershipService struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	SMS    sms.SMSSender
+	Actor  AuditActor // Recorded in the audit log for every change
+}
+
+func NewMembershipService(db *gorm.DB, mailSender mailer.Mailer, smsSender sms.SMSSender) *MembershipService {
+	return &MembershipService{DB: db, Mailer: mailSender, SMS: smsSender}
+}
+
+// WithActor returns a copy of the service that attributes its changes to the actor
+func (s *MembershipService) WithActor(actor AuditActor) *MembershipService {
+	scoped := *s
+	scoped.Actor = actor
+	return &scoped
+}
+
+func (s *MembershipService) audit(tx *gorm.DB, businessID uint, entityType string, entityID uint, action string, before, after interface{}) error {
+	return recordAudit(tx, s.Actor, &businessID, entityType, entityID, action, before, after)
+}
+
+// ListMembers returns the members of a business with their user profiles
+func (s *MembershipService) ListMembers(businessID uint) ([]models.BusinessMember, error) {
+	var members []models.BusinessMember
+	if err := s.DB.Preload("User").
+		Where("business_id = ?", businessID).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes a member's role, keeping at least one owner
+func (s *MembershipService) UpdateMemberRole(businessID, memberID uint, role string) (*models.BusinessMember, error) {
+	if !models.IsValidBusinessRole(role) {
+		return nil, ErrInvalidBusinessRole
+	}
+
+	var member models.BusinessMember
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND business_id = ?", memberID, businessID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMemberNotFound
+			}
+			return err
+		}
+
+		if member.Role == models.BusinessRoleOwner && role != models.BusinessRoleOwner {
+			if err := ensureAnotherOwner(tx, businessID, member.UserID); err != nil {
+				return err
+			}
+		}
+
+		before := member
+		if err := tx.Model(&member).Update("role", role).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityMember, member.ID, models.AuditActionUpdate, &before, &member); err != nil {
+			return err
+		}
+
+		return syncPrimaryOwner(tx, businessID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// RemoveMember revokes a member's access, keeping at least one owner
+func (s *MembershipService) RemoveMember(businessID, memberID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var member models.BusinessMember
+		if err := tx.Where("id = ? AND business_id = ?", memberID, businessID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMemberNotFound
+			}
+			return err
+		}
+
+		if member.Role == models.BusinessRoleOwner {
+			if err := ensureAnotherOwner(tx, businessID, member.UserID); err != nil {
+				return err
+			}
+		}
+
+		// Soft delete keeps the membership for the record; accepting a new invitation restores it
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+		if err := s.audit(tx, businessID, models.AuditEntityMember, member.ID, models.AuditActionDelete, &member, nil); err != nil {
+			return err
+		}
+
+		return syncPrimaryOwner(tx, businessID)
+	})
+}
+
+// Invite creates an invitation and sends it by email or, if no email is given, by SMS
+func (s *MembershipService) Invite(businessID, inviterID uint, email, phoneNumber, role string) (*models.BusinessInvitation, error) {
+	if !models.IsValidBusinessRole(role) {
+		return nil, ErrInvalidBusinessRole
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if phoneNumber != "" {
+		phoneNumber = utils.NormalizePhoneNumber(phoneNumber)
+	}
+	if email == "" && phoneNumber == "" {
+		return nil, ErrInvitationRecipientRequired
+	}
+
+	var business models.Business
+	if err := s.DB.First(&business, businessID).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.BusinessInvitation{
+		BusinessID:  businessID,
+		Email:       email,
+		PhoneNumber: phoneNumber,
+		Role:        role,
+		TokenHash:   utils.HashToken(token),
+		InvitedByID: inviterID,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(invitation).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, businessID, models.AuditEntityInvitation, invitation.ID, models.AuditActionCreate, nil, invitation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	link := appURL("/invitations/accept", token)
+	if email != "" {
+		err = s.Mailer.Send(mailer.Message{
+			To:      email,
+			Subject: fmt.Sprintf("Undangan bergabung dengan %s", business.Name),
+			Body: fmt.Sprintf("Anda diundang untuk bergabung dengan bisnis %s sebagai %s.\n\nTerima undangan melalui tautan berikut dalam %d hari:\n\n%s",
+				business.Name, role, int(invitationTTL.Hours()/24), link),
+		})
+	} else {
+		err = s.SMS.Send(phoneNumber, fmt.Sprintf("Anda diundang bergabung dengan %s sebagai %s. Terima undangan: %s", business.Name, role, link))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to deliver invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations returns the pending invitations of a business
+func (s *MembershipService) ListInvitations(businessID uint) ([]models.BusinessInvitation, error) {
+	var invitations []models.BusinessInvitation
+	if err := s.DB.Where("business_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", businessID, time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation cancels a pending invitation
+func (s *MembershipService) RevokeInvitation(businessID, invitationID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var invitation models.BusinessInvitation
+		if err := tx.Where("id = ? AND business_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID, businessID).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+
+		before := invitation
+		if err := tx.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, businessID, models.AuditEntityInvitation, invitation.ID, models.AuditActionUpdate, &before, &invitation)
+	})
+}
+
+// AcceptInvitation adds the user to the business if the invitation was sent to their
+// email or phone number. An existing membership is upgraded, never downgraded.
+func (s *MembershipService) AcceptInvitation(userID uint, token string) (*models.BusinessMember, error) {
+	var member models.BusinessMember
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var invitation models.BusinessInvitation
+		if err := tx.Where("token_hash = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", utils.HashToken(token), time.Now()).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		emailMatches := invitation.Email != "" && strings.EqualFold(invitation.Email, user.Email)
+		phoneMatches := invitation.PhoneNumber != "" && invitation.PhoneNumber == utils.NormalizePhoneNumber(user.PhoneNumber)
+		if !emailMatches && !phoneMatches {
+			return ErrInvitationMismatch
+		}
+
+		// Members removed earlier are brought back with the invited role
+		err := tx.Unscoped().Where("business_id = ? AND user_id = ?", invitation.BusinessID, userID).First(&member).Error
+		before := member
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			member = models.BusinessMember{
+				BusinessID: invitation.BusinessID,
+				UserID:     userID,
+				Role:       invitation.Role,
+			}
+			if err := tx.Create(&member).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, invitation.BusinessID, models.AuditEntityMember, member.ID, models.AuditActionCreate, nil, &member); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case member.DeletedAt.Valid:
+			if err := tx.Unscoped().Model(&member).Updates(map[string]interface{}{
+				"deleted_at": nil,
+				"role":       invitation.Role,
+			}).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, invitation.BusinessID, models.AuditEntityMember, member.ID, models.AuditActionRestore, &before, &member); err != nil {
+				return err
+			}
+		case !models.BusinessRoleAtLeast(member.Role, invitation.Role):
+			if err := tx.Model(&member).Update("role", invitation.Role).Error; err != nil {
+				return err
+			}
+			if err := s.audit(tx, invitation.BusinessID, models.AuditEntityMember, member.ID, models.AuditActionUpdate, &before, &member); err != nil {
+				return err
+			}
+		}
+
+		invitationBefore := invitation
+		if err := tx.Model(&invitation).Updates(map[string]interface{}{
+			"accepted_at":    time.Now(),
+			"accepted_by_id": userID,
+		}).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, invitation.BusinessID, models.AuditEntityInvitation, invitation.ID, models.AuditActionUpdate, &invitationBefore, &invitation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// ensureAnotherOwner fails unless the business has an owner other than userID
+func ensureAnotherOwner(tx *gorm.DB, businessID, userID uint) error {
+	var owners int64
+	if err := tx.Model(&models.BusinessMember{}).
+		Where("business_id = ? AND role = ? AND user_id <> ?", businessID, models.BusinessRoleOwner, userID).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// syncPrimaryOwner keeps Business.UserID pointing at one of the current owners
+func syncPrimaryOwner(tx *gorm.DB, businessID uint) error {
+	var business models.Business
+	if err := tx.Unscoped().Select("id", "user_id").First(&business, businessID).Error; err != nil {
+		return err
+	}
+
+	var owner models.BusinessMember
+	if err := tx.Where("business_id = ? AND role = ?", businessID, models.BusinessRoleOwner).
+		Order("created_at ASC").
+		First(&owner).Error; err != nil {
+		return err
+	}
+
+	var stillOwner int64
+	if err := tx.Model(&models.BusinessMember{}).
+		Where("business_id = ? AND user_id = ? AND role = ?", businessID, business.UserID, models.BusinessRoleOwner).
+		Count(&stillOwner).Error; err != nil {
+		return err
+	}
+	if stillOwner > 0 {
+		return nil
+	}
+
+	// The business may have been deleted
+	return tx.Unscoped().Model(&business).Update("user_id", owner.UserID).Error
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"slices"
+	"testing"
+)
+
+// memberAuditActions returns the audit actions recorded for the entity type, oldest first
+func memberAuditActions(t *testing.T, s *MembershipService, businessID uint, entityType string) []string {
+	t.Helper()
+
+	var actions []string
+	if err := s.DB.Model(&models.AuditLog{}).
+		Where("business_id = ? AND entity_type = ?", businessID, entityType).
+		Order("id").
+		Pluck("action", &actions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return actions
+}
+
+func TestMembershipChangesAreAudited(t *testing.T) {
+	db := newTestDB(t)
+	mail := &captureMailer{}
+	owner := createTestUser(t, db, "owner")
+	invitee := createTestUser(t, db, "bob")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+
+	ownerService := NewMembershipService(db, mail, &captureSender{}).WithActor(AuditActor{UserID: owner.ID})
+	inviteeService := NewMembershipService(db, mail, &captureSender{}).WithActor(AuditActor{UserID: invitee.ID})
+
+	invite := func() string {
+		t.Helper()
+		if _, err := ownerService.Invite(business.ID, owner.ID, invitee.Email, "", models.BusinessRoleViewer); err != nil {
+			t.Fatalf("Invite: %v", err)
+		}
+		return mail.lastEmailToken(t)
+	}
+
+	member, err := inviteeService.AcceptInvitation(invitee.ID, invite())
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if _, err := ownerService.UpdateMemberRole(business.ID, member.ID, models.BusinessRoleEditor); err != nil {
+		t.Fatalf("UpdateMemberRole: %v", err)
+	}
+	if err := ownerService.RemoveMember(business.ID, member.ID); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+
+	role, err := NewBusinessService(db).GetMemberRole(invitee.ID, business.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role != "" {
+		t.Fatalf("removed member still has role %q", role)
+	}
+
+	// Invited again after being removed, the membership comes back with the new role
+	restored, err := inviteeService.AcceptInvitation(invitee.ID, invite())
+	if err != nil {
+		t.Fatalf("accepting a second invitation: %v", err)
+	}
+	if restored.ID != member.ID || restored.Role != models.BusinessRoleViewer || restored.DeletedAt.Valid {
+		t.Errorf("restored membership = %+v, want member %d back as viewer", restored, member.ID)
+	}
+
+	second, err := ownerService.Invite(business.ID, owner.ID, "", "+6280000000000", models.BusinessRoleViewer)
+	if err != nil {
+		t.Fatalf("Invite by phone: %v", err)
+	}
+	if err := ownerService.RevokeInvitation(business.ID, second.ID); err != nil {
+		t.Fatalf("RevokeInvitation: %v", err)
+	}
+	if err := ownerService.RevokeInvitation(business.ID, second.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("revoking twice: error = %v, want %v", err, ErrInvitationNotFound)
+	}
+
+	wantMember := []string{models.AuditActionCreate, models.AuditActionUpdate, models.AuditActionDelete, models.AuditActionRestore}
+	if got := memberAuditActions(t, ownerService, business.ID, models.AuditEntityMember); !slices.Equal(got, wantMember) {
+		t.Errorf("member audit actions = %v, want %v", got, wantMember)
+	}
+	wantInvitation := []string{
+		models.AuditActionCreate, models.AuditActionUpdate, // First invitation sent and accepted
+		models.AuditActionCreate, models.AuditActionUpdate, // Second invitation sent and accepted
+		models.AuditActionCreate, models.AuditActionUpdate, // Invitation by phone sent and revoked
+	}
+	if got := memberAuditActions(t, ownerService, business.ID, models.AuditEntityInvitation); !slices.Equal(got, wantInvitation) {
+		t.Errorf("invitation audit actions = %v, want %v", got, wantInvitation)
+	}
+
+	var removal models.AuditLog
+	if err := db.Where("entity_type = ? AND action = ?", models.AuditEntityMember, models.AuditActionDelete).First(&removal).Error; err != nil {
+		t.Fatal(err)
+	}
+	if removal.ActorID == nil || *removal.ActorID != owner.ID {
+		t.Errorf("removal actor = %v, want the owner %d", removal.ActorID, owner.ID)
+	}
+}
+
+func TestRemoveLastOwner(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+	business := createTestBusiness(t, db, owner, "Kopi Nusantara")
+	s := NewMembershipService(db, &captureMailer{}, &captureSender{})
+
+	members, err := s.ListMembers(business.ID)
+	if err != nil || len(members) != 1 {
+		t.Fatalf("ListMembers() = %v, %v, want the owner", members, err)
+	}
+	if err := s.RemoveMember(business.ID, members[0].ID); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("removing the last owner: error = %v, want %v", err, ErrLastOwner)
+	}
+	if _, err := s.UpdateMemberRole(business.ID, members[0].ID, models.BusinessRoleViewer); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("demoting the last owner: error = %v, want %v", err, ErrLastOwner)
+	}
+}
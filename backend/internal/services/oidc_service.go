@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/oidc"
+	"go-gin-backend/internal/utils"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const oidcLoginStateTTL = 10 * time.Minute
+
+var (
+	// ErrUnknownOIDCProvider is returned for providers that are not configured
+	ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+	// ErrInvalidOIDCState is returned for unknown, used or expired login states
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	// ErrOIDCLoginFailed is returned when the provider rejects the code or the ID token is invalid
+	ErrOIDCLoginFailed = errors.New("identity provider login failed")
+	// ErrOIDCEmailRequired is returned when a new account would be created without an email address
+	ErrOIDCEmailRequired = errors.New("identity provider did not share an email address")
+	// ErrOIDCEmailNotVerified is returned when the email belongs to an account but the provider
+	// or the account has not verified it, so the identity cannot be linked
+	ErrOIDCEmailNotVerified = errors.New("email address must be verified by the identity provider and on the account to link them")
+	// ErrOIDCAccountUnavailable is returned when the linked account no longer exists
+	ErrOIDCAccountUnavailable = errors.New("linked account no longer exists")
+)
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// OIDCProviderInfo is what clients need to show a sign-in button for a provider
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCService signs users in with external OpenID Connect identity providers
+type OIDCService struct {
+	DB          *gorm.DB
+	AuthService *AuthService
+	Providers   map[string]*oidc.Provider
+}
+
+func NewOIDCService(db *gorm.DB, authService *AuthService, providers map[string]*oidc.Provider) *OIDCService {
+	return &OIDCService{DB: db, AuthService: authService, Providers: providers}
+}
+
+// ListProviders returns the configured providers sorted by name
+func (s *OIDCService) ListProviders() []OIDCProviderInfo {
+	providers := make([]OIDCProviderInfo, 0, len(s.Providers))
+	for _, p := range s.Providers {
+		providers = append(providers, OIDCProviderInfo{Name: p.Name, DisplayName: p.DisplayName})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+// StartLogin remembers a new login attempt and returns the provider URL to send the user to.
+// role is given to the account if this login creates one.
+func (s *OIDCService) StartLogin(providerName, role string) (string, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return "", ErrUnknownOIDCProvider
+	}
+	if role == "" {
+		role = models.RoleOwner
+	}
+	if !models.IsSelfAssignableRole(role) {
+		return "", ErrInvalidRole
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := utils.GenerateRandomToken(32)
+		if err != nil {
+			return "", err
+		}
+		secrets[i] = secret
+	}
+	state, nonce, codeVerifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := provider.AuthCodeURL(state, nonce, codeVerifier)
+	if err != nil {
+		return "", err
+	}
+
+	// Logins that were never completed are cleared out as new ones start
+	if err := s.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		log.Printf("Failed to clean up expired OIDC login states: %v", err)
+	}
+
+	if err := s.DB.Create(&models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		Role:         role,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	}).Error; err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// CompleteLogin redeems the code the provider redirected back with, finds or creates the
+// user for the external identity and signs them in like a password login would
+func (s *OIDCService) CompleteLogin(providerName, code, state string, client ClientInfo) (*LoginResult, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	loginState, err := s.consumeLoginState(providerName, state)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.Exchange(code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC login with %s failed: %v", providerName, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, created, err := s.resolveUser(providerName, claims, loginState.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if created && user.EmailVerifiedAt == nil {
+		if err := s.AuthService.SendEmailVerification(user.ID); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	s.AuthService.LoginGuard.RecordSuccess(user.Username, user.ID, client.IPAddress)
+	return s.AuthService.completeLogin(user, client)
+}
+
+// consumeLoginState deletes the pending login so the state cannot be replayed
+func (s *OIDCService) consumeLoginState(providerName, state string) (*models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	if err := s.DB.Where("state_hash = ? AND provider = ?", utils.HashToken(state), providerName).
+		First(&loginState).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+
+	result := s.DB.Delete(&loginState)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	return &loginState, nil
+}
+
+// resolveUser returns the user linked to the external identity. Identities seen for the
+// first time are linked to the account with the same email address, or to a new account
+// when there is none. Linking needs the address verified on both sides, or someone who
+// registered with an address they do not own would share the account its owner signs in to.
+func (s *OIDCService) resolveUser(providerName string, claims *oidc.IDTokenClaims, role string) (*models.User, bool, error) {
+	var user models.User
+	created := false
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var identity models.ExternalIdentity
+		err := tx.Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&identity).Error
+		if err == nil {
+			if err := tx.First(&user, identity.UserID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrOIDCAccountUnavailable
+				}
+				return err
+			}
+			return tx.Model(&identity).Updates(map[string]interface{}{
+				"email":         claims.Email,
+				"last_login_at": now,
+			}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if claims.Email == "" {
+			return ErrOIDCEmailRequired
+		}
+
+		err = tx.Where("LOWER(email) = LOWER(?)", claims.Email).First(&user).Error
+		switch {
+		case err == nil:
+			if !claims.IsEmailVerified() || user.EmailVerifiedAt == nil {
+				return ErrOIDCEmailNotVerified
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := s.createUser(tx, &user, providerName, claims, role); err != nil {
+				return err
+			}
+			created = true
+		default:
+			return err
+		}
+
+		return tx.Create(&models.ExternalIdentity{
+			UserID:      user.ID,
+			Provider:    providerName,
+			Subject:     claims.Subject,
+			Email:       claims.Email,
+			LastLoginAt: &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &user, created, nil
+}
+
+// createUser creates the account for a first-time external login. It gets an unusable
+// password; the user can set one through the password reset flow.
+func (s *OIDCService) createUser(tx *gorm.DB, user *models.User, providerName string, claims *oidc.IDTokenClaims, role string) error {
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	username, err := availableUsername(tx, claims)
+	if err != nil {
+		return err
+	}
+
+	// Phone numbers are unique, so accounts without one get a placeholder until the user adds theirs
+	phoneNumber := utils.NormalizePhoneNumber(claims.PhoneNumber)
+	if phoneNumber != "" {
+		var taken int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("phone_number IN ?", utils.PhoneNumberVariants(phoneNumber)).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			phoneNumber = ""
+		}
+	}
+	if phoneNumber == "" {
+		phoneNumber = fmt.Sprintf("oidc-%s-%s", providerName, utils.HashToken(claims.Subject)[:16])
+	}
+
+	*user = models.User{
+		Username:    username,
+		Password:    string(hashedPassword),
+		Email:       claims.Email,
+		PhoneNumber: phoneNumber,
+		Role:        role,
+	}
+	if claims.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return NewUserService(tx).CreateUser(user)
+}
+
+// availableUsername derives a free username from the provider's preferred username or the
+// email address
+func availableUsername(tx *gorm.DB, claims *oidc.IDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Trim(usernameUnsafeChars.ReplaceAllString(strings.ToLower(base), ""), "._-")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var taken int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/oidc"
+	"go-gin-backend/internal/oidc/oidctest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestOIDCService(t *testing.T) (*OIDCService, *oidctest.Server, *captureMailer) {
+	t.Helper()
+
+	auth, mail := newTestAuthService(t)
+	auth.LoginGuard = NewLoginGuardService(auth.UserService.DB)
+	idp := oidctest.NewServer(t)
+	providers := map[string]*oidc.Provider{
+		"test": oidc.NewProvider("test", "", idp.URL, oidctest.ClientID, oidctest.ClientSecret, "http://app.test/auth/oidc/test/callback", nil),
+	}
+	return NewOIDCService(auth.UserService.DB, auth, providers), idp, mail
+}
+
+// oidcLogin signs in through the mock provider as the identity
+func oidcLogin(t *testing.T, s *OIDCService, idp *oidctest.Server, identity oidctest.Identity) (*LoginResult, error) {
+	t.Helper()
+
+	authURL, state := startOIDCLogin(t, s)
+	return s.CompleteLogin("test", idp.Authorize(t, authURL, identity), state, ClientInfo{IPAddress: "203.0.113.1"})
+}
+
+func startOIDCLogin(t *testing.T, s *OIDCService) (authURL, state string) {
+	t.Helper()
+
+	authURL, err := s.StartLogin("test", "")
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authURL, u.Query().Get("state")
+}
+
+// linkedUserID returns the account the provider's subject is linked to, or zero
+func linkedUserID(t *testing.T, s *OIDCService, subject string) uint {
+	t.Helper()
+
+	var identities []models.ExternalIdentity
+	if err := s.DB.Where("provider = ? AND subject = ?", "test", subject).Find(&identities).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) == 0 {
+		return 0
+	}
+	return identities[0].UserID
+}
+
+func TestOIDCLoginLinksAccounts(t *testing.T) {
+	tests := []struct {
+		name             string
+		localAccount     bool
+		localVerified    bool
+		providerVerified bool
+		wantErr          error
+		wantLinked       bool // Linked to the local account rather than a new one
+	}{
+		{name: "new account", providerVerified: true},
+		{name: "new account with an unverified email", providerVerified: false},
+		{name: "both verified", localAccount: true, localVerified: true, providerVerified: true, wantLinked: true},
+		// Someone registered the address without proving they own it
+		{name: "local email unverified", localAccount: true, providerVerified: true, wantErr: ErrOIDCEmailNotVerified},
+		{name: "provider email unverified", localAccount: true, localVerified: true, wantErr: ErrOIDCEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, idp, mail := newTestOIDCService(t)
+			var local *models.User
+			if tt.localAccount {
+				local = createTestUser(t, s.DB, "alice")
+				if tt.localVerified {
+					if err := s.DB.Model(local).Update("email_verified_at", time.Now()).Error; err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			result, err := oidcLogin(t, s, idp, oidctest.Identity{
+				Subject:       "subject-1",
+				Email:         "Alice@example.com",
+				EmailVerified: tt.providerVerified,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteLogin() error = %v, want %v", err, tt.wantErr)
+			}
+
+			userID := linkedUserID(t, s, "subject-1")
+			if err != nil {
+				if userID != 0 {
+					t.Errorf("the identity was linked to user %d", userID)
+				}
+				return
+			}
+			if result.TokenPair == nil {
+				t.Fatalf("CompleteLogin() = %+v, want tokens", result)
+			}
+
+			switch {
+			case tt.wantLinked && userID != local.ID:
+				t.Errorf("identity linked to user %d, want the local account %d", userID, local.ID)
+			case !tt.wantLinked && (userID == 0 || (local != nil && userID == local.ID)):
+				t.Errorf("identity linked to user %d, want a new account", userID)
+			}
+			if tt.wantLinked {
+				return
+			}
+
+			user, err := NewUserService(s.DB).GetUserByID(userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Email != "Alice@example.com" || user.Role != models.RoleOwner {
+				t.Errorf("created user %+v", user)
+			}
+			if verified := user.EmailVerifiedAt != nil; verified != tt.providerVerified {
+				t.Errorf("email verified = %v, want %v", verified, tt.providerVerified)
+			}
+			if sent := len(mail.messages) > 0; sent == tt.providerVerified {
+				t.Errorf("verification email sent = %v, want %v", sent, !tt.providerVerified)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginReturningIdentity(t *testing.T) {
+	s, idp, _ := newTestOIDCService(t)
+	identity := oidctest.Identity{Subject: "subject-1", Email: "alice@example.com", EmailVerified: true}
+
+	if _, err := oidcLogin(t, s, idp, identity); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	userID := linkedUserID(t, s, "subject-1")
+
+	// The provider's email may change; the subject keeps identifying the account
+	identity.Email = "alice@new.example.com"
+	if _, err := oidcLogin(t, s, idp, identity); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if got := linkedUserID(t, s, "subject-1"); got != userID {
+		t.Errorf("second login linked to user %d, want %d", got, userID)
+	}
+	var users int64
+	if err := s.DB.Model(&models.User{}).Count(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if users != 1 {
+		t.Errorf("%d users exist, want 1", users)
+	}
+}
+
+func TestOIDCLoginState(t *testing.T) {
+	s, idp, _ := newTestOIDCService(t)
+	identity := oidctest.Identity{Subject: "subject-1", Email: "alice@example.com", EmailVerified: true}
+	client := ClientInfo{IPAddress: "203.0.113.1"}
+
+	authURL, state := startOIDCLogin(t, s)
+	if _, err := s.CompleteLogin("test", idp.Authorize(t, authURL, identity), "forged-state", client); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("unknown state: error = %v, want %v", err, ErrInvalidOIDCState)
+	}
+	if _, err := s.CompleteLogin("test", idp.Authorize(t, authURL, identity), state, client); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if _, err := s.CompleteLogin("test", idp.Authorize(t, authURL, identity), state, client); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed state: error = %v, want %v", err, ErrInvalidOIDCState)
+	}
+	if _, err := s.CompleteLogin("other", "code", state, client); !errors.Is(err, ErrUnknownOIDCProvider) {
+		t.Errorf("unknown provider: error = %v, want %v", err, ErrUnknownOIDCProvider)
+	}
+}
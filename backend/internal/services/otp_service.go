@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/sms"
+	"go-gin-backend/internal/utils"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	otpLength        = 6
+	otpTTL           = 5 * time.Minute
+	otpMaxAttempts   = 5
+	otpResendWait    = time.Minute
+	otpHourlyLimit   = 5
+	otpLimiterWindow = time.Hour
+)
+
+// ErrInvalidOTP is returned for wrong, expired or exhausted codes
+var ErrInvalidOTP = errors.New("invalid or expired code")
+
+// RateLimitError is returned when an action has to wait before it can be retried
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %d seconds", int(e.RetryAfter.Seconds()))
+}
+
+type OTPService struct {
+	DB     *gorm.DB
+	Sender sms.SMSSender
+}
+
+func NewOTPService(db *gorm.DB, sender sms.SMSSender) *OTPService {
+	return &OTPService{DB: db, Sender: sender}
+}
+
+// SendOTP texts a new code to the phone number, replacing any pending code for the same purpose.
+// Resends are limited to one per minute and a few per hour for each number.
+func (s *OTPService) SendOTP(phoneNumber, purpose string) error {
+	phoneNumber = utils.NormalizePhoneNumber(phoneNumber)
+
+	if err := s.checkResendLimit(phoneNumber, purpose); err != nil {
+		return err
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PhoneOTP{}).
+			Where("phone_number = ? AND purpose = ? AND consumed_at IS NULL", phoneNumber, purpose).
+			Update("consumed_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PhoneOTP{
+			PhoneNumber: phoneNumber,
+			Purpose:     purpose,
+			CodeHash:    hashOTP(phoneNumber, code),
+			ExpiresAt:   time.Now().Add(otpTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Kode verifikasi Anda: %s. Berlaku %d menit. Jangan bagikan kode ini kepada siapa pun.", code, int(otpTTL.Minutes()))
+	return s.Sender.Send(phoneNumber, message)
+}
+
+// VerifyOTP checks a code against the latest pending code and consumes it on success
+func (s *OTPService) VerifyOTP(phoneNumber, purpose, code string) error {
+	phoneNumber = utils.NormalizePhoneNumber(phoneNumber)
+
+	var otp models.PhoneOTP
+	if err := s.DB.Where("phone_number = ? AND purpose = ? AND consumed_at IS NULL", phoneNumber, purpose).
+		Order("created_at DESC").
+		First(&otp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidOTP
+		}
+		return err
+	}
+
+	if time.Now().After(otp.ExpiresAt) || otp.Attempts >= otpMaxAttempts {
+		return ErrInvalidOTP
+	}
+
+	if !hmac.Equal([]byte(otp.CodeHash), []byte(hashOTP(phoneNumber, code))) {
+		updates := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+		if otp.Attempts+1 >= otpMaxAttempts {
+			updates["consumed_at"] = time.Now() // Burn the code after too many guesses
+		}
+		if err := s.DB.Model(&otp).Updates(updates).Error; err != nil {
+			return err
+		}
+		return ErrInvalidOTP
+	}
+
+	result := s.DB.Model(&otp).Where("consumed_at IS NULL").Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidOTP
+	}
+
+	return nil
+}
+
+func (s *OTPService) checkResendLimit(phoneNumber, purpose string) error {
+	var recent []models.PhoneOTP
+	if err := s.DB.Where("phone_number = ? AND purpose = ? AND created_at > ?", phoneNumber, purpose, time.Now().Add(-otpLimiterWindow)).
+		Order("created_at DESC").
+		Find(&recent).Error; err != nil {
+		return err
+	}
+
+	if len(recent) == 0 {
+		return nil
+	}
+
+	if wait := otpResendWait - time.Since(recent[0].CreatedAt); wait > 0 {
+		return &RateLimitError{RetryAfter: wait}
+	}
+
+	if len(recent) >= otpHourlyLimit {
+		oldest := recent[len(recent)-1]
+		return &RateLimitError{RetryAfter: otpLimiterWindow - time.Since(oldest.CreatedAt)}
+	}
+
+	return nil
+}
+
+// generateOTPCode returns a random numeric code of otpLength digits
+func generateOTPCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpLength, n), nil
+}
+
+func hashOTP(phoneNumber, code string) string {
+	return utils.HashToken(phoneNumber + ":" + code)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"regexp"
+	"testing"
+	"time"
+)
+
+const testPhoneNumber = "+6281234567890"
+
+// captureSender keeps texted messages instead of delivering them
+type captureSender struct {
+	messages []string
+}
+
+func (s *captureSender) Send(phoneNumber, message string) error {
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+var otpCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// lastCode returns the code in the last message sent
+func (s *captureSender) lastCode(t *testing.T) string {
+	t.Helper()
+
+	if len(s.messages) == 0 {
+		t.Fatal("no message was sent")
+	}
+	code := otpCodePattern.FindString(s.messages[len(s.messages)-1])
+	if code == "" {
+		t.Fatal("message does not contain a code")
+	}
+	return code
+}
+
+func newTestOTPService(t *testing.T) (*OTPService, *captureSender) {
+	t.Helper()
+
+	sender := &captureSender{}
+	return NewOTPService(newTestDB(t), sender), sender
+}
+
+// wrongCode returns a code that differs from the one sent
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestVerifyOTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		verify  func(t *testing.T, s *OTPService, code string) string
+		wantErr error
+	}{
+		{
+			name:   "correct code",
+			verify: func(_ *testing.T, _ *OTPService, code string) string { return code },
+		},
+		{
+			name:    "wrong code",
+			verify:  func(_ *testing.T, _ *OTPService, code string) string { return wrongCode(code) },
+			wantErr: ErrInvalidOTP,
+		},
+		{
+			name: "correct code after a few wrong guesses",
+			verify: func(t *testing.T, s *OTPService, code string) string {
+				for i := 0; i < otpMaxAttempts-1; i++ {
+					if err := s.VerifyOTP(testPhoneNumber, models.OTPPurposeLogin, wrongCode(code)); !errors.Is(err, ErrInvalidOTP) {
+						t.Fatalf("guess %d: error = %v, want %v", i+1, err, ErrInvalidOTP)
+					}
+				}
+				return code
+			},
+		},
+		{
+			name: "correct code after too many wrong guesses",
+			verify: func(t *testing.T, s *OTPService, code string) string {
+				for i := 0; i < otpMaxAttempts; i++ {
+					if err := s.VerifyOTP(testPhoneNumber, models.OTPPurposeLogin, wrongCode(code)); !errors.Is(err, ErrInvalidOTP) {
+						t.Fatalf("guess %d: error = %v, want %v", i+1, err, ErrInvalidOTP)
+					}
+				}
+				return code
+			},
+			wantErr: ErrInvalidOTP,
+		},
+		{
+			name: "code already used",
+			verify: func(t *testing.T, s *OTPService, code string) string {
+				if err := s.VerifyOTP(testPhoneNumber, models.OTPPurposeLogin, code); err != nil {
+					t.Fatal(err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidOTP,
+		},
+		{
+			name: "expired code",
+			verify: func(t *testing.T, s *OTPService, code string) string {
+				if err := s.DB.Model(&models.PhoneOTP{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+					t.Fatal(err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidOTP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sender := newTestOTPService(t)
+			if err := s.SendOTP(testPhoneNumber, models.OTPPurposeLogin); err != nil {
+				t.Fatalf("SendOTP: %v", err)
+			}
+
+			code := tt.verify(t, s, sender.lastCode(t))
+			if err := s.VerifyOTP(testPhoneNumber, models.OTPPurposeLogin, code); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyOTP() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyOTPChecksPurpose(t *testing.T) {
+	s, sender := newTestOTPService(t)
+	if err := s.SendOTP(testPhoneNumber, models.OTPPurposePhoneVerification); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.VerifyOTP(testPhoneNumber, models.OTPPurposeLogin, sender.lastCode(t)); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("VerifyOTP() with another purpose: error = %v, want %v", err, ErrInvalidOTP)
+	}
+}
+
+func TestSendOTPRateLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []time.Duration // How long ago earlier codes were sent
+		limited  bool
+	}{
+		{"first code", nil, false},
+		{"resend within a minute", []time.Duration{30 * time.Second}, true},
+		{"resend after a minute", []time.Duration{2 * time.Minute}, false},
+		{"hourly limit reached", []time.Duration{2 * time.Minute, 10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 40 * time.Minute}, true},
+		{"hourly limit over", []time.Duration{2 * time.Minute, 10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 70 * time.Minute}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sender := newTestOTPService(t)
+			for _, ago := range tt.previous {
+				sentAt := time.Now().Add(-ago)
+				otp := &models.PhoneOTP{PhoneNumber: testPhoneNumber, Purpose: models.OTPPurposeLogin, CodeHash: "x", ExpiresAt: sentAt.Add(otpTTL)}
+				otp.CreatedAt = sentAt
+				if err := s.DB.Create(otp).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := s.SendOTP(testPhoneNumber, models.OTPPurposeLogin)
+			var rateLimit *RateLimitError
+			if limited := errors.As(err, &rateLimit); limited != tt.limited {
+				t.Fatalf("SendOTP() error = %v, want rate limited %v", err, tt.limited)
+			}
+			if tt.limited {
+				if rateLimit.RetryAfter <= 0 {
+					t.Errorf("RetryAfter = %v, want a positive wait", rateLimit.RetryAfter)
+				}
+				if len(sender.messages) != 0 {
+					t.Errorf("sent %d messages while rate limited", len(sender.messages))
+				}
+			} else if err != nil || len(sender.messages) != 1 {
+				t.Errorf("SendOTP() error = %v, sent %d messages, want one", err, len(sender.messages))
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// AccessTokenTTL is the lifetime of a signed access token
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is the lifetime of a session and its refresh token
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrSessionNotFound is returned when a session does not exist for the user
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// ClientInfo describes the client a session was created from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type SessionService struct {
+	DB *gorm.DB
+}
+
+func NewSessionService(db *gorm.DB) *SessionService {
+	return &SessionService{DB: db}
+}
+
+// CreateSession starts a new session for the user and returns its raw refresh token
+func (s *SessionService) CreateSession(userID uint, client ClientInfo) (*models.Session, string, error) {
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+	}
+
+	if err := s.DB.Create(session).Error; err != nil {
+		return nil, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one on the same session.
+// Presenting an already rotated token revokes the session, since it means the token leaked.
+func (s *SessionService) RotateRefreshToken(refreshToken string, client ClientInfo) (*models.Session, string, error) {
+	tokenHash := utils.HashToken(refreshToken)
+
+	var session models.Session
+	err := s.DB.Where("refresh_token_hash = ?", tokenHash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var reused models.Session
+		if s.DB.Where("previous_token_hash = ?", tokenHash).First(&reused).Error == nil {
+			s.revoke(&reused)
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !session.IsActive() {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	newToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Guard on the old hash so two concurrent refreshes cannot both succeed
+	result := s.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, tokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  utils.HashToken(newToken),
+			"previous_token_hash": tokenHash,
+			"ip_address":          client.IPAddress,
+			"user_agent":          client.UserAgent,
+			"last_used_at":        time.Now(),
+		})
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if err := s.DB.First(&session, session.ID).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &session, newToken, nil
+}
+
+// IsSessionActive reports whether the session exists and has not been revoked or expired
+func (s *SessionService) IsSessionActive(sessionID uint) (bool, error) {
+	var count int64
+	if err := s.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListActiveSessions returns the user's sessions that can still be used, most recent first
+func (s *SessionService) ListActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes a single session belonging to the user
+func (s *SessionService) RevokeSession(userID, sessionID uint) error {
+	result := s.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions revokes every active session of the user
+func (s *SessionService) RevokeAllSessions(userID uint) error {
+	return s.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *SessionService) revoke(session *models.Session) {
+	s.DB.Model(session).Update("revoked_at", time.Now())
+}
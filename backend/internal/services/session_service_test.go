@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	db := newTestDB(t)
+	s := NewSessionService(db)
+	user := createTestUser(t, db, "alice")
+	client := ClientInfo{IPAddress: "203.0.113.1", UserAgent: "test"}
+
+	session, first, err := s.CreateSession(user.ID, client)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	rotated, second, err := s.RotateRefreshToken(first, client)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if rotated.ID != session.ID || second == "" || second == first {
+		t.Fatalf("RotateRefreshToken() = session %d, token %q; want a new token for session %d", rotated.ID, second, session.ID)
+	}
+
+	// The first token was already rotated, someone else holds a copy of it
+	if _, _, err := s.RotateRefreshToken(first, client); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("reused token: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if active, err := s.IsSessionActive(session.ID); err != nil || active {
+		t.Errorf("IsSessionActive() after reuse = %v, %v; want the session revoked", active, err)
+	}
+	if _, _, err := s.RotateRefreshToken(second, client); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("latest token after reuse: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, _, err := s.RotateRefreshToken("unknown", client); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRotateRevokedSession(t *testing.T) {
+	db := newTestDB(t)
+	s := NewSessionService(db)
+	user := createTestUser(t, db, "alice")
+
+	session, token, err := s.CreateSession(user.ID, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeSession(user.ID, session.ID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, _, err := s.RotateRefreshToken(token, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("revoked session: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if err := s.RevokeSession(user.ID+1, session.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("revoking another user's session: error = %v, want %v", err, ErrSessionNotFound)
+	}
+}
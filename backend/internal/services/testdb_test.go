@@ -0,0 +1,79 @@
+package services
+
+import (
+	"database/sql/driver"
+	"go-gin-backend/internal/database"
+	"go-gin-backend/internal/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/go-sqlite"
+	gormsqlite "github.com/glebarez/sqlite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	// The migrations are written for Postgres, which has NOW()
+	sqlite.MustRegisterScalarFunction("now", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format("2006-01-02 15:04:05.999999"), nil
+	})
+}
+
+// newTestDB opens a migrated SQLite database in the test's temporary directory
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(gormsqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := database.AutoMigrateAll(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// createTestUser stores a user whose password is "password"
+func createTestUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{
+		Username:    username,
+		Password:    string(hash),
+		Email:       username + "@example.com",
+		PhoneNumber: "+62812" + username,
+		Role:        models.RoleOwner,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// createTestBusiness stores a business owned by the user
+func createTestBusiness(t *testing.T, db *gorm.DB, owner *models.User, name string) *models.Business {
+	t.Helper()
+
+	business := &models.Business{UserID: owner.ID, Name: name}
+	if err := db.Create(business).Error; err != nil {
+		t.Fatalf("failed to create business: %v", err)
+	}
+	if err := db.Create(&models.BusinessMember{BusinessID: business.ID, UserID: owner.ID, Role: models.BusinessRoleOwner}).Error; err != nil {
+		t.Fatalf("failed to add business owner: %v", err)
+	}
+	return business
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/scanner"
+	"go-gin-backend/internal/storage"
+	"go-gin-backend/internal/utils"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// uploadURLPrefix is the path uploaded files are served under. File URLs stored in the
+// database are the prefix followed by the storage key.
+const uploadURLPrefix = "/uploads"
+
+const (
+	defaultMaxUploadSizeMB = 10
+	maxUploadNameLength    = 200
+)
+
+var (
+	// ErrFileTooLarge is returned for uploads over the size cap
+	ErrFileTooLarge = errors.New("file is too large")
+	// ErrFileInfected is returned when the malware scan rejects an upload
+	ErrFileInfected = errors.New("file was rejected by the malware scan")
+	// ErrScanUnavailable is returned when an upload could not be scanned, so it is not stored
+	ErrScanUnavailable = errors.New("file could not be scanned for malware")
+)
+
+// uploadPolicy is what an upload endpoint accepts
+type uploadPolicy struct {
+	maxSize   int64
+	types     map[string]string // Sniffed content type -> extension of the stored file
+	typeNames string            // Listed in the validation message
+}
+
+// Legal documents and certificates, usually scans or PDFs
+var documentUploadTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+func documentUploadPolicy() uploadPolicy {
+	return uploadPolicy{maxSize: MaxUploadSize(), types: documentUploadTypes, typeNames: "PDF, JPEG or PNG"}
+}
+
+// MaxUploadSize caps document uploads, from UPLOAD_MAX_SIZE_MB
+func MaxUploadSize() int64 {
+	mb, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_SIZE_MB"))
+	if err != nil || mb < 1 {
+		mb = defaultMaxUploadSizeMB
+	}
+	return int64(mb) << 20
+}
+
+// storedUpload is an upload that passed the pipeline and was saved
+type storedUpload struct {
+	Key         string
+	URL         string
+	FileName    string // The client's file name, made safe to show and store
+	ContentType string
+}
+
+// storeUpload runs an upload through the pipeline shared by every upload endpoint: the size
+// cap, content sniffing against the policy's types and the malware scan. Files that pass are
+// saved under dir with a generated name; the client's file name never becomes part of the key.
+func storeUpload(store storage.Storage, scan scanner.Scanner, dir string, policy uploadPolicy, file multipart.File, header *multipart.FileHeader) (*storedUpload, error) {
+	if header.Size > policy.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	// The stored type and extension follow the content, not what the client claims
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(head[:n]), ";")[0])
+	ext, ok := policy.types[contentType]
+	if !ok {
+		return nil, &ValidationError{Field: "file", Message: "files must be " + policy.typeNames}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if err := scan.Scan(header.Filename, io.LimitReader(file, policy.maxSize)); err != nil {
+		var infected *scanner.InfectedError
+		if errors.As(err, &infected) {
+			log.Printf("Rejected upload %q: %v", header.Filename, err)
+			return nil, ErrFileInfected
+		}
+		log.Printf("Failed to scan upload %q: %v", header.Filename, err)
+		return nil, ErrScanUnavailable
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	name, err := utils.GenerateRandomToken(12)
+	if err != nil {
+		return nil, err
+	}
+	key := path.Join(dir, name+ext)
+	if err := store.Put(key, io.LimitReader(file, policy.maxSize), header.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to save file: %v", err)
+	}
+
+	return &storedUpload{
+		Key:         key,
+		URL:         uploadURL(key),
+		FileName:    sanitizeUploadName(header.Filename, ext),
+		ContentType: contentType,
+	}, nil
+}
+
+// sanitizeUploadName keeps the last element of the client's file name without control or
+// path characters, falling back to a generic name when nothing is left
+func sanitizeUploadName(filename, ext string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r), r == unicode.ReplacementChar:
+			return -1
+		case strings.ContainsRune(`/:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, filename)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+
+	if len(name) > maxUploadNameLength {
+		base := strings.TrimSuffix(name, path.Ext(name))
+		suffix := path.Ext(name)
+		if len(suffix) > 10 {
+			suffix = ""
+		}
+		name = strings.ToValidUTF8(base[:maxUploadNameLength-len(suffix)], "") + suffix
+	}
+	if name == "" {
+		return "file" + ext
+	}
+	return name
+}
+
+// uploadURL is the file URL stored for a key
+func uploadURL(key string) string {
+	return uploadURLPrefix + "/" + key
+}
+
+// uploadKeyFromURL maps a /uploads/... file URL to its storage key, rejecting anything that
+// is not an upload or would escape the store
+func uploadKeyFromURL(fileURL string) (string, bool) {
+	rel, ok := strings.CutPrefix(fileURL, uploadURLPrefix+"/")
+	if !ok {
+		return "", false
+	}
+	key, err := storage.CleanKey(rel)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// removeUploads deletes stored files, logging the ones that could not be removed
+func removeUploads(store storage.Storage, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(key); err != nil {
+			log.Printf("Failed to remove upload %s: %v", key, err)
+		}
+	}
+}
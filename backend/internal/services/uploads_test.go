@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"go-gin-backend/internal/scanner"
+	"go-gin-backend/internal/storage"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uploadFile is an in-memory multipart.File
+type uploadFile struct {
+	*bytes.Reader
+}
+
+func (uploadFile) Close() error { return nil }
+
+func newUploadFile(content string) (multipart.File, int64) {
+	return uploadFile{bytes.NewReader([]byte(content))}, int64(len(content))
+}
+
+// failingScanner cannot reach its antivirus
+type failingScanner struct{}
+
+func (failingScanner) Scan(string, io.Reader) error {
+	return errors.New("connection refused")
+}
+
+const (
+	testPDF   = "%PDF-1.4\n1 0 obj << >> endobj\n%%EOF"
+	testPNG   = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	testHTML  = "<!DOCTYPE html><html><script>alert(1)</script></html>"
+	testEICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+)
+
+func TestStoreUpload(t *testing.T) {
+	policy := uploadPolicy{maxSize: 1 << 10, types: documentUploadTypes, typeNames: "PDF, JPEG or PNG"}
+
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		scanner  scanner.Scanner
+		wantExt  string
+		wantType string
+		wantErr  error
+		invalid  bool // A ValidationError is expected
+	}{
+		{name: "PDF", filename: "permit.pdf", content: testPDF, wantExt: ".pdf", wantType: "application/pdf"},
+		{name: "extension follows the content", filename: "scan.pdf", content: testPNG, wantExt: ".png", wantType: "image/png"},
+		{name: "HTML named as a PDF", filename: "permit.pdf", content: testHTML, invalid: true},
+		{name: "over the size cap", filename: "permit.pdf", content: testPDF + strings.Repeat(" ", 1<<10), wantErr: ErrFileTooLarge},
+		{name: "infected", filename: "permit.pdf", content: testPDF + "\n" + testEICAR, wantErr: ErrFileInfected},
+		{name: "scanner unavailable", filename: "permit.pdf", content: testPDF, scanner: failingScanner{}, wantErr: ErrScanUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := storage.NewLocalStorage(dir, uploadURLPrefix, []byte("test-secret"))
+			scan := tt.scanner
+			if scan == nil {
+				scan = scanner.NewLocalScanner()
+			}
+			file, size := newUploadFile(tt.content)
+
+			upload, err := storeUpload(store, scan, "legal/business/1", policy, file, &multipart.FileHeader{Filename: tt.filename, Size: size})
+
+			var validationErr *ValidationError
+			switch {
+			case tt.invalid:
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("storeUpload() error = %v, want a validation error", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("storeUpload() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("storeUpload() error = %v", err)
+			}
+			if err != nil {
+				if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+					t.Errorf("a rejected upload was stored")
+				}
+				return
+			}
+
+			if !strings.HasPrefix(upload.Key, "legal/business/1/") || filepath.Ext(upload.Key) != tt.wantExt {
+				t.Errorf("key = %s, want a %s file in legal/business/1", upload.Key, tt.wantExt)
+			}
+			if strings.Contains(upload.Key, strings.TrimSuffix(tt.filename, filepath.Ext(tt.filename))) {
+				t.Errorf("key %s contains the client's file name", upload.Key)
+			}
+			if upload.URL != uploadURL(upload.Key) || upload.FileName != tt.filename || upload.ContentType != tt.wantType {
+				t.Errorf("storeUpload() = %+v", upload)
+			}
+
+			r, _, err := store.Get(upload.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if stored, _ := io.ReadAll(r); string(stored) != tt.content {
+				t.Errorf("stored %q, want the uploaded content", stored)
+			}
+		})
+	}
+}
+
+func TestStoreUploadGeneratesKeys(t *testing.T) {
+	store := storage.NewLocalStorage(t.TempDir(), uploadURLPrefix, []byte("test-secret"))
+	policy := documentUploadPolicy()
+
+	keys := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		file, size := newUploadFile(testPDF)
+		upload, err := storeUpload(store, scanner.NewLocalScanner(), "financials/1", policy, file, &multipart.FileHeader{Filename: "report.pdf", Size: size})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keys[upload.Key] {
+			t.Fatalf("key %s was generated twice", upload.Key)
+		}
+		keys[upload.Key] = true
+	}
+}
+
+func TestMaxUploadSize(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int64
+	}{
+		{"", defaultMaxUploadSizeMB << 20},
+		{"25", 25 << 20},
+		{"0", defaultMaxUploadSizeMB << 20},
+		{"ten", defaultMaxUploadSizeMB << 20},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("UPLOAD_MAX_SIZE_MB", tt.env)
+		if got := MaxUploadSize(); got != tt.want {
+			t.Errorf("UPLOAD_MAX_SIZE_MB=%q: MaxUploadSize() = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeUploadName(t *testing.T) {
+	long := strings.Repeat("a", 300)
+
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"permit.pdf", "permit.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\alice\permit.pdf`, "permit.pdf"},
+		{"per\x00mit\n.pdf", "permit.pdf"},
+		{`a:b*c?"d<e>f|.pdf`, "a_b_c__d_e_f_.pdf"},
+		{"  .hidden.pdf. ", "hidden.pdf"},
+		{"..", "file.pdf"},
+		{"", "file.pdf"},
+		{long + ".pdf", long[:maxUploadNameLength-4] + ".pdf"},
+		{long + "." + long, long[:maxUploadNameLength]},
+		{strings.Repeat("é", 150) + ".pdf", strings.Repeat("é", 98) + ".pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeUploadName(tt.filename, ".pdf"); got != tt.want {
+			t.Errorf("sanitizeUploadName(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestUploadKeyFromURL(t *testing.T) {
+	tests := []struct {
+		fileURL string
+		wantKey string
+		wantOK  bool
+	}{
+		{"/uploads/legal/business/1/a.pdf", "legal/business/1/a.pdf", true},
+		{"/uploads/../secret", "", false},
+		{"/uploads/", "", false},
+		{"https://cdn.example.com/uploads/a.png", "", false},
+		{"/static/a.png", "", false},
+	}
+
+	for _, tt := range tests {
+		key, ok := uploadKeyFromURL(tt.fileURL)
+		if key != tt.wantKey || ok != tt.wantOK {
+			t.Errorf("uploadKeyFromURL(%q) = %q, %v; want %q, %v", tt.fileURL, key, ok, tt.wantKey, tt.wantOK)
+		}
+	}
+}
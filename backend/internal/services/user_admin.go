@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User statuses admins can filter by
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusLocked    = "locked"
+)
+
+var (
+	// ErrSelfModeration is returned when admins try to suspend or demote themselves
+	ErrSelfModeration = errors.New("admins cannot moderate their own account")
+	// ErrAlreadySuspended is returned when suspending a suspended user
+	ErrAlreadySuspended = errors.New("user already suspended")
+	// ErrNotSuspended is returned when lifting a suspension that is not in place
+	ErrNotSuspended = errors.New("user is not suspended")
+	// ErrNotLocked is returned when unlocking an account that is not locked out
+	ErrNotLocked = errors.New("user is not locked")
+)
+
+// UserSearchFilter narrows down the users returned to admins
+type UserSearchFilter struct {
+	Query  string // Matches username, email or phone number
+	Role   string
+	Status string
+	Page   int
+	Limit  int
+}
+
+// SearchUsers returns the users matching the filter, newest first, with the total count
+func (s *UserService) SearchUsers(filter UserSearchFilter) ([]models.User, int64, error) {
+	query := s.DB.Model(&models.User{})
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("username ILIKE ? OR email ILIKE ? OR phone_number ILIKE ?", like, like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("suspended_at IS NULL")
+	case UserStatusSuspended:
+		query = query.Where("suspended_at IS NOT NULL")
+	case UserStatusLocked:
+		query = query.Where("locked_until > ?", time.Now())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// SuspendUser blocks the user from signing in and ends all of their sessions
+func (s *UserService) SuspendUser(userID uint, reason string) (*models.User, error) {
+	return s.moderateUser(userID, func(tx *gorm.DB, user *models.User) (map[string]interface{}, error) {
+		if user.SuspendedAt != nil {
+			return nil, ErrAlreadySuspended
+		}
+		return map[string]interface{}{
+			"suspended_at":      time.Now(),
+			"suspension_reason": reason,
+		}, nil
+	}, true)
+}
+
+// UnsuspendUser lets a suspended user sign in again
+func (s *UserService) UnsuspendUser(userID uint) (*models.User, error) {
+	return s.moderateUser(userID, func(tx *gorm.DB, user *models.User) (map[string]interface{}, error) {
+		if user.SuspendedAt == nil {
+			return nil, ErrNotSuspended
+		}
+		return map[string]interface{}{
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}, nil
+	}, false)
+}
+
+// UnlockUser lifts the lockout after repeated failed logins so the user can sign in right away
+func (s *UserService) UnlockUser(userID uint) (*models.User, error) {
+	return s.moderateUser(userID, func(tx *gorm.DB, user *models.User) (map[string]interface{}, error) {
+		if user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+			return nil, ErrNotLocked
+		}
+		if err := NewLoginGuardService(tx).Unlock(user); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"locked_until": nil}, nil
+	}, false)
+}
+
+// SetUserRole assigns a role and ends the user's sessions so the new permissions apply right away
+func (s *UserService) SetUserRole(userID uint, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	return s.moderateUser(userID, func(tx *gorm.DB, user *models.User) (map[string]interface{}, error) {
+		return map[string]interface{}{"role": role}, nil
+	}, true)
+}
+
+// RequirePasswordReset signs the user out and blocks sign-in until they reset their password
+func (s *UserService) RequirePasswordReset(userID uint) (*models.User, error) {
+	return s.moderateUser(userID, func(tx *gorm.DB, user *models.User) (map[string]interface{}, error) {
+		return map[string]interface{}{"password_reset_required": true}, nil
+	}, true)
+}
+
+// moderateUser applies an admin change to the user in one audited transaction
+func (s *UserService) moderateUser(userID uint, change func(tx *gorm.DB, user *models.User) (map[string]interface{}, error), revokeSessions bool) (*models.User, error) {
+	if s.Actor.UserID == userID {
+		return nil, ErrSelfModeration
+	}
+
+	var user models.User
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		before := user
+
+		updates, err := change(tx, &user)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if revokeSessions {
+			if err := NewSessionService(tx).RevokeAllSessions(userID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, userID, models.AuditActionUpdate, &before, &user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"go-gin-backend/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestUnlockUser(t *testing.T) {
+	db := newTestDB(t)
+	guard := NewLoginGuardService(db)
+	admin := createTestUser(t, db, "admin")
+	user := createTestUser(t, db, "alice")
+	users := NewUserService(db).WithActor(AuditActor{UserID: admin.ID, Reason: "verified by phone"})
+
+	if _, err := users.UnlockUser(user.ID); !errors.Is(err, ErrNotLocked) {
+		t.Fatalf("unlocking an account that is not locked: error = %v, want %v", err, ErrNotLocked)
+	}
+
+	for i := 0; i < loginLockoutThreshold; i++ {
+		guard.RecordFailure(user.Username, &user.ID, "10.0.0.1")
+	}
+
+	unlocked, err := users.UnlockUser(user.ID)
+	if err != nil {
+		t.Fatalf("UnlockUser: %v", err)
+	}
+	if unlocked.LockedUntil != nil {
+		t.Errorf("LockedUntil = %v, want nil", unlocked.LockedUntil)
+	}
+	if err := guard.Check(user.Username, "10.0.0.2"); err != nil {
+		t.Errorf("Check() after unlocking: error = %v, want nil", err)
+	}
+
+	var entry models.AuditLog
+	if err := db.Where("entity_type = ? AND entity_id = ?", models.AuditEntityUser, user.ID).Order("id DESC").First(&entry).Error; err != nil {
+		t.Fatalf("no audit entry for the unlock: %v", err)
+	}
+	if entry.ActorID == nil || *entry.ActorID != admin.ID || entry.Reason != "verified by phone" {
+		t.Errorf("audit entry = actor %v reason %q, want the admin and their reason", entry.ActorID, entry.Reason)
+	}
+	if !strings.Contains(string(entry.Diff), "locked_until") {
+		t.Errorf("audit diff %s does not record the lock being lifted", entry.Diff)
+	}
+
+	if _, err := NewUserService(db).WithActor(AuditActor{UserID: user.ID}).UnlockUser(user.ID); !errors.Is(err, ErrSelfModeration) {
+		t.Errorf("unlocking yourself: error = %v, want %v", err, ErrSelfModeration)
+	}
+}
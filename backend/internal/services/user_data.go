@@ -0,0 +1,442 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/storage"
+	"go-gin-backend/internal/utils"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// UserDataExport is everything stored about a user, as handed out for a data export request
+type UserDataExport struct {
+	ExportedAt    time.Time                     `json:"exported_at"`
+	Profile       *models.User                  `json:"profile"`
+	Sessions      []models.Session              `json:"sessions"`
+	APIKeys       []models.APIKey               `json:"api_keys"`
+	Identities    []models.ExternalIdentity     `json:"external_identities"`
+	LoginHistory  []models.LoginAttempt         `json:"login_history"`
+	Memberships   []models.BusinessMember       `json:"memberships"`
+	Businesses    []models.Business             `json:"businesses"`
+	LegalVersions []models.LegalFileVersion     `json:"legal_file_versions"`
+	AISuggestions []models.BusinessAISuggestion `json:"ai_suggestions"`
+	Projections   []models.HistoricalProjection `json:"projections"`
+
+	files []string        // Storage keys of the businesses' uploads
+	store storage.Storage // Where the files are read from
+}
+
+// ExportUserData collects the user's profile, security history and memberships, together with
+// the businesses they own: their products, legals and earlier files, financials and AI
+// suggestions. Businesses the user only works on are listed by their membership row.
+func (s *UserService) ExportUserData(userID uint) (*UserDataExport, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &UserDataExport{ExportedAt: time.Now(), Profile: user, store: s.Storage}
+
+	if err := s.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&export.Sessions).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&export.APIKeys).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&export.Identities).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("user_id = ? OR username = ?", userID, normalizeUsername(user.Username)).
+		Order("created_at ASC").
+		Find(&export.LoginHistory).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("user_id = ?", userID).Find(&export.Memberships).Error; err != nil {
+		return nil, err
+	}
+
+	businessIDs := make([]uint, 0, len(export.Memberships))
+	for _, m := range export.Memberships {
+		if m.Role == models.BusinessRoleOwner {
+			businessIDs = append(businessIDs, m.BusinessID)
+		}
+	}
+	if len(businessIDs) == 0 {
+		return export, nil
+	}
+
+	if err := s.DB.Preload("Products.ProductLegals").
+		Preload("Legals").
+		Preload("Financials").
+		Where("id IN ?", businessIDs).
+		Find(&export.Businesses).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("business_id IN ?", businessIDs).Order("replaced_at ASC").Find(&export.LegalVersions).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Preload("Suggestions").Where("business_id IN ?", businessIDs).Find(&export.AISuggestions).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("business_id IN ?", businessIDs).Find(&export.Projections).Error; err != nil {
+		return nil, err
+	}
+
+	for _, business := range export.Businesses {
+		for _, legal := range business.Legals {
+			export.addFile(legal.FileURL)
+		}
+		for _, financial := range business.Financials {
+			export.addFile(financial.ReportFileURL)
+		}
+		for _, product := range business.Products {
+			for _, image := range product.Images {
+				export.addFile(image.URL)
+			}
+			for _, legal := range product.ProductLegals {
+				export.addFile(legal.FileURL)
+			}
+		}
+	}
+	for _, version := range export.LegalVersions {
+		export.addFile(version.FileURL)
+	}
+
+	return export, nil
+}
+
+func (e *UserDataExport) addFile(fileURL string) {
+	if key, ok := uploadKeyFromURL(fileURL); ok {
+		e.files = append(e.files, key)
+	}
+}
+
+// WriteZip writes the export as a zip archive with one JSON file per section and the
+// uploaded files under files/
+func (e *UserDataExport) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", e.Profile},
+		{"sessions.json", e.Sessions},
+		{"api_keys.json", e.APIKeys},
+		{"external_identities.json", e.Identities},
+		{"login_history.json", e.LoginHistory},
+		{"memberships.json", e.Memberships},
+		{"businesses.json", e.Businesses},
+		{"legal_file_versions.json", e.LegalVersions},
+		{"ai_suggestions.json", e.AISuggestions},
+		{"projections.json", e.Projections},
+	}
+	for _, section := range sections {
+		f, err := zw.Create(section.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section.data); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range e.files {
+		if err := e.addFileToZip(zw, key); err != nil {
+			// A missing upload should not block the rest of the export
+			log.Printf("Skipping %s in data export: %v", key, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+func (e *UserDataExport) addFileToZip(zw *zip.Writer, key string) error {
+	src, _, err := e.store.Get(key)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create("files/" + key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// VerifyPassword returns ErrInvalidPassword unless the password matches the user's
+func (s *UserService) VerifyPassword(userID uint, password string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// EraseUser deletes a user's account for good. Businesses the user solely owns are deleted
+// together with their uploaded files, shared businesses pass to the remaining owners, and
+// the user row is anonymized so nothing identifying remains.
+func (s *UserService) EraseUser(userID uint) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var memberships []models.BusinessMember
+		if err := tx.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+			return err
+		}
+
+		var soleOwned []uint
+		for _, m := range memberships {
+			if m.Role == models.BusinessRoleOwner {
+				err := ensureAnotherOwner(tx, m.BusinessID, userID)
+				if errors.Is(err, ErrLastOwner) {
+					soleOwned = append(soleOwned, m.BusinessID)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Unscoped().Delete(&m).Error; err != nil {
+				return err
+			}
+			if err := syncPrimaryOwner(tx, m.BusinessID); err != nil {
+				return err
+			}
+		}
+
+		// Memberships removed earlier are kept soft-deleted for the record
+		if err := tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Delete(&models.BusinessMember{}).Error; err != nil {
+			return err
+		}
+
+		// Businesses that were already deleted still hold the user's data, unless another
+		// owner can restore them
+		var deletedOwned []uint
+		if err := tx.Unscoped().Model(&models.Business{}).
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+			Pluck("id", &deletedOwned).Error; err != nil {
+			return err
+		}
+		purged := soleOwned
+		for _, businessID := range deletedOwned {
+			if slices.Contains(soleOwned, businessID) {
+				continue
+			}
+			err := ensureAnotherOwner(tx, businessID, userID)
+			if errors.Is(err, ErrLastOwner) {
+				purged = append(purged, businessID)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := syncPrimaryOwner(tx, businessID); err != nil {
+				return err
+			}
+		}
+
+		for _, businessID := range purged {
+			id := businessID
+			if err := recordAudit(tx, s.Actor, &id, models.AuditEntityBusiness, id, models.AuditActionDelete, nil, nil); err != nil {
+				return err
+			}
+		}
+
+		removed, err := purgeBusinesses(tx, purged)
+		if err != nil {
+			return err
+		}
+		files = removed
+
+		if err := eraseUserRecords(tx, user); err != nil {
+			return err
+		}
+
+		placeholder := fmt.Sprintf("deleted-user-%d", user.ID)
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"username":            placeholder,
+			"email":               placeholder + "@deleted.invalid",
+			"phone_number":        placeholder,
+			"password":            "",
+			"email_verified_at":   nil,
+			"phone_verified_at":   nil,
+			"two_factor_enabled":  false,
+			"totp_secret":         "",
+			"totp_last_used_step": 0,
+			"locked_until":        nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(user).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, user.ID, models.AuditActionDelete, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeUploads(s.Storage, files)
+	return nil
+}
+
+// eraseUserRecords deletes the authentication and security records tied to the user
+func eraseUserRecords(tx *gorm.DB, user *models.User) error {
+	username := normalizeUsername(user.Username)
+	phoneNumbers := utils.PhoneNumberVariants(user.PhoneNumber)
+
+	deletes := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&models.Session{}, "user_id = ?", []interface{}{user.ID}},
+		{&models.APIKey{}, "user_id = ?", []interface{}{user.ID}},
+		{&models.ExternalIdentity{}, "user_id = ?", []interface{}{user.ID}},
+		{&models.UserToken{}, "user_id = ?", []interface{}{user.ID}},
+		{&models.RecoveryCode{}, "user_id = ?", []interface{}{user.ID}},
+		{&models.PhoneOTP{}, "phone_number IN ?", []interface{}{phoneNumbers}},
+		{&models.LoginAttempt{}, "user_id = ? OR username = ?", []interface{}{user.ID, username}},
+		{&models.SecurityEvent{}, "user_id = ? OR username = ?", []interface{}{user.ID, username}},
+		{&models.BusinessInvitation{}, "LOWER(email) = ? OR phone_number IN ?", []interface{}{strings.ToLower(user.Email), phoneNumbers}},
+		{&models.DataRoomGrant{}, "investor_id = ?", []interface{}{user.ID}},
+	}
+	for _, d := range deletes {
+		if err := tx.Unscoped().Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeBusinesses hard-deletes the businesses and everything that hangs off them, returning
+// the storage keys of the uploads that should be removed once the transaction commits
+func purgeBusinesses(tx *gorm.DB, businessIDs []uint) ([]string, error) {
+	if len(businessIDs) == 0 {
+		return nil, nil
+	}
+
+	tx = tx.Unscoped().Session(&gorm.Session{})
+
+	var productIDs, legalIDs, financialIDs, missingLegalIDs, missingProductLegalIDs, suggestionIDs []uint
+	plucks := []struct {
+		model  interface{}
+		query  string
+		args   interface{}
+		target *[]uint
+	}{
+		{&models.Product{}, "business_id IN ?", businessIDs, &productIDs},
+		{&models.Legal{}, "business_id IN ?", businessIDs, &legalIDs},
+		{&models.Financial{}, "business_id IN ?", businessIDs, &financialIDs},
+		{&models.MissingLegal{}, "business_id IN ?", businessIDs, &missingLegalIDs},
+		{&models.BusinessAISuggestion{}, "business_id IN ?", businessIDs, &suggestionIDs},
+	}
+	for _, p := range plucks {
+		if err := tx.Model(p.model).Where(p.query, p.args).Pluck("id", p.target).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(productIDs) > 0 {
+		if err := tx.Model(&models.MissingProductLegal{}).Where("product_id IN ?", productIDs).Pluck("id", &missingProductLegalIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var fileURLs []string
+	if err := tx.Model(&models.Legal{}).Where("business_id IN ?", businessIDs).Pluck("file_url", &fileURLs).Error; err != nil {
+		return nil, err
+	}
+	var reportFileURLs []string
+	if err := tx.Model(&models.Financial{}).Where("business_id IN ? AND report_file_url <> ''", businessIDs).Pluck("report_file_url", &reportFileURLs).Error; err != nil {
+		return nil, err
+	}
+	fileURLs = append(fileURLs, reportFileURLs...)
+	var versionFileURLs []string
+	if err := tx.Model(&models.LegalFileVersion{}).Where("business_id IN ?", businessIDs).Pluck("file_url", &versionFileURLs).Error; err != nil {
+		return nil, err
+	}
+	fileURLs = append(fileURLs, versionFileURLs...)
+	if len(productIDs) > 0 {
+		var productFileURLs []string
+		if err := tx.Model(&models.ProductLegal{}).Where("product_id IN ?", productIDs).Pluck("file_url", &productFileURLs).Error; err != nil {
+			return nil, err
+		}
+		fileURLs = append(fileURLs, productFileURLs...)
+
+		imageURLs, err := productImageFiles(tx, "id IN ?", productIDs)
+		if err != nil {
+			return nil, err
+		}
+		fileURLs = append(fileURLs, imageURLs...)
+	}
+
+	// Children first so nothing is left pointing at a deleted parent
+	deletes := []struct {
+		model interface{}
+		query string
+		ids   []uint
+	}{
+		{&models.StepToGetProductLegal{}, "missing_product_legal_id IN ?", missingProductLegalIDs},
+		{&models.MissingProductLegal{}, "id IN ?", missingProductLegalIDs},
+		{&models.ProductLegal{}, "product_id IN ?", productIDs},
+		{&models.Product{}, "id IN ?", productIDs},
+		{&models.StepToGetLegal{}, "missing_legal_id IN ?", missingLegalIDs},
+		{&models.MissingLegal{}, "id IN ?", missingLegalIDs},
+		{&models.LegalAdditionalInfo{}, "legal_id IN ?", legalIDs},
+		{&models.Legal{}, "id IN ?", legalIDs},
+		{&models.LegalFileVersion{}, "business_id IN ?", businessIDs},
+		{&models.FinancialAdditionalInfo{}, "financial_id IN ?", financialIDs},
+		{&models.Financial{}, "id IN ?", financialIDs},
+		{&models.BusinessAISuggestionItem{}, "business_ai_suggestion_id IN ?", suggestionIDs},
+		{&models.BusinessAISuggestion{}, "id IN ?", suggestionIDs},
+		{&models.BusinessSnapshot{}, "business_id IN ?", businessIDs},
+		{&models.CustomFieldValue{}, "business_id IN ?", businessIDs},
+		{&models.CustomFieldDefinition{}, "business_id IN ?", businessIDs},
+		{&models.HistoricalProjection{}, "business_id IN ?", businessIDs},
+		{&models.BusinessAdditionalInfo{}, "business_id IN ?", businessIDs},
+		{&models.BusinessInvitation{}, "business_id IN ?", businessIDs},
+		{&models.DataRoomGrant{}, "business_id IN ?", businessIDs},
+		{&models.BusinessMember{}, "business_id IN ?", businessIDs},
+		{&models.Business{}, "id IN ?", businessIDs},
+	}
+	for _, d := range deletes {
+		if len(d.ids) == 0 {
+			continue
+		}
+		if err := tx.Where(d.query, d.ids).Delete(d.model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	for _, fileURL := range fileURLs {
+		if key, ok := uploadKeyFromURL(fileURL); ok {
+			files = append(files, key)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/storage"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+var errRollback = errors.New("rollback")
+
+// storeTestUpload saves a small file under the key and returns its file URL
+func storeTestUpload(t *testing.T, store storage.Storage, key string) string {
+	t.Helper()
+
+	content := "content of " + key
+	if err := store.Put(key, strings.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("failed to store %s: %v", key, err)
+	}
+	return uploadURL(key)
+}
+
+// exportedFiles returns the names of the uploads in the export archive
+func exportedFiles(t *testing.T, export *UserDataExport) []string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := export.WriteZip(&buf); err != nil {
+		t.Fatalf("WriteZip: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for _, f := range zr.File {
+		if key, ok := strings.CutPrefix(f.Name, "files/"); ok {
+			files = append(files, key)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestExportUserDataIncludesUploads(t *testing.T) {
+	db := newTestDB(t)
+	store := storage.NewLocalStorage(t.TempDir(), uploadURLPrefix, []byte("test-secret"))
+	user := createTestUser(t, db, "alice")
+	business := createTestBusiness(t, db, user, "Kopi Nusantara")
+
+	var want []string
+	create := func(record interface{}) {
+		t.Helper()
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	legalKey := "legal/business/1/permit.pdf"
+	create(&models.Legal{BusinessID: business.ID, FileURL: storeTestUpload(t, store, legalKey)})
+	want = append(want, legalKey)
+
+	imageKey := "products/1/1/front.jpg"
+	product := &models.Product{BusinessID: business.ID, Name: "Arabica", Images: []models.ProductImage{
+		{URL: storeTestUpload(t, store, imageKey)},
+		{URL: "https://cdn.example.com/hosted-elsewhere.jpg"},
+	}}
+	create(product)
+	want = append(want, imageKey)
+	productLegalKey := "legal/products/1/1/halal.pdf"
+	create(&models.ProductLegal{ProductID: product.ID, FileURL: storeTestUpload(t, store, productLegalKey)})
+	want = append(want, productLegalKey)
+
+	versionKey := "legal/business/1/permit-2023.pdf"
+	create(&models.LegalFileVersion{
+		BusinessID: business.ID,
+		EntityType: models.AuditEntityLegal,
+		EntityID:   1,
+		FileURL:    storeTestUpload(t, store, versionKey),
+	})
+	want = append(want, versionKey)
+
+	reportKey := "financials/1/report.pdf"
+	create(&models.Financial{BusinessID: business.ID, ReportFileURL: storeTestUpload(t, store, reportKey)})
+	create(&models.Financial{BusinessID: business.ID}) // No report uploaded
+	want = append(want, reportKey)
+
+	// Another user's business stays out of the export, even where the user is a member
+	other := createTestUser(t, db, "bob")
+	otherBusiness := createTestBusiness(t, db, other, "Teh Manis")
+	create(&models.Legal{BusinessID: otherBusiness.ID, FileURL: storeTestUpload(t, store, "legal/business/2/permit.pdf")})
+	create(&models.BusinessMember{BusinessID: otherBusiness.ID, UserID: user.ID, Role: models.BusinessRoleViewer})
+
+	users := NewUserService(db)
+	users.Storage = store
+	export, err := users.ExportUserData(user.ID)
+	if err != nil {
+		t.Fatalf("ExportUserData: %v", err)
+	}
+
+	if len(export.Businesses) != 1 || export.Businesses[0].ID != business.ID {
+		t.Errorf("exported %d businesses, want only the owned one", len(export.Businesses))
+	}
+	if len(export.Memberships) != 2 {
+		t.Errorf("exported %d memberships, want 2", len(export.Memberships))
+	}
+
+	sort.Strings(want)
+	got := exportedFiles(t, export)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("exported files = %v, want %v", got, want)
+	}
+	assertEveryUploadExported(t, db, got, otherBusiness.ID)
+}
+
+// assertEveryUploadExported checks the export against every file URL erasure would purge
+// for the user's businesses
+func assertEveryUploadExported(t *testing.T, db *gorm.DB, exported []string, otherBusinessID uint) {
+	t.Helper()
+
+	var businessIDs []uint
+	if err := db.Model(&models.Business{}).Where("id <> ?", otherBusinessID).Pluck("id", &businessIDs).Error; err != nil {
+		t.Fatal(err)
+	}
+	var stored []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		stored, err = purgeBusinesses(tx, businessIDs)
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+
+	inExport := make(map[string]bool, len(exported))
+	for _, key := range exported {
+		inExport[key] = true
+	}
+	for _, key := range stored {
+		if !inExport[key] {
+			t.Errorf("%s is removed on erasure but missing from the export", key)
+		}
+	}
+}
+
+func TestEraseUserKeepsSharedBusinesses(t *testing.T) {
+	db := newTestDB(t)
+	store := storage.NewLocalStorage(t.TempDir(), uploadURLPrefix, []byte("test-secret"))
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	businesses := NewBusinessService(db)
+
+	solo := createTestBusiness(t, db, alice, "Kopi Nusantara")
+	soloTrashed := createTestBusiness(t, db, alice, "Kopi Lama")
+	shared := createTestBusiness(t, db, alice, "Teh Manis")
+	sharedTrashed := createTestBusiness(t, db, alice, "Teh Lama")
+	for _, business := range []*models.Business{shared, sharedTrashed} {
+		if err := db.Create(&models.BusinessMember{BusinessID: business.ID, UserID: bob.ID, Role: models.BusinessRoleOwner}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	legalKey := "legal/business/4/permit.pdf"
+	if err := db.Create(&models.Legal{BusinessID: sharedTrashed.ID, FileURL: storeTestUpload(t, store, legalKey)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, business := range []*models.Business{soloTrashed, sharedTrashed} {
+		if err := businesses.DeleteBusiness(business.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users := NewUserService(db)
+	users.Storage = store
+	if err := users.EraseUser(alice.ID); err != nil {
+		t.Fatalf("EraseUser: %v", err)
+	}
+
+	tests := []struct {
+		business *models.Business
+		wantKept bool
+	}{
+		{solo, false},
+		{soloTrashed, false},
+		{shared, true},
+		{sharedTrashed, true}, // Bob can still restore it
+	}
+	for _, tt := range tests {
+		var found models.Business
+		err := db.Unscoped().First(&found, tt.business.ID).Error
+		switch {
+		case !tt.wantKept && !errors.Is(err, gorm.ErrRecordNotFound):
+			t.Errorf("%s: error = %v, want it purged", tt.business.Name, err)
+		case tt.wantKept && err != nil:
+			t.Errorf("%s was purged: %v", tt.business.Name, err)
+		case tt.wantKept && found.UserID != bob.ID:
+			t.Errorf("%s belongs to user %d, want the remaining owner %d", tt.business.Name, found.UserID, bob.ID)
+		}
+	}
+
+	if _, _, err := store.Get(legalKey); err != nil {
+		t.Errorf("the shared business's upload was removed: %v", err)
+	}
+	items, err := businesses.ListTrashedBusinesses(bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].EntityID != sharedTrashed.ID {
+		t.Errorf("bob's trash = %+v, want %s", items, sharedTrashed.Name)
+	}
+}
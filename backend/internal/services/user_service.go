@@ -2,20 +2,46 @@ package services
 
 import (
 	"go-gin-backend/internal/models"
+	"go-gin-backend/internal/storage"
+	"go-gin-backend/internal/utils"
 
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Storage storage.Storage // Holds the uploads of the user's businesses
+	Actor   AuditActor      // Recorded in the audit log for every change
 }
 
 func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{DB: db}
+	return &UserService{DB: db, Storage: storage.Files}
+}
+
+// WithActor returns a copy of the service that attributes its changes to the actor
+func (s *UserService) WithActor(actor AuditActor) *UserService {
+	scoped := *s
+	scoped.Actor = actor
+	return &scoped
+}
+
+func (s *UserService) audit(tx *gorm.DB, userID uint, action string, before, after interface{}) error {
+	return recordAudit(tx, s.Actor, nil, models.AuditEntityUser, userID, action, before, after)
 }
 
 func (s *UserService) CreateUser(user *models.User) error {
-	return s.DB.Create(user).Error
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		// Users signing up on their own are the actor of their creation
+		actor := s.Actor
+		if actor.UserID == 0 {
+			actor.UserID = user.ID
+		}
+		return recordAudit(tx, actor, nil, models.AuditEntityUser, user.ID, models.AuditActionCreate, nil, user)
+	})
 }
 
 func (s *UserService) GetUserByID(id uint) (*models.User, error) {
@@ -34,16 +60,63 @@ func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByPhoneNumber finds a user by phone number in any of its common formats
+func (s *UserService) GetUserByPhoneNumber(phoneNumber string) (*models.User, error) {
+	var user models.User
+	if err := s.DB.Where("phone_number IN ?", utils.PhoneNumberVariants(phoneNumber)).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser updates the user's profile fields. Password and role are never touched here.
+// A changed email address or phone number has to be verified again.
 func (s *UserService) UpdateUser(user *models.User) error {
-	if err := s.DB.Save(user).Error; err != nil {
+	existing, err := s.GetUserByID(user.ID)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	fields := []string{"username", "email", "phone_number"}
+	if existing.Email != user.Email {
+		user.EmailVerifiedAt = nil
+		fields = append(fields, "email_verified_at")
+	}
+	user.PhoneNumber = utils.NormalizePhoneNumber(user.PhoneNumber)
+	if utils.NormalizePhoneNumber(existing.PhoneNumber) != user.PhoneNumber {
+		user.PhoneVerifiedAt = nil
+		fields = append(fields, "phone_verified_at")
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Select(fields).Updates(user).Error; err != nil {
+			return err
+		}
+		if err := tx.First(user, user.ID).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, user.ID, models.AuditActionUpdate, existing, user)
+	})
 }
 
 func (s *UserService) DeleteUser(id uint) error {
-	if err := s.DB.Delete(&models.User{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.User
+		if err := tx.First(&before, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&before).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, id, models.AuditActionDelete, &before, nil)
+	})
 }
@@ -0,0 +1,57 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPSender posts messages to an SMS gateway that accepts a JSON payload
+type HTTPSender struct {
+	GatewayURL string
+	APIKey     string
+	SenderID   string
+	Client     *http.Client
+}
+
+func NewHTTPSender(gatewayURL, apiKey, senderID string) *HTTPSender {
+	return &HTTPSender{
+		GatewayURL: gatewayURL,
+		APIKey:     apiKey,
+		SenderID:   senderID,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HTTPSender) Send(phoneNumber, message string) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":      phoneNumber,
+		"from":    s.SenderID,
+		"message": message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.GatewayURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalSender is used in development and tests. It logs every message and,
+// when Dir is set, also appends it to a per-number file so codes can be read back offline.
+type LocalSender struct {
+	Dir string
+}
+
+func NewLocalSender(dir string) *LocalSender {
+	return &LocalSender{Dir: dir}
+}
+
+func (s *LocalSender) Send(phoneNumber, message string) error {
+	log.Printf("[sms] to=%s message=%q", phoneNumber, message)
+
+	if s.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sms directory: %w", err)
+	}
+
+	filename := strings.TrimPrefix(phoneNumber, "+") + ".txt"
+	f, err := os.OpenFile(filepath.Join(s.Dir, filename), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s\t%s\n", time.Now().Format(time.RFC3339), message); err != nil {
+		return fmt.Errorf("failed to write sms: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package sms
+
+import "os"
+
+// SMSSender delivers text messages to phone numbers
+type SMSSender interface {
+	Send(phoneNumber, message string) error
+}
+
+// NewFromEnv builds the sender selected by SMS_DRIVER ("http" or "local").
+// The local sender is used by default so development never sends real messages.
+func NewFromEnv() SMSSender {
+	switch os.Getenv("SMS_DRIVER") {
+	case "http":
+		return NewHTTPSender(os.Getenv("SMS_GATEWAY_URL"), os.Getenv("SMS_GATEWAY_API_KEY"), os.Getenv("SMS_SENDER_ID"))
+	default:
+		return NewLocalSender(os.Getenv("SMS_LOCAL_DIR"))
+	}
+}
@@ -0,0 +1,97 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"", "*sms.LocalSender"},
+		{"local", "*sms.LocalSender"},
+		{"http", "*sms.HTTPSender"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			t.Setenv("SMS_DRIVER", tt.driver)
+			if got := fmt.Sprintf("%T", NewFromEnv()); got != tt.want {
+				t.Errorf("NewFromEnv() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalSenderAppendsMessages(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalSender(dir)
+
+	for _, message := range []string{"first code", "second code"} {
+		if err := s.Send("+6281234567890", message); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "6281234567890.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), content)
+	}
+	for i, want := range []string{"first code", "second code"} {
+		if !strings.HasSuffix(lines[i], "\t"+want) {
+			t.Errorf("line %d = %q, want it to end with %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestHTTPSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"accepted", http.StatusOK, false},
+		{"rejected", http.StatusBadRequest, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]string
+			var auth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("invalid payload: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewHTTPSender(server.URL, "secret", "APP").Send("+6281234567890", "hello")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, want error %v", err, tt.wantErr)
+			}
+			if auth != "Bearer secret" {
+				t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+			}
+			want := map[string]string{"to": "+6281234567890", "from": "APP", "message": "hello"}
+			for k, v := range want {
+				if payload[k] != v {
+					t.Errorf("payload[%q] = %q, want %q", k, payload[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Package spreadsheet reads the first sheet of CSV and XLSX uploads into rows of text cells.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Formats that can be read
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for files that are neither CSV nor XLSX
+	ErrUnsupportedFormat = errors.New("unsupported file format, upload a CSV or XLSX file")
+	// ErrTooManyRows is returned when a sheet has more rows than the caller allows
+	ErrTooManyRows = errors.New("the sheet has too many rows")
+)
+
+var xlsxMagic = []byte("PK\x03\x04")
+
+// DetectFormat picks the format from the file name, falling back to the content
+func DetectFormat(filename string, data []byte) (string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv", ".txt":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	case ".xls":
+		return "", fmt.Errorf("%w: save .xls workbooks as .xlsx", ErrUnsupportedFormat)
+	}
+	if bytes.HasPrefix(data, xlsxMagic) {
+		return FormatXLSX, nil
+	}
+	if !bytes.ContainsRune(data, 0) {
+		return FormatCSV, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Read returns the rows of the file's first sheet, stopping with ErrTooManyRows when there are
+// more than maxRows. Trailing empty cells are kept so every row can be indexed by column.
+func Read(format string, data []byte, maxRows int) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(data, maxRows)
+	case FormatXLSX:
+		return readXLSX(data, maxRows)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(data []byte, maxRows int) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows) >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// detectDelimiter tells comma separated files from the semicolon separated ones spreadsheet
+// programs write in locales that use the comma as decimal separator
+func detectDelimiter(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	if bytes.Count(line, []byte("\t")) > bytes.Count(line, []byte(",")) {
+		return '\t'
+	}
+	return ','
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText is rich or plain text: either a single <t> or runs of <r><t>
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("invalid XLSX: the workbook has no worksheet")
+	}
+	var sheet xlsxSheet
+	if err := decodeZipXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		rowNumber := row.R
+		if rowNumber == 0 {
+			rowNumber = len(rows) + 1
+		}
+		if rowNumber > maxRows || i >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		// Rows without cells are left out of the XML, keep the numbering the user sees
+		for len(rows) < rowNumber {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			col := j
+			if cell.Ref != "" {
+				if col, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid XLSX: bad shared string in cell %s", cell.Ref)
+				}
+				cells[col] = shared.Items[idx].String()
+			case "inlineStr":
+				cells[col] = cell.Inline.String()
+			case "b":
+				if cell.Value == "1" {
+					cells[col] = "TRUE"
+				} else {
+					cells[col] = "FALSE"
+				}
+			default:
+				cells[col] = cell.Value
+			}
+		}
+		rows[rowNumber-1] = cells
+	}
+	return rows, nil
+}
+
+// firstSheetPath resolves the first sheet of the workbook to its part in the archive
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	wbFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", errors.New("invalid XLSX: missing workbook")
+	}
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(wbFile, &workbook); err != nil {
+		return "", err
+	}
+	relFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if len(workbook.Sheets) == 0 || !ok {
+		return fallback, nil
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(relFile, &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+// Parts larger than this are refused, which keeps compressed bombs from exhausting memory
+const maxXMLPartSize = 64 << 20
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > maxXMLPartSize {
+		return fmt.Errorf("invalid XLSX: %s is too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("invalid XLSX: %w", err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXMLPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("invalid XLSX: %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex turns a cell reference such as "C12" into the zero-based column index 2
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for i, r := range ref {
+		if r >= 'A' && r <= 'Z' {
+			col = col*26 + int(r-'A'+1)
+			continue
+		}
+		if i == 0 {
+			break
+		}
+		return col - 1, nil
+	}
+	return 0, fmt.Errorf("invalid XLSX: bad cell reference %q", ref)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalSignedURL(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/uploads", []byte("secret"))
+
+	signed, err := s.SignedURL("legal/business/1/permit #2.pdf", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ok := strings.CutPrefix(u.Path, "/uploads/")
+	if !ok || key != "legal/business/1/permit #2.pdf" {
+		t.Fatalf("SignedURL() = %s, want a link to the file under /uploads", signed)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+
+	if !s.VerifySignedURL(key, expires, signature) {
+		t.Error("the signed URL is not accepted")
+	}
+	if s.VerifySignedURL("legal/business/1/other.pdf", expires, signature) {
+		t.Error("the signature is accepted for another file")
+	}
+	if s.VerifySignedURL(key, expires, strings.Repeat("0", len(signature))) {
+		t.Error("a forged signature is accepted")
+	}
+	if s.VerifySignedURL(key, "never", signature) {
+		t.Error("the signature is accepted without a valid expiry")
+	}
+	if s.VerifySignedURL(key, expires+"0", signature) {
+		t.Error("the signature is accepted with a later expiry")
+	}
+	other := NewLocalStorage(s.Dir, s.URLPrefix, []byte("another secret"))
+	if other.VerifySignedURL(key, expires, signature) {
+		t.Error("the signature is accepted with another key")
+	}
+
+	expired, err := s.SignedURL(key, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err = url.Parse(expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.VerifySignedURL(key, u.Query().Get("expires"), u.Query().Get("signature")) {
+		t.Error("an expired link is accepted")
+	}
+
+	if _, err := s.SignedURL("../secret", time.Minute); err != ErrInvalidKey {
+		t.Errorf("SignedURL with an invalid key: error = %v, want %v", err, ErrInvalidKey)
+	}
+}
@@ -10,34 +10,84 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func GetUserIDFromContext(c *gin.Context) (uint, error) {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		return 0, errors.New("missing authorization header")
+// Token audiences keep short-lived login challenges from being used as access tokens
+const (
+	TokenAudienceAccess             = "access"
+	TokenAudienceTwoFactorChallenge = "2fa_challenge"
+)
+
+// Claims are the JWT claims issued to authenticated users
+type Claims struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.StandardClaims
+}
+
+// GetJWTSecret returns the secret used to sign and verify tokens
+func GetJWTSecret() []byte {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		jwtSecret = "your-secret-key" // Default for development
 	}
+	return []byte(jwtSecret)
+}
 
+// ParseToken validates a signed token and returns its claims
+func ParseToken(tokenString string) (*Claims, error) {
 	// Remove Bearer prefix if present
 	if after, ok := strings.CutPrefix(tokenString, "Bearer "); ok {
 		tokenString = after
 	}
 
-	// Load secret from environment
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-
-	// Parse JWT
-	claims := &jwt.StandardClaims{}
+	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return GetJWTSecret(), nil
 	})
 	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	// Convert subject (sub) => userID
-	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	return claims, nil
+}
+
+// GetUserIDFromContext returns the user the auth middleware authenticated, which also checks
+// the token's audience and that its session is still live. Routes without the middleware
+// have no user.
+func GetUserIDFromContext(c *gin.Context) (uint, error) {
+	subject := c.GetString("userID")
+	if subject == "" {
+		return 0, errors.New("not authenticated")
+	}
+	userID, err := strconv.ParseUint(subject, 10, 32)
 	if err != nil {
 		return 0, errors.New("invalid user ID in token")
 	}
-
 	return uint(userID), nil
 }
+
+// GetSessionIDFromContext returns the session set by the auth middleware
+func GetSessionIDFromContext(c *gin.Context) (uint, error) {
+	sessionID := c.GetUint("sessionID")
+	if sessionID == 0 {
+		return 0, errors.New("missing session")
+	}
+	return sessionID, nil
+}
+
+// GetUserRoleFromContext returns the role set by the auth middleware
+func GetUserRoleFromContext(c *gin.Context) string {
+	return c.GetString("role")
+}
+
+// HasPermission reports whether the authenticated user was granted the permission
+func HasPermission(c *gin.Context, permission string) bool {
+	for _, p := range c.GetStringSlice("permissions") {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
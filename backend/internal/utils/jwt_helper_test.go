@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetUserIDFromContext(t *testing.T) {
+	// A validly signed token only counts once the auth middleware has checked its session
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{StandardClaims: jwt.StandardClaims{
+		Subject:   "7",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}}).SignedString(GetJWTSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string // Set by the auth middleware
+		want    uint
+		wantErr bool
+	}{
+		{name: "authenticated", userID: "7", want: 7},
+		{name: "token without the middleware", wantErr: true},
+		{name: "malformed subject", userID: "seven", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/", nil)
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+			if tt.userID != "" {
+				c.Set("userID", tt.userID)
+			}
+
+			got, err := GetUserIDFromContext(c)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("GetUserIDFromContext() = %d, %v; want %d, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
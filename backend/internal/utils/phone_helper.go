@@ -0,0 +1,31 @@
+package utils
+
+import "strings"
+
+// NormalizePhoneNumber converts Indonesian phone numbers to the +62 format,
+// e.g. "0812-3456-789" and "62812345678" both become "+62812345678".
+func NormalizePhoneNumber(phone string) string {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		return phone
+	case strings.HasPrefix(phone, "0"):
+		return "+62" + phone[1:]
+	case strings.HasPrefix(phone, "62"):
+		return "+" + phone
+	default:
+		return phone
+	}
+}
+
+// PhoneNumberVariants returns the formats a phone number may have been stored in,
+// so records saved before normalization can still be found.
+func PhoneNumberVariants(phone string) []string {
+	normalized := NormalizePhoneNumber(phone)
+	variants := []string{normalized, strings.TrimSpace(phone)}
+	if local, ok := strings.CutPrefix(normalized, "+62"); ok {
+		variants = append(variants, "0"+local, "62"+local)
+	}
+	return variants
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The RFC 6238 appendix B secret, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B SHA-1 vectors, cut to the six digits authenticator apps show
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestHOTPMatchesRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, v := range rfc6238Vectors {
+		if got := hotp(key, v.unix/totpPeriod); got != v.code {
+			t.Errorf("hotp at %d = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		at := time.Unix(v.unix, 0)
+		step, ok := ValidateTOTP(rfc6238Secret, v.code, at)
+		if !ok {
+			t.Errorf("ValidateTOTP(%s) at %d rejected the code", v.code, v.unix)
+			continue
+		}
+		if want := v.unix / totpPeriod; step != want {
+			t.Errorf("ValidateTOTP(%s) at %d = step %d, want %d", v.code, v.unix, step, want)
+		}
+	}
+
+	at := time.Unix(1111111111, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		want   bool
+	}{
+		{"previous step", rfc6238Secret, "050471", at.Add(totpPeriod * time.Second), true},
+		{"next step", rfc6238Secret, "050471", at.Add(-totpPeriod * time.Second), true},
+		{"two steps late", rfc6238Secret, "050471", at.Add(2 * totpPeriod * time.Second), false},
+		{"surrounding spaces", rfc6238Secret, " 050471 ", at, true},
+		{"lowercase secret", strings.ToLower(rfc6238Secret), "050471", at, true},
+		{"wrong code", rfc6238Secret, "050472", at, false},
+		{"too short", rfc6238Secret, "50471", at, false},
+		{"too long", rfc6238Secret, "0050471", at, false},
+		{"invalid secret", "not base32!", "050471", at, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, tt.at); ok != tt.want {
+				t.Errorf("ValidateTOTP() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret is %d bytes, want 20", len(key))
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("ITBuddy", "user@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/ITBuddy:user@example.com" {
+		t.Errorf("URI = %s, want otpauth://totp/ITBuddy:user@example.com", uri)
+	}
+	want := map[string]string{"secret": rfc6238Secret, "issuer": "ITBuddy", "algorithm": "SHA1", "digits": "6", "period": "30"}
+	for k, v := range want {
+		if got := uri.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}